@@ -0,0 +1,325 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+	"time"
+)
+
+// archivedFields are the default fields of the archived command.
+const archivedFields = "id,description,status,project,tags,completed,archived"
+
+// Archive holds the tasks moved out of a list by archive and delete. It is
+// kept next to the store, so the list itself stays small. taken holds the
+// tasks moved back into the list until the list is saved.
+type Archive struct {
+	Todos Todos `json:"todos"`
+
+	path  string
+	dirty bool
+	taken Todos
+}
+
+// archivePath returns the archive of the store at storePath.
+func archivePath(storePath string) string {
+	return storePath + ".archive"
+}
+
+// OpenArchive loads the archive at path. A missing archive is empty.
+func OpenArchive(path string) (*Archive, error) {
+	a := &Archive{}
+	if err := NewStorage[Archive](path).Load(a); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("reading archive: %w", err)
+	}
+	a.path = path
+	return a, nil
+}
+
+// Save writes the archive back if it changed. Tasks taken out are still
+// written, so they are not lost if the list cannot be saved; release drops
+// them once it is.
+func (a *Archive) Save() error {
+	if !a.dirty {
+		return nil
+	}
+	saved := Archive{Todos: append(slices.Clip(a.Todos), a.taken...)}
+	if err := NewStorage[Archive](a.path).Save(saved); err != nil {
+		return fmt.Errorf("writing archive: %w", err)
+	}
+	a.dirty = false
+	return nil
+}
+
+// release forgets the tasks taken out of the archive after the list they
+// went back to was saved, for the next Save to drop them.
+func (a *Archive) release() {
+	if len(a.taken) > 0 {
+		a.taken = nil
+		a.dirty = true
+	}
+}
+
+// put adds t as archived at now, replacing an older copy of it.
+func (a *Archive) put(t Todo, now time.Time) {
+	t.ArchivedAt = &now
+	t.Progress = nil
+	if index, err := a.Todos.IndexOf(t.ID); err == nil {
+		a.Todos[index] = t
+	} else {
+		a.Todos = append(a.Todos, t)
+	}
+	a.dirty = true
+}
+
+// take removes the task id from the archive and returns it.
+func (a *Archive) take(id int) (Todo, error) {
+	index, err := a.Todos.IndexOf(id)
+	if err != nil {
+		return Todo{}, fmt.Errorf("task %d is not archived", id)
+	}
+	t := a.Todos[index]
+	a.Todos = slices.Delete(a.Todos, index, index+1)
+	a.taken = append(a.taken, t)
+	a.dirty = true
+	t.ArchivedAt = nil
+	return t, nil
+}
+
+// Archive opens the archive of the store on first use.
+func (ctx *Context) Archive() (*Archive, error) {
+	if ctx.archive == nil {
+		a, err := OpenArchive(ctx.archivePath)
+		if err != nil {
+			return nil, err
+		}
+		ctx.archive = a
+	}
+	return ctx.archive, nil
+}
+
+// archiveTodo moves the task id from the list into the archive. Subtasks
+// and tasks blocked by it are unlinked as by a delete.
+func (ctx *Context) archiveTodo(id int, now time.Time) error {
+	a, err := ctx.Archive()
+	if err != nil {
+		return err
+	}
+	index, err := ctx.List.IndexOf(id)
+	if err != nil {
+		return err
+	}
+	t := cloneTodo(ctx.List.Todos[index])
+	if err := ctx.List.delete(id); err != nil {
+		return err
+	}
+	a.put(t, now)
+	ctx.archived = append(ctx.archived, id)
+	return nil
+}
+
+// restoreTodo moves the task id from the archive back into the list under
+// its old ID. Links to tasks that are gone are dropped.
+func (ctx *Context) restoreTodo(id int) error {
+	if _, err := ctx.List.IndexOf(id); err == nil {
+		return fmt.Errorf("task %d is already in the list", id)
+	}
+	a, err := ctx.Archive()
+	if err != nil {
+		return err
+	}
+	t, err := a.take(id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := ctx.List.IndexOf(t.ParentID); err != nil {
+		t.ParentID = 0
+	}
+	t.BlockedBy = slices.DeleteFunc(t.BlockedBy, func(b int) bool {
+		_, err := ctx.List.IndexOf(b)
+		return err != nil
+	})
+	if len(t.BlockedBy) == 0 {
+		t.BlockedBy = nil
+	}
+	ctx.List.insert(t)
+	ctx.archived = append(ctx.archived, id)
+	return nil
+}
+
+// syncArchive moves the tasks of the archived changes of entry between the
+// list and the archive, after the entry was undone or redone.
+func (ctx *Context) syncArchive(entry JournalEntry, undo bool) error {
+	for _, c := range entry.Changes {
+		if !c.Archived {
+			continue
+		}
+		a, err := ctx.Archive()
+		if err != nil {
+			return err
+		}
+		from, to := c.sides(undo)
+		if to == nil {
+			a.put(cloneTodo(*from), entry.At)
+		} else {
+			a.take(c.ID)
+		}
+	}
+	return nil
+}
+
+// finishedAt is when a finished task was completed or, for a cancelled
+// one, last changed.
+func finishedAt(t Todo) time.Time {
+	if t.CompletedAt != nil {
+		return *t.CompletedAt
+	}
+	return lastChange(t)
+}
+
+func checkAutoArchive(value string) error {
+	_, err := parseDuration(value)
+	return err
+}
+
+// autoArchive archives the tasks finished longer ago than the auto-archive
+// setting allows, recorded as a journal entry of its own.
+func autoArchive(ctx *Context, now time.Time) error {
+	value, source := ctx.App.Setting("auto-archive")
+	if value == "" {
+		return nil
+	}
+	age, err := parseDuration(value)
+	if err != nil {
+		return fmt.Errorf("%s: invalid auto-archive: %w", source, err)
+	}
+
+	before := cloneTodos(ctx.List.Todos)
+	for _, t := range before {
+		if t.Status.Finished() && finishedAt(t).Before(now.Add(-age)) {
+			if err := ctx.archiveTodo(t.ID, now); err != nil {
+				return err
+			}
+		}
+	}
+	ctx.Journal.Record("archive (auto-archive "+value+")", before, ctx.List.Todos, ctx.takeArchived(), now)
+	return nil
+}
+
+// takeArchived returns the tasks the command moved between the list and the
+// archive so far, for the journal.
+func (ctx *Context) takeArchived() []int {
+	ids := ctx.archived
+	ctx.archived = nil
+	return ids
+}
+
+func newArchiveCmd() *Command {
+	flags := newFlagSet("archive")
+	sel := addSelectorFlags(flags)
+
+	return &Command{
+		Name:    "archive",
+		Args:    "[ids]",
+		Summary: "Move finished tasks into the archive, by default all of them.",
+		Flags:   flags,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) > 1 {
+				return usageErrorf("expected one list of task ids")
+			}
+
+			now := time.Now()
+			var ids []int
+			if len(args) == 0 && !sel.filtering() {
+				for _, t := range ctx.List.Todos {
+					if t.Status.Finished() {
+						ids = append(ids, t.ID)
+					}
+				}
+			} else {
+				spec := ""
+				if len(args) == 1 {
+					spec = args[0]
+				}
+				selected, err := sel.selectIDs(ctx.List.Todos, spec, now)
+				if err != nil {
+					return err
+				}
+				for _, id := range selected {
+					index, _ := ctx.List.IndexOf(id)
+					t := ctx.List.Todos[index]
+					switch {
+					case t.Status.Finished():
+						ids = append(ids, id)
+					case spec != "":
+						return fmt.Errorf("task %d is %s, only finished tasks can be archived", id, t.Status)
+					}
+				}
+			}
+
+			// Archiving can be undone and restored, so it needs no confirmation
+			sel.yes = true
+			if ok, err := sel.confirm(ctx, "archive", "", ids); !ok {
+				return err
+			}
+			for _, id := range ids {
+				if err := ctx.archiveTodo(id, now); err != nil {
+					return err
+				}
+			}
+			fmt.Fprintf(ctx.Stdout, "Archived %d %s\n", len(ids), plural(len(ids), "task"))
+			return nil
+		},
+	}
+}
+
+func newArchivedCmd() *Command {
+	flags := newFlagSet("archived")
+	output := addOutputFlags(flags, archivedFields)
+
+	return &Command{
+		Name:     "archived",
+		Args:     "[list]",
+		Summary:  "Show the archived tasks.",
+		Flags:    flags,
+		ReadOnly: true,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) > 1 || len(args) == 1 && args[0] != "list" {
+				return usageErrorf("unexpected argument %q", strings.Join(args, " "))
+			}
+			a, err := ctx.Archive()
+			if err != nil {
+				return err
+			}
+			return output.render(ctx.Stdout, a.Todos)
+		},
+	}
+}
+
+func newRestoreCmd() *Command {
+	return &Command{
+		Name:    "restore",
+		Args:    "<ids>",
+		Summary: "Move archived or deleted tasks back into the list.",
+		Flags:   newFlagSet("restore"),
+		Run: func(ctx *Context, args []string) error {
+			if len(args) != 1 {
+				return usageErrorf("expected one list of task ids")
+			}
+			ids, err := parseIDs(args[0])
+			if err != nil {
+				return err
+			}
+			for _, id := range ids {
+				if err := ctx.restoreTodo(id); err != nil {
+					return err
+				}
+				fmt.Fprintf(ctx.Stdout, "Restored task %d\n", id)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Helper to read back the archive of the app's store
+func loadArchive(t *testing.T, app *App) Todos {
+	t.Helper()
+	path, err := app.StorePath()
+	if err != nil {
+		t.Fatalf("Failed to resolve store: %v", err)
+	}
+	a, err := OpenArchive(archivePath(path))
+	if err != nil {
+		t.Fatalf("Failed to load archive: %v", err)
+	}
+	return a.Todos
+}
+
+func TestDeleteArchivesAndRestores(t *testing.T) {
+	app := newTestApp(t)
+	runCmd(t, app, "add", "Parent")
+	runCmd(t, app, "add", "--parent", "1", "Child")
+	runCmd(t, app, "add", "Other")
+
+	if code, stdout, _ := runCmd(t, app, "delete", "1"); code != ExitOK || stdout != "Deleted task 1\n" {
+		t.Fatalf("Expected task 1 deleted, got %d: %q", code, stdout)
+	}
+	archived := loadArchive(t, app)
+	if len(archived) != 1 || archived[0].ID != 1 || archived[0].ArchivedAt == nil {
+		t.Fatalf("Expected task 1 in the archive, got %+v", archived)
+	}
+
+	_, stdout, _ := runCmd(t, app, "archived", "list")
+	if !strings.Contains(stdout, "Parent") {
+		t.Errorf("Expected the archived task listed, got '%s'", stdout)
+	}
+
+	if code, _, stderr := runCmd(t, app, "restore", "1"); code != ExitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", ExitOK, code, stderr)
+	}
+	todos := loadTodos(t, app)
+	if len(todos) != 3 || todos[0].ID != 1 || todos[0].ArchivedAt != nil {
+		t.Errorf("Expected task 1 back in place, got %+v", todos)
+	}
+	if len(loadArchive(t, app)) != 0 {
+		t.Error("Expected the archive to be empty after restore")
+	}
+	if code, _, _ := runCmd(t, app, "restore", "1"); code != ExitError {
+		t.Errorf("Expected exit code %d restoring an active task, got %d", ExitError, code)
+	}
+
+	runCmd(t, app, "delete", "--purge", "3")
+	if len(loadTodos(t, app)) != 2 || len(loadArchive(t, app)) != 0 {
+		t.Error("Expected -purge to skip the archive")
+	}
+}
+
+func TestArchiveCommand(t *testing.T) {
+	app := newTestApp(t)
+	runCmd(t, app, "add", "Done")
+	runCmd(t, app, "add", "Open")
+	runCmd(t, app, "add", "Cancelled")
+	runCmd(t, app, "mark", "1", "done")
+	runCmd(t, app, "mark", "3", "cancelled")
+
+	if code, _, _ := runCmd(t, app, "archive", "2"); code != ExitError {
+		t.Errorf("Expected exit code %d archiving an open task, got %d", ExitError, code)
+	}
+
+	_, stdout, _ := runCmd(t, app, "archive")
+	if stdout != "Archived 2 tasks\n" {
+		t.Errorf("Expected the finished tasks archived, got %q", stdout)
+	}
+	if todos := loadTodos(t, app); len(todos) != 1 || todos[0].ID != 2 {
+		t.Errorf("Expected only the open task left, got %+v", todos)
+	}
+
+	// Undo and redo move the tasks between the list and the archive
+	runCmd(t, app, "undo")
+	if len(loadTodos(t, app)) != 3 || len(loadArchive(t, app)) != 0 {
+		t.Error("Expected undo to bring the archived tasks back")
+	}
+	runCmd(t, app, "redo")
+	if len(loadTodos(t, app)) != 1 || len(loadArchive(t, app)) != 2 {
+		t.Error("Expected redo to archive the tasks again")
+	}
+}
+
+func TestAutoArchive(t *testing.T) {
+	app := newTestApp(t)
+	runCmd(t, app, "add", "Old")
+	runCmd(t, app, "add", "Recent")
+	runCmd(t, app, "mark", "1,2", "done", "-yes")
+
+	store, _ := app.Store()
+	list := TodoList{}
+	store.Load(&list)
+	old := time.Now().AddDate(0, 0, -20)
+	list.Todos[0].CompletedAt = &old
+	store.Save(list)
+
+	t.Setenv("TASK_AUTO_ARCHIVE", "14d")
+	runCmd(t, app, "add", "New")
+	todos := loadTodos(t, app)
+	if len(todos) != 2 || todos[0].ID != 2 {
+		t.Errorf("Expected the old task auto-archived, got %+v", todos)
+	}
+
+	_, stdout, _ := runCmd(t, app, "history")
+	if !strings.Contains(stdout, "archive (auto-archive 14d)") {
+		t.Errorf("Expected the auto-archive in the history, got '%s'", stdout)
+	}
+
+	t.Setenv("TASK_AUTO_ARCHIVE", "soon")
+	if code, _, _ := runCmd(t, app, "add", "Later"); code != ExitError {
+		t.Errorf("Expected exit code %d for an invalid policy, got %d", ExitError, code)
+	}
+}
+
+func TestArchiveKeepsTakenTasksUntilReleased(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.json.archive")
+	a, _ := OpenArchive(path)
+	a.put(Todo{ID: 1, Description: "Old"}, time.Now())
+	a.Save()
+
+	// A restored task stays archived until its list was saved
+	a.take(1)
+	a.Save()
+	if saved, _ := OpenArchive(path); len(saved.Todos) != 1 {
+		t.Errorf("Expected the taken task kept, got %+v", saved.Todos)
+	}
+	a.release()
+	a.Save()
+	if saved, _ := OpenArchive(path); len(saved.Todos) != 0 {
+		t.Errorf("Expected the released task dropped, got %+v", saved.Todos)
+	}
+}
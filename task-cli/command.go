@@ -1,74 +1,788 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// Exit codes returned by App.Run.
+const (
+	ExitOK    = 0
+	ExitError = 1
+	ExitUsage = 2
+)
+
+// Command is a single subcommand such as "task add". Every command owns its
+// flag set, positional argument synopsis and usage text. The changes of
+// commands that write are recorded in the journal unless NoJournal is set.
+// RawArgs passes the arguments on without parsing flags. Hidden commands
+// are left out of the usage and the man page.
 type Command struct {
-	Add    string
-	Del    int
-	Edit   string
-	Status string
-	List   bool
+	Name      string
+	Args      string
+	Summary   string
+	Flags     *flag.FlagSet
+	ReadOnly  bool
+	NoStore   bool
+	NoJournal bool
+	RawArgs   bool
+	Hidden    bool
+	Run       func(ctx *Context, args []string) error
+}
+
+// Context is what a command runs against. Journal is only open for commands
+// that write, the archive and sync state of the list once a command asks
+// for them. gitMerge and gitPush are set by a sync for commitGit, gitFiles
+// by commands that write other lists of the repository. renumbered holds
+// the local tasks a merge moved to new IDs, moved the copies of the tasks a
+// move added to other lists. rollbacks revert those other lists when the
+// command fails before its own list is saved.
+type Context struct {
+	App     *App
+	List    *TodoList
+	Journal *Journal
+	Stdout  io.Writer
+	Stderr  io.Writer
+
+	archive     *Archive
+	archivePath string
+	archived    []int
+	gitMerge    string
+	gitPush     string
+	syncState   *SyncState
+	gitFiles    []string
+	renumbered  map[int]int
+	moved       map[int]movedTodo
+	rollbacks   []func() error
+}
+
+// UsageError reports a command invoked with bad arguments. It makes the
+// command print its usage and exit with ExitUsage.
+type UsageError struct {
+	Msg string
+}
+
+func (e *UsageError) Error() string {
+	return e.Msg
+}
+
+func usageErrorf(format string, a ...any) error {
+	return &UsageError{Msg: fmt.Sprintf(format, a...)}
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
 }
 
-func NewCmdFlags() *Command {
-	cf := Command{}
+// PrintUsage writes the command synopsis, summary and flag defaults to w.
+func (cmd *Command) PrintUsage(w io.Writer) {
+	synopsis := "task " + cmd.Name
+	if hasFlags(cmd.Flags) {
+		synopsis += " [flags]"
+	}
+	if cmd.Args != "" {
+		synopsis += " " + cmd.Args
+	}
+	fmt.Fprintf(w, "usage: %s\n\n%s\n", synopsis, cmd.Summary)
 
-	flag.StringVar(&cf.Add, "add", "", "add todo")
-	flag.StringVar(&cf.Edit, "update", "", "update task name")
-	flag.StringVar(&cf.Status, "status", "", "change task status")
-	flag.IntVar(&cf.Del, "delete", -1, "delete task by id")
-	flag.BoolVar(&cf.List, "list", false, "print all task")
+	if hasFlags(cmd.Flags) {
+		fmt.Fprintln(w, "\nflags:")
+		cmd.Flags.SetOutput(w)
+		cmd.Flags.PrintDefaults()
+		cmd.Flags.SetOutput(io.Discard)
+	}
+}
 
-	flag.Parse()
+func hasFlags(fs *flag.FlagSet) bool {
+	found := false
+	fs.VisitAll(func(*flag.Flag) { found = true })
+	return found
+}
 
-	return &cf
+// resetFlags restores every flag to its default so a command can be run
+// more than once. Custom flag.Values treat their empty default as a reset.
+func resetFlags(fs *flag.FlagSet) {
+	fs.VisitAll(func(f *flag.Flag) {
+		f.Value.Set(f.DefValue)
+	})
 }
 
-func (cf *Command) Execute(todos *Todos) {
-	switch {
-	case cf.Add != "":
-		todos.add(cf.Add)
-	case cf.List:
-		todos.Print()
-	case cf.Edit != "":
-		parts := strings.SplitN(cf.Edit, ":", 2)
-		if len(parts) != 2 {
-			fmt.Println("Error, invalid format. Please use id:new_description")
-			os.Exit(1)
+// parseArgs parses fs allowing flags and positional arguments to be mixed,
+// e.g. `task add "Buy milk" -x`.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
 		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// parseID parses a task reference given on the command line.
+func parseID(arg string) (int, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, usageErrorf("invalid task id %q", arg)
+	}
+	return id, nil
+}
+
+// App wires the subcommands to the store and output streams. Backend,
+// File and List hold the global flags, which override the settings of
+// Config.
+type App struct {
+	Backend  string
+	File     string
+	List     string
+	Config   *Config
+	Stdin    io.Reader
+	Stdout   io.Writer
+	Stderr   io.Writer
+	Commands []*Command
+	globals  *flag.FlagSet
+}
+
+func NewApp(stdout, stderr io.Writer) *App {
+	app := &App{
+		Stdin:  os.Stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+		Commands: []*Command{
+			newAddCmd(),
+			newListCmd(),
+			newUpdateCmd(),
+			newEditCmd(),
+			newNoteCmd(),
+			newShowCmd(),
+			newMarkCmd(),
+			newDeleteCmd(),
+			newArchiveCmd(),
+			newArchivedCmd(),
+			newRestoreCmd(),
+			newOverdueCmd(),
+			newUpcomingCmd(),
+			newStartCmd(),
+			newStopCmd(),
+			newReportCmd(),
+			newStatsCmd(),
+			newUndoCmd(),
+			newRedoCmd(),
+			newHistoryCmd(),
+			newListsCmd(),
+			newListCreateCmd(),
+			newListRenameCmd(),
+			newListDefaultCmd(),
+			newMoveCmd(),
+			newMigrateStoreCmd(),
+			newDoctorCmd(),
+			newImportCmd(),
+			newExportCmd(),
+			newRemoteCmd(),
+			newSyncCmd("push", syncPush, "Send the changes to tasks to the todo-list-api server."),
+			newSyncCmd("pull", syncPull, "Fetch the changes to todos from the todo-list-api server."),
+			newSyncCmd("sync", syncBoth, "Push and pull, keeping the newer side of conflicting changes. With git on, merge the git remote first."),
+			newGitCmd(),
+			newConfigCmd(),
+			newTUICmd(),
+			newCompletionCmd(),
+			newCompleteCmd(),
+			newManCmd(),
+			newHelpCmd(),
+		},
+	}
 
-		index, err := strconv.Atoi(parts[0])
+	app.globals = newFlagSet("task")
+	app.globals.StringVar(&app.Backend, "store", "", "storage backend: "+strings.Join(StoreBackends(), ", ")+" (default \"json\")")
+	app.globals.StringVar(&app.File, "file", "", "path of a task store to use instead of a named list")
+	app.globals.StringVar(&app.List, "list", "", "named list to use (default: the default list)")
+
+	return app
+}
 
+// Setting returns the value of a setting and where it came from. A global
+// flag of the same name takes precedence over the environment and the
+// config file.
+func (app *App) Setting(name string) (value, source string) {
+	if f := app.globals.Lookup(name); f != nil && f.Value.String() != "" {
+		return f.Value.String(), "flag -" + name
+	}
+	return app.Config.Lookup(name)
+}
+
+// StoreBackend returns the name of the selected storage backend.
+func (app *App) StoreBackend() string {
+	backend, _ := app.Setting("store")
+	return backend
+}
+
+// Store opens the store selected by the store, file and list settings.
+func (app *App) Store() (Store, error) {
+	path, err := app.StorePath()
+	if err != nil {
+		return nil, err
+	}
+	return OpenStore(app.StoreBackend(), path)
+}
+
+// StorePath returns the file of the selected store. A file setting wins
+// over the list setting unless -list is given.
+func (app *App) StorePath() (string, error) {
+	if app.File != "" && app.List != "" {
+		return "", usageErrorf("-file and -list cannot be combined")
+	}
+	file, _ := app.Setting("file")
+	if app.List != "" {
+		file = ""
+	}
+	return StorePath(app.StoreBackend(), file, app.ListName())
+}
+
+// ListName returns the name of the selected list.
+func (app *App) ListName() string {
+	name, _ := app.Setting("list")
+	return name
+}
+
+func (app *App) Lookup(name string) *Command {
+	for _, cmd := range app.Commands {
+		if cmd.Name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// PrintUsage writes the list of subcommands to w.
+func (app *App) PrintUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: task [global flags] <command> [flags] [args]")
+	fmt.Fprintln(w, "\ncommands:")
+	for _, cmd := range app.Commands {
+		if cmd.Hidden {
+			continue
+		}
+		fmt.Fprintf(w, "  %-14s %s\n", cmd.Name, cmd.Summary)
+	}
+	fmt.Fprintln(w, "\nglobal flags:")
+	app.globals.SetOutput(w)
+	app.globals.PrintDefaults()
+	app.globals.SetOutput(io.Discard)
+	fmt.Fprintln(w, "\nRun 'task help <command>' for details.")
+}
+
+// Run executes the subcommand named by args[0] against the stored tasks and
+// returns the process exit code. The store stays locked from Load until
+// Save, and tasks are only written back when a mutating command succeeds.
+// The archive is written before and after the store, so tasks moving
+// between the two are never lost. The sync state and the journal follow
+// the store, the git commit comes last.
+func (app *App) Run(args []string) int {
+	err := app.globals.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		app.PrintUsage(app.Stdout)
+		return ExitOK
+	}
+	if err != nil {
+		fmt.Fprintf(app.Stderr, "task: %v\n\n", err)
+		app.PrintUsage(app.Stderr)
+		return ExitUsage
+	}
+
+	cfg, err := LoadConfig()
+	if err == nil {
+		err = cfg.applyDisplay()
+	}
+	if err != nil {
+		fmt.Fprintf(app.Stderr, "task: %v\n", err)
+		return ExitError
+	}
+	app.Config = cfg
+
+	args = app.globals.Args()
+	if len(args) == 0 {
+		app.PrintUsage(app.Stderr)
+		return ExitUsage
+	}
+
+	cmd := app.Lookup(args[0])
+	if cmd == nil {
+		fmt.Fprintf(app.Stderr, "task: unknown command %q\n\n", args[0])
+		app.PrintUsage(app.Stderr)
+		return ExitUsage
+	}
+
+	positional := args[1:]
+	if !cmd.RawArgs {
+		resetFlags(cmd.Flags)
+		positional, err = parseArgs(cmd.Flags, args[1:])
+		if errors.Is(err, flag.ErrHelp) {
+			cmd.PrintUsage(app.Stdout)
+			return ExitOK
+		}
 		if err != nil {
-			fmt.Println("Invalid Index")
-			os.Exit(1)
+			return app.fail(cmd, &UsageError{Msg: err.Error()})
+		}
+	}
+
+	ctx := &Context{App: app, Stdout: app.Stdout, Stderr: app.Stderr}
+	if cmd.NoStore {
+		if err := cmd.Run(ctx, positional); err != nil {
+			return app.fail(cmd, err)
+		}
+		return ExitOK
+	}
+
+	path, err := app.StorePath()
+	if err != nil {
+		return app.fail(cmd, err)
+	}
+	store, _ := OpenStore(app.StoreBackend(), path)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return app.fail(cmd, err)
+	}
+	if !cmd.ReadOnly && gitEnabled(app) {
+		if err := checkGitStore(app, path); err != nil {
+			return app.fail(cmd, err)
+		}
+	}
+
+	lock, err := LockStore(path, !cmd.ReadOnly)
+	if err != nil {
+		return app.fail(cmd, err)
+	}
+	defer lock.Unlock()
+
+	list := TodoList{}
+	if err := store.Load(&list); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return app.fail(cmd, err)
+	}
+
+	ctx.List = &list
+	ctx.archivePath = archivePath(path)
+	var before Todos
+	if !cmd.ReadOnly {
+		if ctx.Journal, err = OpenJournal(journalPath(path)); err != nil {
+			return app.fail(cmd, err)
+		}
+		if !cmd.NoJournal {
+			if err := autoArchive(ctx, time.Now()); err != nil {
+				return app.fail(cmd, err)
+			}
+		}
+		before = cloneTodos(list.Todos)
+	}
+
+	list.Todos.rollup()
+	err = cmd.Run(ctx, positional)
+	if len(ctx.renumbered) > 0 {
+		renumbered := TodoList{Todos: before}
+		renumbered.renumber(ctx.renumbered)
+		before = renumbered.Todos
+	}
+	var partial *PartialError
+	if err != nil && (cmd.ReadOnly || !errors.As(err, &partial)) {
+		return app.fail(cmd, ctx.rollBack(err))
+	}
+
+	if !cmd.ReadOnly {
+		// A task left in the archive as well is harmless, restore
+		// refuses IDs the list holds
+		if ctx.archive != nil {
+			if err := ctx.archive.Save(); err != nil {
+				return app.fail(cmd, ctx.rollBack(err))
+			}
+		}
+		if err := store.Save(list); err != nil {
+			return app.fail(cmd, ctx.rollBack(err))
+		}
+		if ctx.archive != nil {
+			ctx.archive.release()
+			if err := ctx.archive.Save(); err != nil {
+				return app.fail(cmd, err)
+			}
 		}
+		if ctx.syncState != nil {
+			if err := ctx.syncState.Save(); err != nil {
+				return app.fail(cmd, err)
+			}
+		}
+		if !cmd.NoJournal {
+			ctx.Journal.Record(commandLine(args), before, list.Todos, ctx.takeArchived(), time.Now())
+			ctx.Journal.markMoved(ctx.moved)
+		}
+		if err := ctx.Journal.Save(); err != nil {
+			return app.fail(cmd, err)
+		}
+		if err := ctx.commitGit(path, args, before); err != nil {
+			return app.fail(cmd, err)
+		}
+	}
+	if partial != nil {
+		return app.fail(cmd, partial)
+	}
+	return ExitOK
+}
 
-		todos.update(parts[1], index)
-	case cf.Status != "":
-		parts := strings.SplitN(cf.Status, ":", 2)
-		if len(parts) != 2 {
-			fmt.Println("Error, invalid format. Please use id:new_status")
-			os.Exit(1)
+// rollBack reverts the changes the command made to other lists, newest
+// first, after it failed with err.
+func (ctx *Context) rollBack(err error) error {
+	errs := []error{err}
+	for i := len(ctx.rollbacks) - 1; i >= 0; i-- {
+		if rbErr := ctx.rollbacks[i](); rbErr != nil {
+			errs = append(errs, fmt.Errorf("rolling back: %w", rbErr))
 		}
+	}
+	ctx.rollbacks = nil
+	return errors.Join(errs...)
+}
 
-		index, err := strconv.Atoi(parts[0])
+func (app *App) fail(cmd *Command, err error) int {
+	fmt.Fprintf(app.Stderr, "task %s: %v\n", cmd.Name, err)
 
+	var usageErr *UsageError
+	if errors.As(err, &usageErr) {
+		fmt.Fprintln(app.Stderr)
+		cmd.PrintUsage(app.Stderr)
+		return ExitUsage
+	}
+	return ExitError
+}
+
+// taskFlags are the flags add and update share to set the attributes of a
+// task besides its description.
+type taskFlags struct {
+	due      *string
+	priority *string
+	project  projectFlag
+	tags     tagList
+	untag    tagList
+	parent   *string
+	blockers idList
+	unblock  idList
+	recur    recurFlag
+}
+
+func addTaskFlags(fs *flag.FlagSet, update bool) *taskFlags {
+	f := &taskFlags{}
+	if update {
+		f.due = fs.String("due", "", "new due date, or \"none\" to clear it")
+		f.priority = fs.String("priority", "", "new priority: "+priorityNames()+" or none")
+		fs.Var(&f.project, "project", "move the task to this project, or \"none\"")
+		fs.Var(&f.tags, "tag", "add these tags (comma separated, repeatable)")
+		fs.Var(&f.untag, "untag", "remove these tags (comma separated, repeatable)")
+		f.parent = fs.String("parent", "", "make the task a subtask of this task, or \"none\"")
+		fs.Var(&f.blockers, "blocked-by", "add tasks that must be finished first (comma separated, repeatable)")
+		fs.Var(&f.unblock, "unblock", "remove these blocking tasks (comma separated, repeatable)")
+		fs.Var(&f.recur, "recur", "new schedule: daily, weekly[:mon,thu], monthly[:15], after:3d or none")
+	} else {
+		f.due = fs.String("due", "", "due date, e.g. 2026-11-01, tomorrow, friday or +3d")
+		f.priority = fs.String("priority", "", "priority: "+priorityNames())
+		fs.Var(&f.project, "project", "project the task belongs to (same as +project in the description)")
+		fs.Var(&f.tags, "tag", "tags (comma separated, repeatable; same as #tag in the description)")
+		f.parent = fs.String("parent", "", "add the task as a subtask of this task")
+		fs.Var(&f.blockers, "blocked-by", "tasks that must be finished first (comma separated, repeatable)")
+		fs.Var(&f.recur, "recur", "repeat the task: daily, weekly[:mon,thu], monthly[:15] or after:3d")
+	}
+	return f
+}
+
+func (f *taskFlags) changed() bool {
+	return *f.due != "" || *f.priority != "" || f.project.set || len(f.tags) > 0 || len(f.untag) > 0 ||
+		*f.parent != "" || len(f.blockers) > 0 || len(f.unblock) > 0 || f.recur.set
+}
+
+// apply sets the attributes given by flags on t, checking relationships
+// against the other tasks in todos.
+func (f *taskFlags) apply(todos Todos, t *Todo, now time.Time) error {
+	if *f.due != "" {
+		dueAt, err := parseDue(*f.due, now)
 		if err != nil {
-			fmt.Println("Invalid Index")
-			os.Exit(1)
+			return err
 		}
+		t.DueAt = dueAt
+	}
+	if *f.priority != "" {
+		p, err := ParsePriority(*f.priority)
+		if err != nil {
+			return usageErrorf("%v", err)
+		}
+		t.Priority = p
+	}
+	if f.project.set {
+		t.Project = f.project.name
+	}
+	t.Tags = removeTags(addTags(t.Tags, f.tags...), f.untag...)
+
+	if *f.parent != "" {
+		parent, err := parseParent(*f.parent)
+		if err != nil {
+			return err
+		}
+		if err := todos.checkParent(t.ID, parent); err != nil {
+			return err
+		}
+		t.ParentID = parent
+	}
+	if err := todos.checkBlockers(t.ID, f.blockers); err != nil {
+		return err
+	}
+	for _, b := range f.blockers {
+		if !slices.Contains(t.BlockedBy, b) {
+			t.BlockedBy = append(t.BlockedBy, b)
+		}
+	}
+	t.BlockedBy = slices.DeleteFunc(t.BlockedBy, func(b int) bool { return slices.Contains(f.unblock, b) })
+	if len(t.BlockedBy) == 0 {
+		t.BlockedBy = nil
+	}
+
+	// A new schedule needs a due date for its first occurrence
+	if f.recur.set {
+		t.Recur = nil
+		if f.recur.rule != nil {
+			rule := *f.recur.rule
+			if t.DueAt == nil {
+				first := rule.First(now)
+				t.DueAt = &first
+			}
+			rule.pin(*t.DueAt)
+			t.Recur = &rule
+		}
+	}
+	return nil
+}
+
+// setText replaces the description of t, moving #tags and +project words
+// into their fields.
+func setText(t *Todo, text string) error {
+	description, tags, project, err := parseLabels(text)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+	if strings.TrimSpace(description) == "" {
+		return usageErrorf("missing task description")
+	}
+
+	t.Description = description
+	t.Tags = addTags(t.Tags, tags...)
+	if project != "" {
+		t.Project = project
+	}
+	return nil
+}
+
+func newAddCmd() *Command {
+	flags := newFlagSet("add")
+	attrs := addTaskFlags(flags, false)
+
+	return &Command{
+		Name:    "add",
+		Args:    "<description>",
+		Summary: "Add a new task. Words like #tag and +project become labels.",
+		Flags:   flags,
+		Run: func(ctx *Context, args []string) error {
+			// Nothing is saved when a label or flag turns out invalid
+			text := strings.Join(args, " ")
+			todo := ctx.List.add(text)
+			if err := setText(todo, text); err != nil {
+				return err
+			}
+			if err := attrs.apply(ctx.List.Todos, todo, time.Now()); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(ctx.Stdout, "Added task %d\n", todo.ID)
+			return nil
+		},
+	}
+}
+
+func newUpdateCmd() *Command {
+	flags := newFlagSet("update")
+	attrs := addTaskFlags(flags, true)
+	sel := addSelectorFlags(flags)
+
+	return &Command{
+		Name:    "update",
+		Args:    "[ids] [description]",
+		Summary: "Replace the description or change the due date, priority, project or tags of tasks.",
+		Flags:   flags,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) < 2 && !attrs.changed() {
+				return usageErrorf("expected a task id and a description or flags to change")
+			}
+
+			spec := ""
+			if len(args) > 0 {
+				spec = args[0]
+			}
+			now := time.Now()
+			ids, err := sel.selectIDs(ctx.List.Todos, spec, now)
+			if err != nil {
+				return err
+			}
+			if len(args) > 1 && len(ids) > 1 {
+				return usageErrorf("a description can only be given for a single task")
+			}
+			if ok, err := sel.confirm(ctx, "update", "", ids); !ok {
+				return err
+			}
+
+			for _, id := range ids {
+				index, _ := ctx.List.IndexOf(id)
+				todo := ctx.List.Todos[index]
+				if len(args) > 1 {
+					if err := setText(&todo, strings.Join(args[1:], " ")); err != nil {
+						return err
+					}
+				}
+				if err := attrs.apply(ctx.List.Todos, &todo, now); err != nil {
+					return fmt.Errorf("task %d: %w", id, err)
+				}
+				ctx.List.Todos[index] = todo
+				fmt.Fprintf(ctx.Stdout, "Updated task %d\n", id)
+			}
+			return nil
+		},
+	}
+}
+
+func newMarkCmd() *Command {
+	flags := newFlagSet("mark")
+	sel := addSelectorFlags(flags)
+
+	return &Command{
+		Name:    "mark",
+		Args:    "[ids] <status>",
+		Summary: "Change the status of tasks (" + statusNames() + ").",
+		Flags:   flags,
+		Run: func(ctx *Context, args []string) error {
+			var spec, name string
+			switch {
+			case len(args) == 2:
+				spec, name = args[0], args[1]
+			case len(args) == 1 && sel.filtering():
+				name = args[0]
+			default:
+				return usageErrorf("expected task ids and a status")
+			}
+
+			status, err := ParseStatus(name)
+			if err != nil {
+				return usageErrorf("%v", err)
+			}
+			now := time.Now()
+			ids, err := sel.selectIDs(ctx.List.Todos, spec, now)
+			if err != nil {
+				return err
+			}
+			if ok, err := sel.confirm(ctx, "mark", " as "+string(status), ids); !ok {
+				return err
+			}
+
+			for _, id := range ids {
+				if err := ctx.List.StatusChange(status, id); err != nil {
+					return err
+				}
+				fmt.Fprintf(ctx.Stdout, "Marked task %d as %s\n", id, status)
+
+				if status == StatusDone {
+					next, err := ctx.List.spawnNext(id, now)
+					if err != nil {
+						return err
+					}
+					if next != nil {
+						fmt.Fprintf(ctx.Stdout, "Next occurrence is task %d, due %s\n", next.ID, formatValue(next.DueAt, humanLayout))
+					}
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func newDeleteCmd() *Command {
+	flags := newFlagSet("delete")
+	purge := flags.Bool("purge", false, "delete the tasks for good instead of moving them to the archive")
+	sel := addSelectorFlags(flags)
+
+	return &Command{
+		Name:    "delete",
+		Args:    "[ids]",
+		Summary: "Delete tasks, keeping them in the archive unless -purge is given.",
+		Flags:   flags,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) > 1 {
+				return usageErrorf("expected one list of task ids")
+			}
+
+			spec := ""
+			if len(args) == 1 {
+				spec = args[0]
+			}
+			now := time.Now()
+			ids, err := sel.selectIDs(ctx.List.Todos, spec, now)
+			if err != nil {
+				return err
+			}
+			if ok, err := sel.confirm(ctx, "delete", "", ids); !ok {
+				return err
+			}
+
+			for _, id := range ids {
+				if *purge {
+					err = ctx.List.delete(id)
+				} else {
+					err = ctx.archiveTodo(id, now)
+				}
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(ctx.Stdout, "Deleted task %d\n", id)
+			}
+			return nil
+		},
+	}
+}
 
-		todos.StatusChange(parts[1], index)
-	case cf.Del != -1:
-		todos.delete(cf.Del)
-	default:
-		fmt.Println("Invalid Command")
+func newHelpCmd() *Command {
+	return &Command{
+		Name:    "help",
+		Args:    "[command]",
+		Summary: "Show help for a command.",
+		Flags:   newFlagSet("help"),
+		NoStore: true,
+		Run: func(ctx *Context, args []string) error {
+			switch len(args) {
+			case 0:
+				ctx.App.PrintUsage(ctx.Stdout)
+				return nil
+			case 1:
+				cmd := ctx.App.Lookup(args[0])
+				if cmd == nil {
+					return usageErrorf("unknown command %q", args[0])
+				}
+				cmd.PrintUsage(ctx.Stdout)
+				return nil
+			default:
+				return usageErrorf("expected at most one command name")
+			}
+		},
 	}
 }
@@ -0,0 +1,495 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The completion scripts hand the words of the command line to the hidden
+// __complete command, which prints the candidates for the last word one per
+// line as value<TAB>description. When there are none the shells fall back
+// to completing file names.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for task
+_task() {
+    local line=${COMP_LINE:0:COMP_POINT} cur=${COMP_WORDS[COMP_CWORD]} word value
+    local -a words
+    read -ra words <<< "$line"
+    [[ $line == *[[:space:]] ]] && words+=("")
+    word=${words[${#words[@]}-1]}
+    COMPREPLY=()
+    while IFS=$'\t' read -r value _; do
+        # bash splits words at = and :, complete only the part after them
+        COMPREPLY+=("${value#"${word%"$cur"}"}")
+    done < <(task __complete "${words[@]:1}" 2>/dev/null)
+}
+complete -o default -F _task task
+`,
+	"zsh": `#compdef task
+# zsh completion for task
+_task() {
+    local -a candidates
+    local value desc
+    while IFS=$'\t' read -r value desc; do
+        candidates+=("${value//:/\\:}${desc:+:$desc}")
+    done < <(task __complete "${(@)words[2,CURRENT]}" 2>/dev/null)
+    if (( ${#candidates} )); then
+        _describe 'task' candidates
+    else
+        _files
+    fi
+}
+compdef _task task
+`,
+	"fish": `# fish completion for task
+function __task_complete
+    set -l tokens (commandline -opc) (commandline -ct)
+    set -l candidates (task __complete $tokens[2..-1] 2>/dev/null)
+    if test (count $candidates) -gt 0
+        printf '%s\n' $candidates
+    else
+        __fish_complete_path (commandline -ct)
+    end
+end
+complete -c task -f -a '(__task_complete)'
+`,
+}
+
+func newCompletionCmd() *Command {
+	return &Command{
+		Name:    "completion",
+		Args:    "<shell>",
+		Summary: "Print the completion script for a shell: " + strings.Join(sortedKeys(completionScripts), ", ") + ".",
+		Flags:   newFlagSet("completion"),
+		NoStore: true,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) != 1 {
+				return usageErrorf("expected a shell")
+			}
+			script, ok := completionScripts[args[0]]
+			if !ok {
+				return usageErrorf("unknown shell %q (available: %s)", args[0], strings.Join(sortedKeys(completionScripts), ", "))
+			}
+			fmt.Fprint(ctx.Stdout, script)
+			return nil
+		},
+	}
+}
+
+func newCompleteCmd() *Command {
+	return &Command{
+		Name:    "__complete",
+		Args:    "<words>",
+		Summary: "Print the completions of the last word for the completion scripts.",
+		Flags:   newFlagSet("__complete"),
+		NoStore: true,
+		RawArgs: true,
+		Hidden:  true,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) == 0 {
+				args = []string{""}
+			}
+			for _, c := range ctx.App.complete(args) {
+				if c.desc != "" {
+					fmt.Fprintf(ctx.Stdout, "%s\t%s\n", c.value, c.desc)
+				} else {
+					fmt.Fprintln(ctx.Stdout, c.value)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// candidate is a completion with an optional description.
+type candidate struct {
+	value, desc string
+}
+
+// completer lists the values of an argument or flag. Those of list
+// completers can be given several times separated by commas, as in 1,4-6.
+type completer struct {
+	values func(c *completion) []candidate
+	list   bool
+}
+
+// completion is the command line being completed. The tasks and the
+// archive are only read once a completer asks for them.
+type completion struct {
+	app      *App
+	list     *TodoList
+	archived Todos
+}
+
+// flagCompleters complete the values of flags by name. Entries keyed
+// "command flag" apply to a single command.
+var flagCompleters = map[string]completer{
+	"status":             {statusValues, true},
+	"priority":           {priorityValues, true},
+	"tag":                {tagValues, true},
+	"untag":              {tagValues, true},
+	"project":            {projectValues, false},
+	"parent":             {taskIDs, false},
+	"blocked-by":         {taskIDs, true},
+	"unblock":            {taskIDs, true},
+	"fields":             {fieldValues, true},
+	"output":             {keyValues(outputFormats), false},
+	"group-by":           {keyValues(groupings), false},
+	"sort":               {keyValues(sortKeys), false},
+	"date":               {fixedValues("created", "updated"), false},
+	"format":             {keyValues(exchangeFormats), false},
+	"chart":              {keyValues(charts), false},
+	"list":               {listValues, false},
+	"store":              {backendValues, false},
+	"move to":            {listValues, false},
+	"migrate-store from": {backendValues, false},
+	"migrate-store to":   {backendValues, false},
+}
+
+// argCompleters complete positional arguments by the token naming them in
+// the synopsis of the command. Entries keyed "command token" apply to a
+// single command.
+var argCompleters = map[string]completer{
+	"id":               {taskIDs, false},
+	"ids":              {taskIDs, true},
+	"status":           {statusValues, false},
+	"command":          {commandValues, false},
+	"key":              {settingValues, false},
+	"name":             {listValues, false},
+	"old":              {listValues, false},
+	"shell":            {keyValues(completionScripts), false},
+	"archived list":    {fixedValues("list"), false},
+	"restore ids":      {archivedIDs, true},
+	"list-create name": {},
+}
+
+// settingCompleters complete the values of `config set`.
+var settingCompleters = map[string]completer{
+	"store":       {backendValues, false},
+	"list":        {listValues, false},
+	"date-format": {keyValues(dateFormats), false},
+	"table-style": {keyValues(tableStyles), false},
+	"git":         {fixedValues("on", "off"), false},
+}
+
+// complete returns the candidates for the last of words, the command line
+// after the program name.
+func (app *App) complete(words []string) []candidate {
+	cur, words := words[len(words)-1], words[:len(words)-1]
+	c := &completion{app: app}
+
+	// Global flags come before the command and select the list
+	i := 0
+	for ; i < len(words) && strings.HasPrefix(words[i], "-"); i++ {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(words[i], "-"), "=")
+		f := app.globals.Lookup(name)
+		if f == nil || isBoolFlag(f) {
+			continue
+		}
+		if !hasValue {
+			if i+1 == len(words) {
+				return c.run(flagCompleters[name], cur)
+			}
+			i++
+			value = words[i]
+		}
+		f.Value.Set(value)
+	}
+	if i == len(words) {
+		if strings.HasPrefix(cur, "-") {
+			return c.flagOrValue(nil, app.globals, cur)
+		}
+		return filterCandidates(commandValues(c), cur)
+	}
+
+	cmd := app.Lookup(words[i])
+	if cmd == nil || cmd.RawArgs {
+		return nil
+	}
+	var args []string
+	for i++; i < len(words); i++ {
+		w := words[i]
+		if !strings.HasPrefix(w, "-") || w == "-" {
+			args = append(args, w)
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(w, "-"), "=")
+		if f := cmd.Flags.Lookup(name); f != nil && !isBoolFlag(f) && !hasValue {
+			if i+1 == len(words) {
+				return c.run(c.flagCompleter(cmd, name), cur)
+			}
+			i++
+		}
+	}
+	if strings.HasPrefix(cur, "-") {
+		return c.flagOrValue(cmd, cmd.Flags, cur)
+	}
+	return c.arg(cmd, args, cur)
+}
+
+// flagOrValue completes the flags of fs, or the value of a flag given as
+// -name=value. cmd is nil for the global flags.
+func (c *completion) flagOrValue(cmd *Command, fs *flag.FlagSet, cur string) []candidate {
+	name, value, hasValue := strings.Cut(strings.TrimLeft(cur, "-"), "=")
+	if hasValue {
+		prefix := strings.TrimSuffix(cur, value)
+		var cands []candidate
+		for _, cand := range c.run(c.flagCompleter(cmd, name), value) {
+			cands = append(cands, candidate{prefix + cand.value, cand.desc})
+		}
+		return cands
+	}
+
+	var cands []candidate
+	fs.VisitAll(func(f *flag.Flag) {
+		cands = append(cands, candidate{"--" + f.Name, f.Usage})
+	})
+	if !strings.HasPrefix(cur, "--") {
+		cur = "-" + cur
+	}
+	return filterCandidates(cands, cur)
+}
+
+func (c *completion) flagCompleter(cmd *Command, name string) completer {
+	if cmd != nil {
+		if comp, ok := flagCompleters[cmd.Name+" "+name]; ok {
+			return comp
+		}
+	}
+	return flagCompleters[name]
+}
+
+// arg completes the positional argument after args from the synopsis of
+// cmd. An optional argument also offers the values of the one after it, so
+// `mark` completes both task IDs and statuses first. A synopsis of
+// alternatives such as "list | get <key>" offers the first words of the
+// alternatives, then follows the one chosen.
+func (c *completion) arg(cmd *Command, args []string, cur string) []candidate {
+	tokens := synopsisTokens(cmd.Args)
+	if strings.Contains(cmd.Args, "|") {
+		alternatives := strings.Split(cmd.Args, "|")
+		if len(args) == 0 {
+			var cands []candidate
+			for _, alt := range alternatives {
+				cands = append(cands, candidate{value: strings.Fields(alt)[0]})
+			}
+			return filterCandidates(cands, cur)
+		}
+		tokens = nil
+		for _, alt := range alternatives {
+			if t := synopsisTokens(alt); t[0] == args[0] {
+				tokens = t
+			}
+		}
+		if len(tokens) == 0 {
+			return nil
+		}
+		if cmd.Name == "config" && args[0] == "set" && len(args) == 2 {
+			return c.run(settingCompleters[args[1]], cur)
+		}
+	}
+
+	var cands []candidate
+	for i := len(args); i < len(tokens); i++ {
+		token, optional := strings.CutPrefix(tokens[i], "[")
+		token = strings.Trim(token, "[]<>")
+		if token == tokens[i] {
+			// A literal word of the synopsis
+			cands = append(cands, filterCandidates([]candidate{{value: token}}, cur)...)
+		} else if comp, ok := argCompleters[cmd.Name+" "+token]; ok {
+			cands = append(cands, c.run(comp, cur)...)
+		} else {
+			cands = append(cands, c.run(argCompleters[token], cur)...)
+		}
+		if !optional {
+			break
+		}
+	}
+	return cands
+}
+
+// synopsisTokens splits a synopsis into its arguments, keeping the words of
+// one argument such as "<git arguments>" together.
+func synopsisTokens(synopsis string) []string {
+	var tokens []string
+	for _, field := range strings.Fields(synopsis) {
+		if n := len(tokens); n > 0 && strings.ContainsAny(tokens[n-1][:1], "[<") && !strings.ContainsAny(tokens[n-1], "]>") {
+			tokens[n-1] += " " + field
+			continue
+		}
+		tokens = append(tokens, field)
+	}
+	return tokens
+}
+
+// run lists the values of comp matching cur. Of a list completer only the
+// last value after a comma is completed.
+func (c *completion) run(comp completer, cur string) []candidate {
+	if comp.values == nil {
+		return nil
+	}
+	head := ""
+	if comp.list {
+		if i := strings.LastIndex(cur, ","); i >= 0 {
+			head, cur = cur[:i+1], cur[i+1:]
+		}
+	}
+	var cands []candidate
+	for _, cand := range filterCandidates(comp.values(c), cur) {
+		cands = append(cands, candidate{head + cand.value, cand.desc})
+	}
+	return cands
+}
+
+func filterCandidates(cands []candidate, prefix string) []candidate {
+	return slices.DeleteFunc(cands, func(c candidate) bool {
+		return !strings.HasPrefix(c.value, prefix)
+	})
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// todos returns the tasks of the selected list, or none when it cannot be
+// read.
+func (c *completion) todos() Todos {
+	if c.list == nil {
+		list, _ := c.app.readList()
+		c.list = &list
+	}
+	return c.list.Todos
+}
+
+func fixedValues(values ...string) func(*completion) []candidate {
+	return func(*completion) []candidate {
+		cands := make([]candidate, len(values))
+		for i, v := range values {
+			cands[i] = candidate{value: v}
+		}
+		return cands
+	}
+}
+
+func keyValues[V any](m map[string]V) func(*completion) []candidate {
+	return fixedValues(sortedKeys(m)...)
+}
+
+func statusValues(*completion) []candidate {
+	var cands []candidate
+	for _, s := range Statuses {
+		cands = append(cands, candidate{value: string(s)})
+	}
+	return cands
+}
+
+func priorityValues(*completion) []candidate {
+	var cands []candidate
+	for _, p := range Priorities {
+		cands = append(cands, candidate{value: string(p)})
+	}
+	return cands
+}
+
+func fieldValues(*completion) []candidate {
+	var cands []candidate
+	for _, f := range Fields {
+		cands = append(cands, candidate{value: f.Name})
+	}
+	return cands
+}
+
+func backendValues(*completion) []candidate {
+	return fixedValues(StoreBackends()...)(nil)
+}
+
+func commandValues(c *completion) []candidate {
+	var cands []candidate
+	for _, cmd := range c.app.Commands {
+		if !cmd.Hidden {
+			cands = append(cands, candidate{cmd.Name, cmd.Summary})
+		}
+	}
+	return cands
+}
+
+func settingValues(*completion) []candidate {
+	cands := make([]candidate, len(settings))
+	for i, s := range settings {
+		cands[i] = candidate{s.name, s.summary}
+	}
+	return cands
+}
+
+func listValues(c *completion) []candidate {
+	names, _ := ListNames(c.app.StoreBackend())
+	var cands []candidate
+	for _, name := range names {
+		cands = append(cands, candidate{name, listSummary(c.app.StoreBackend(), name)})
+	}
+	return cands
+}
+
+func taskIDs(c *completion) []candidate {
+	return todoCandidates(c.todos())
+}
+
+func archivedIDs(c *completion) []candidate {
+	if c.archived == nil {
+		if path, err := c.app.StorePath(); err == nil {
+			if archive, err := OpenArchive(archivePath(path)); err == nil {
+				c.archived = archive.Todos
+			}
+		}
+	}
+	return todoCandidates(c.archived)
+}
+
+// todoCandidates offers the IDs of todos described by their descriptions,
+// open tasks first.
+func todoCandidates(todos Todos) []candidate {
+	var open, finished []candidate
+	for _, t := range todos {
+		cand := candidate{strconv.Itoa(t.ID), t.Description}
+		if t.Status.Finished() {
+			cand.desc += " (" + string(t.Status) + ")"
+			finished = append(finished, cand)
+		} else {
+			open = append(open, cand)
+		}
+	}
+	return append(open, finished...)
+}
+
+func tagValues(c *completion) []candidate {
+	return countValues(c.todos(), func(t Todo) []string { return t.Tags })
+}
+
+func projectValues(c *completion) []candidate {
+	return countValues(c.todos(), func(t Todo) []string {
+		if t.Project == "" {
+			return nil
+		}
+		return []string{t.Project}
+	})
+}
+
+// countValues offers the values of todos described by how many tasks have
+// them.
+func countValues(todos Todos, values func(Todo) []string) []candidate {
+	counts := map[string]int{}
+	for _, t := range todos {
+		for _, v := range values(t) {
+			counts[v]++
+		}
+	}
+	var cands []candidate
+	for _, v := range sortedKeys(counts) {
+		cands = append(cands, candidate{v, fmt.Sprintf("%d %s", counts[v], plural(counts[v], "task"))})
+	}
+	return cands
+}
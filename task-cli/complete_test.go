@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Helper to complete a command line, returning one line per candidate
+func completeLine(t *testing.T, app *App, line string) []string {
+	t.Helper()
+	words := strings.Fields(line)
+	if line == "" || strings.HasSuffix(line, " ") {
+		words = append(words, "")
+	}
+	code, stdout, stderr := runCmd(t, app, append([]string{"__complete"}, words...)...)
+	if code != 0 {
+		t.Fatalf("Expected completion of %q to succeed, got %d %q", line, code, stderr)
+	}
+	return strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+}
+
+func TestComplete(t *testing.T) {
+	app := newTestApp(t)
+	runCmd(t, app, "add", "Buy milk", "-tag", "shop")
+	runCmd(t, app, "add", "Write report", "-tag", "work", "-project", "q4")
+	runCmd(t, app, "add", "Call Ann", "-tag", "work")
+	runCmd(t, app, "mark", "1", "done")
+
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"ma", []string{"mark\tChange the status of tasks (todo, in-progress, blocked, done, cancelled).", "man\tPrint the manual page in roff, e.g. task man | man -l -."}},
+		{"mark ", []string{"2\tWrite report", "3\tCall Ann", "1\tBuy milk (done)", "todo", "in-progress", "blocked", "done", "cancelled"}},
+		{"mark 2 d", []string{"done"}},
+		{"update 1,", []string{"1,2\tWrite report", "1,3\tCall Ann", "1,1\tBuy milk (done)"}},
+		{"list --tag ", []string{"shop\t1 task", "work\t2 tasks"}},
+		{"list --status=in", []string{"--status=in-progress"}},
+		{"list -status todo,b", []string{"todo,blocked"}},
+		{"add x --proj", []string{"--project\tproject the task belongs to (same as +project in the description)"}},
+		{"add x --project ", []string{"q4\t1 task"}},
+		{"config ", []string{"list", "get", "set", "unset"}},
+		{"config set git ", []string{"on", "off"}},
+		{"config get ta", []string{"table-style\ttable borders: ascii, none, rounded, unicode"}},
+		{"completion ", []string{"bash", "fish", "zsh"}},
+		{"--st", []string{"--store\tstorage backend: db, json, jsonl (default \"json\")"}},
+		{"-store js", []string{"json", "jsonl"}},
+		{"git lo", []string{""}},
+		{"__comp", []string{""}},
+	}
+	for _, tt := range tests {
+		got := completeLine(t, app, tt.line)
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%q: expected %q, got %q", tt.line, tt.want, got)
+		}
+	}
+}
+
+func TestCompleteLists(t *testing.T) {
+	app := newTestApp(t)
+	app.File = ""
+	runCmd(t, app, "list-create", "work")
+	runCmd(t, app, "-list", "work", "add", "Plan sprint")
+	runCmd(t, app, "-list", "work", "archive", "-yes", "1")
+
+	if got := completeLine(t, app, "move 1 --to w"); len(got) != 1 || got[0] != "work\t1 task, 1 open" {
+		t.Errorf("Expected the work list, got %q", got)
+	}
+	if got := completeLine(t, app, "list-create "); len(got) != 1 || got[0] != "" {
+		t.Errorf("Expected no candidates for a new name, got %q", got)
+	}
+
+	// The global flags select the list IDs are completed from
+	runCmd(t, app, "-list", "work", "mark", "1", "done")
+	runCmd(t, app, "-list", "work", "archive")
+	if got := completeLine(t, app, "-list work restore "); len(got) != 1 || got[0] != "1\tPlan sprint (done)" {
+		t.Errorf("Expected the archived task, got %q", got)
+	}
+}
+
+func TestCompletionScripts(t *testing.T) {
+	app := newTestApp(t)
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		code, stdout, _ := runCmd(t, app, "completion", shell)
+		if code != 0 || !strings.Contains(stdout, "task __complete") {
+			t.Errorf("%s: expected a script calling task __complete, got %d %q", shell, code, stdout)
+		}
+	}
+	if code, _, _ := runCmd(t, app, "completion", "tcsh"); code != 2 {
+		t.Errorf("Expected usage error for an unknown shell, got %d", code)
+	}
+	if _, stdout, _ := runCmd(t, app, "help"); strings.Contains(stdout, "__complete") {
+		t.Errorf("Expected __complete hidden from the usage, got %q", stdout)
+	}
+}
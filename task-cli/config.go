@@ -0,0 +1,292 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aquasecurity/table"
+	"gopkg.in/yaml.v3"
+)
+
+// setting is a configurable value. Settings named like a global flag can
+// also be given with that flag.
+type setting struct {
+	name    string
+	def     string
+	summary string
+	check   func(string) error
+}
+
+var settings = []setting{
+	{"store", "json", "storage backend: " + strings.Join(StoreBackends(), ", "), checkBackend},
+	{"file", "", "path of a task store to use instead of a named list", nil},
+	{"list", defaultListName, "list used when -list is not given", checkListName},
+	{"date-format", "rfc1123", "dates in tables: " + strings.Join(sortedKeys(dateFormats), ", ") + " or a Go time layout", checkDateFormat},
+	{"table-style", "unicode", "table borders: " + strings.Join(sortedKeys(tableStyles), ", "), checkTableStyle},
+	{"auto-archive", "", "archive tasks finished this long ago, e.g. 14d (empty: never)", checkAutoArchive},
+	{"git", "off", "commit every change to the lists in a git repository next to them: on or off", checkGit},
+}
+
+func lookupSetting(name string) (setting, bool) {
+	i := slices.IndexFunc(settings, func(s setting) bool { return s.name == name })
+	if i < 0 {
+		return setting{}, false
+	}
+	return settings[i], true
+}
+
+func settingNames() string {
+	names := make([]string, len(settings))
+	for i, s := range settings {
+		names[i] = s.name
+	}
+	return strings.Join(names, ", ")
+}
+
+// envName is the environment variable overriding a setting, e.g.
+// TASK_DATE_FORMAT.
+func envName(name string) string {
+	return "TASK_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+func checkBackend(name string) error {
+	if _, ok := storeBackends[name]; !ok {
+		return fmt.Errorf("unknown store %q (available: %s)", name, strings.Join(StoreBackends(), ", "))
+	}
+	return nil
+}
+
+// dateLayout holds the layouts of times and of all-day dates.
+type dateLayout struct {
+	time, day string
+}
+
+var dateFormats = map[string]dateLayout{
+	"rfc1123": {time.RFC1123, "Mon, 02 Jan 2006"},
+	"iso":     {"2006-01-02 15:04", "2006-01-02"},
+	"short":   {"Jan 2 15:04", "Jan 2"},
+}
+
+func parseDateFormat(value string) (dateLayout, error) {
+	if layout, ok := dateFormats[strings.ToLower(value)]; ok {
+		return layout, nil
+	}
+	// A layout without any element formats every time the same
+	ref := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	if ref.Format(value) == ref.AddDate(1, 1, 1).Add(time.Hour).Format(value) {
+		return dateLayout{}, fmt.Errorf("invalid date format %q, use %s or a Go time layout", value, strings.Join(sortedKeys(dateFormats), ", "))
+	}
+	return dateLayout{value, value}, nil
+}
+
+func checkDateFormat(value string) error {
+	_, err := parseDateFormat(value)
+	return err
+}
+
+var tableStyles = map[string]table.Dividers{
+	"unicode": table.UnicodeDividers,
+	"rounded": table.UnicodeRoundedDividers,
+	"ascii":   table.ASCIIDividers,
+	"none":    table.NoDividers,
+}
+
+func checkTableStyle(value string) error {
+	if _, ok := tableStyles[value]; !ok {
+		return fmt.Errorf("invalid table style %q, use %s", value, strings.Join(sortedKeys(tableStyles), ", "))
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Config holds the settings of the config file. Environment variables
+// override the file, and Get falls back to the built-in defaults.
+type Config struct {
+	path   string
+	values map[string]string
+}
+
+// configPath returns $XDG_CONFIG_HOME/task/config.yaml, or
+// ~/.config/task/config.yaml when XDG_CONFIG_HOME is unset.
+func configPath() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "task", "config.yaml"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot find the config directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "task", "config.yaml"), nil
+}
+
+// LoadConfig reads the config file. A missing file is an empty config.
+func LoadConfig() (*Config, error) {
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{path: path, values: map[string]string{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, &c.values); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if c.values == nil {
+		c.values = map[string]string{}
+	}
+	for name, value := range c.values {
+		s, ok := lookupSetting(name)
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown setting %q (available: %s)", path, name, settingNames())
+		}
+		if s.check != nil {
+			if err := s.check(value); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", path, name, err)
+			}
+		}
+	}
+	return c, nil
+}
+
+// Lookup returns the value of a setting and where it came from. A nil
+// Config only sees the environment and the defaults.
+func (c *Config) Lookup(name string) (value, source string) {
+	if v := os.Getenv(envName(name)); v != "" {
+		return v, envName(name)
+	}
+	if c != nil {
+		if v, ok := c.values[name]; ok {
+			return v, "config file"
+		}
+	}
+	s, _ := lookupSetting(name)
+	return s.def, "default"
+}
+
+func (c *Config) Get(name string) string {
+	value, _ := c.Lookup(name)
+	return value
+}
+
+// Set stores a setting in the config file.
+func (c *Config) Set(name, value string) error {
+	s, ok := lookupSetting(name)
+	if !ok {
+		return usageErrorf("unknown setting %q (available: %s)", name, settingNames())
+	}
+	if s.check != nil {
+		if err := s.check(value); err != nil {
+			return usageErrorf("%v", err)
+		}
+	}
+	c.values[name] = value
+	return c.save()
+}
+
+// Unset removes a setting from the config file.
+func (c *Config) Unset(name string) error {
+	if _, ok := lookupSetting(name); !ok {
+		return usageErrorf("unknown setting %q (available: %s)", name, settingNames())
+	}
+	delete(c.values, name)
+	return c.save()
+}
+
+func (c *Config) save() error {
+	data, err := yaml.Marshal(c.values)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path, data, 0o644)
+}
+
+// Layouts and borders used by the human readable output, set from the
+// configuration by App.Run.
+var (
+	humanDates = dateFormats["rfc1123"]
+	tableStyle = table.UnicodeDividers
+)
+
+// applyDisplay sets the output settings, which may come from the
+// environment without having been checked.
+func (c *Config) applyDisplay() error {
+	value, source := c.Lookup("date-format")
+	layout, err := parseDateFormat(value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	value, source = c.Lookup("table-style")
+	if err := checkTableStyle(value); err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	humanDates, tableStyle = layout, tableStyles[value]
+	return nil
+}
+
+func newConfigCmd() *Command {
+	return &Command{
+		Name:    "config",
+		Args:    "list | get <key> | set <key> <value> | unset <key>",
+		Summary: "Show or change the settings of the config file.",
+		Flags:   newFlagSet("config"),
+		NoStore: true,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) == 0 {
+				return usageErrorf("expected list, get, set or unset")
+			}
+			cfg := ctx.App.Config
+			action, args := args[0], args[1:]
+
+			switch {
+			case action == "list" && len(args) == 0:
+				fmt.Fprintf(ctx.Stdout, "# %s\n", cfg.path)
+				for _, s := range settings {
+					value, source := ctx.App.Setting(s.name)
+					fmt.Fprintf(ctx.Stdout, "%-12s %-24q (%s)\n", s.name, value, source)
+				}
+				return nil
+			case action == "get" && len(args) == 1:
+				if _, ok := lookupSetting(args[0]); !ok {
+					return usageErrorf("unknown setting %q (available: %s)", args[0], settingNames())
+				}
+				value, _ := ctx.App.Setting(args[0])
+				fmt.Fprintln(ctx.Stdout, value)
+				return nil
+			case action == "set" && len(args) == 2:
+				if err := cfg.Set(args[0], args[1]); err != nil {
+					return err
+				}
+				if env := envName(args[0]); os.Getenv(env) != "" {
+					fmt.Fprintf(ctx.Stderr, "task config: %s is set and overrides the config file\n", env)
+				}
+				return nil
+			case action == "unset" && len(args) == 1:
+				return cfg.Unset(args[0])
+			}
+			return usageErrorf("expected list, get <key>, set <key> <value> or unset <key>")
+		},
+	}
+}
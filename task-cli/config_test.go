@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseDateFormat(t *testing.T) {
+	layout, err := parseDateFormat("ISO")
+	if err != nil || layout.day != "2006-01-02" {
+		t.Errorf("Expected the iso layouts, got %+v, %v", layout, err)
+	}
+	layout, err = parseDateFormat("02.01.2006")
+	if err != nil || layout.time != "02.01.2006" {
+		t.Errorf("Expected a custom layout, got %+v, %v", layout, err)
+	}
+	if _, err := parseDateFormat("fancy"); err == nil {
+		t.Error("Expected error for a layout without any element, got nil")
+	}
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("TASK_LIST", "")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Expected an empty config without a file, got %v", err)
+	}
+	if value, source := cfg.Lookup("store"); value != "json" || source != "default" {
+		t.Errorf("Expected default store, got %s (%s)", value, source)
+	}
+
+	if err := cfg.Set("list", "work"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := cfg.Set("table-style", "wavy"); err == nil {
+		t.Error("Expected error for an invalid value, got nil")
+	}
+	if err := cfg.Set("colour", "red"); err == nil {
+		t.Error("Expected error for an unknown setting, got nil")
+	}
+
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to reload config: %v", err)
+	}
+	if value, source := cfg.Lookup("list"); value != "work" || source != "config file" {
+		t.Errorf("Expected list from the file, got %s (%s)", value, source)
+	}
+
+	t.Setenv("TASK_LIST", "team")
+	if value, source := cfg.Lookup("list"); value != "team" || source != "TASK_LIST" {
+		t.Errorf("Expected the environment to win, got %s (%s)", value, source)
+	}
+
+	os.WriteFile(filepath.Join(dir, "task", "config.yaml"), []byte("colour: red\n"), 0o644)
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "unknown setting") {
+		t.Errorf("Expected unknown setting error, got %v", err)
+	}
+}
+
+func TestAppConfig(t *testing.T) {
+	app := newTestApp(t)
+	app.File = ""
+	defer func() { humanDates, tableStyle = dateFormats["rfc1123"], tableStyles["unicode"] }()
+
+	if code, _, stderr := runCmd(t, app, "config", "set", "date-format", "iso"); code != ExitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", ExitOK, code, stderr)
+	}
+	runCmd(t, app, "config", "set", "table-style", "ascii")
+	runCmd(t, app, "add", "--due", "2026-11-01", "Pay rent")
+
+	_, stdout, _ := runCmd(t, app, "list", "--fields", "id,due")
+	if !strings.Contains(stdout, "2026-11-01") || !strings.Contains(stdout, "+") {
+		t.Errorf("Expected iso dates in an ascii table, got '%s'", stdout)
+	}
+
+	// Flags beat the environment, which beats the config file
+	runCmd(t, app, "config", "set", "store", "jsonl")
+	t.Setenv("TASK_STORE", "db")
+	_, stdout, _ = runCmd(t, app, "config", "get", "store")
+	if stdout != "db\n" {
+		t.Errorf("Expected store from the environment, got %q", stdout)
+	}
+	_, stdout, _ = runCmd(t, app, "-store", "json", "config", "list")
+	if !strings.Contains(stdout, `store        "json"`) || !strings.Contains(stdout, "(flag -store)") ||
+		!strings.Contains(stdout, "(config file)") {
+		t.Errorf("Unexpected config list: %q", stdout)
+	}
+	app.Backend = ""
+
+	t.Setenv("TASK_TABLE_STYLE", "wavy")
+	if code, _, _ := runCmd(t, app, "list"); code != ExitError {
+		t.Errorf("Expected exit code %d for an invalid environment value, got %d", ExitError, code)
+	}
+	t.Setenv("TASK_TABLE_STYLE", "")
+
+	if code, _, _ := runCmd(t, app, "config", "get", "colour"); code != ExitUsage {
+		t.Errorf("Expected exit code %d for an unknown setting, got %d", ExitUsage, code)
+	}
+	runCmd(t, app, "config", "unset", "date-format")
+	if _, stdout, _ = runCmd(t, app, "config", "get", "date-format"); stdout != "rfc1123\n" {
+		t.Errorf("Expected the default after unset, got %q", stdout)
+	}
+}
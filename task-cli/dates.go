@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+// ParseDate understands the date inputs accepted on the command line,
+// relative to now:
+//
+//	2026-11-01, 2026-11-01 15:04, RFC 3339
+//	today, tomorrow, yesterday
+//	+3d, -2w, +1m, +1y (days, weeks, months, years from today)
+//	monday, fri (the next such weekday)
+//
+// Inputs without a time of day resolve to midnight at the start of the day.
+func ParseDate(s string, now time.Time) (time.Time, error) {
+	input := strings.ToLower(strings.TrimSpace(s))
+	today := startOfDay(now)
+
+	switch input {
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+
+	if wd, ok := weekdays[input]; ok {
+		days := (int(wd) - int(today.Weekday()) + 7) % 7
+		if days == 0 {
+			days = 7
+		}
+		return today.AddDate(0, 0, days), nil
+	}
+
+	if t, ok := parseOffset(input, today); ok {
+		return t, nil
+	}
+
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, input, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, strings.TrimSpace(s)); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date %q, use YYYY-MM-DD, today, tomorrow, a weekday or an offset like +3d", s)
+}
+
+// parseOffset handles "+3d" style inputs. The sign is required for
+// offsets so "3d" is never mistaken for something else.
+func parseOffset(input string, today time.Time) (time.Time, bool) {
+	if len(input) < 3 || (input[0] != '+' && input[0] != '-') {
+		return time.Time{}, false
+	}
+
+	n, err := strconv.Atoi(input[:len(input)-1])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	switch input[len(input)-1] {
+	case 'd':
+		return today.AddDate(0, 0, n), true
+	case 'w':
+		return today.AddDate(0, 0, 7*n), true
+	case 'm':
+		return today.AddDate(0, n, 0), true
+	case 'y':
+		return today.AddDate(n, 0, 0), true
+	}
+	return time.Time{}, false
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// isAllDay reports whether t carries no time of day, as produced by
+// ParseDate for plain dates.
+func isAllDay(t time.Time) bool {
+	return t.Equal(startOfDay(t))
+}
+
+// parseDuration accepts Go durations plus whole days and weeks, e.g.
+// "14d", "2w" or "36h".
+func parseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if len(s) > 1 {
+		unit := time.Duration(0)
+		switch s[len(s)-1] {
+		case 'd':
+			unit = 24 * time.Hour
+		case 'w':
+			unit = 7 * 24 * time.Hour
+		}
+		if unit != 0 {
+			n, err := strconv.Atoi(s[:len(s)-1])
+			if err == nil && n >= 0 {
+				return time.Duration(n) * unit, nil
+			}
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q, use e.g. 30d, 2w or 12h", s)
+	}
+	return d, nil
+}
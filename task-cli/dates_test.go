@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	// A Saturday afternoon
+	now := time.Date(2026, 10, 17, 15, 30, 0, 0, time.Local)
+	day := func(m time.Month, d int) time.Time {
+		return time.Date(2026, m, d, 0, 0, 0, 0, time.Local)
+	}
+
+	testCases := []struct {
+		input string
+		want  time.Time
+	}{
+		{"2026-11-01", day(11, 1)},
+		{"2026-11-01 09:15", time.Date(2026, 11, 1, 9, 15, 0, 0, time.Local)},
+		{"2026-11-01T09:15:00Z", time.Date(2026, 11, 1, 9, 15, 0, 0, time.UTC)},
+		{"today", day(10, 17)},
+		{"Tomorrow", day(10, 18)},
+		{"yesterday", day(10, 16)},
+		{"+3d", day(10, 20)},
+		{"-1w", day(10, 10)},
+		{"+1m", day(11, 17)},
+		{"monday", day(10, 19)},
+		{"sat", day(10, 24)},
+	}
+
+	for _, tc := range testCases {
+		got, err := ParseDate(tc.input, now)
+		if err != nil {
+			t.Errorf("%s: expected no error, got %v", tc.input, err)
+			continue
+		}
+		if !got.Equal(tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.input, tc.want, got)
+		}
+	}
+
+	for _, input := range []string{"", "3d", "+3x", "soon", "2026-13-01"} {
+		if _, err := ParseDate(input, now); err == nil {
+			t.Errorf("Expected error for %q, got nil", input)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	testCases := map[string]time.Duration{
+		"3d":  72 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"12h": 12 * time.Hour,
+	}
+	for input, want := range testCases {
+		got, err := parseDuration(input)
+		if err != nil || got != want {
+			t.Errorf("%s: expected %v, got %v (%v)", input, want, got, err)
+		}
+	}
+
+	if _, err := parseDuration("-2d"); err == nil {
+		t.Error("Expected error for negative duration, got nil")
+	}
+}
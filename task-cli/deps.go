@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// idList collects task IDs given as a comma separated, repeatable flag.
+type idList []int
+
+func (l *idList) String() string {
+	parts := make([]string, len(*l))
+	for i, id := range *l {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (l *idList) Set(value string) error {
+	if value == "" {
+		*l = nil
+		return nil
+	}
+	for _, part := range strings.Split(value, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || id < 1 {
+			return fmt.Errorf("invalid task id %q", part)
+		}
+		*l = append(*l, id)
+	}
+	return nil
+}
+
+// parseParent resolves a -parent flag value, "none" or 0 detaching the
+// task from its parent.
+func parseParent(value string) (int, error) {
+	if strings.EqualFold(value, "none") {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil || id < 0 {
+		return 0, usageErrorf("invalid -parent %q, use a task id or none", value)
+	}
+	return id, nil
+}
+
+// checkParent reports whether the task id may become a subtask of parent
+// without creating a cycle.
+func (todos Todos) checkParent(id, parent int) error {
+	if parent == 0 {
+		return nil
+	}
+	if parent == id {
+		return fmt.Errorf("task %d cannot be its own parent", id)
+	}
+	for p := parent; p != 0; {
+		index, err := todos.IndexOf(p)
+		if err != nil {
+			return fmt.Errorf("parent %w", err)
+		}
+		p = todos[index].ParentID
+		if p == id {
+			return fmt.Errorf("task %d is an ancestor of task %d, making it the parent would create a cycle", id, parent)
+		}
+	}
+	return nil
+}
+
+// checkBlockers reports whether every blocker exists and may block the task
+// id without creating a dependency cycle.
+func (todos Todos) checkBlockers(id int, blockers []int) error {
+	for _, b := range blockers {
+		if b == id {
+			return fmt.Errorf("task %d cannot block itself", id)
+		}
+		if _, err := todos.IndexOf(b); err != nil {
+			return fmt.Errorf("blocking %w", err)
+		}
+		if todos.dependsOn(b, id) {
+			return fmt.Errorf("task %d already depends on task %d, blocking would create a cycle", b, id)
+		}
+	}
+	return nil
+}
+
+// dependsOn reports whether task id is blocked by target, directly or
+// through other blockers.
+func (todos Todos) dependsOn(id, target int) bool {
+	seen := map[int]bool{}
+	queue := []int{id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if seen[current] {
+			continue
+		}
+		seen[current] = true
+
+		index, err := todos.IndexOf(current)
+		if err != nil {
+			continue
+		}
+		for _, b := range todos[index].BlockedBy {
+			if b == target {
+				return true
+			}
+			queue = append(queue, b)
+		}
+	}
+	return false
+}
+
+// openBlockers returns the blockers of t that are not finished yet.
+// Blockers that were deleted no longer block.
+func (todos Todos) openBlockers(t Todo) []int {
+	var open []int
+	for _, b := range t.BlockedBy {
+		if index, err := todos.IndexOf(b); err == nil && !todos[index].Status.Finished() {
+			open = append(open, b)
+		}
+	}
+	return open
+}
+
+// unlink removes every reference to the task id: its subtasks move up to
+// its parent and it no longer blocks anything.
+func (todos Todos) unlink(id int) {
+	parent := 0
+	if index, err := todos.IndexOf(id); err == nil {
+		parent = todos[index].ParentID
+	}
+	for i := range todos {
+		if todos[i].ParentID == id {
+			todos[i].ParentID = parent
+		}
+		if slices.Contains(todos[i].BlockedBy, id) {
+			todos[i].BlockedBy = slices.DeleteFunc(slices.Clone(todos[i].BlockedBy), func(b int) bool { return b == id })
+			if len(todos[i].BlockedBy) == 0 {
+				todos[i].BlockedBy = nil
+			}
+		}
+	}
+}
+
+// rollup sets Progress on every task with subtasks to the percentage of its
+// subtasks, at any depth, that are done. Cancelled subtasks do not count.
+func (todos Todos) rollup() {
+	children := map[int][]int{}
+	byID := map[int]int{}
+	for i, t := range todos {
+		byID[t.ID] = i
+		if t.ParentID != 0 {
+			children[t.ParentID] = append(children[t.ParentID], t.ID)
+		}
+	}
+
+	for i := range todos {
+		todos[i].Progress = nil
+		if len(children[todos[i].ID]) == 0 {
+			continue
+		}
+
+		done, total := 0, 0
+		seen := map[int]bool{todos[i].ID: true}
+		queue := slices.Clone(children[todos[i].ID])
+		for len(queue) > 0 {
+			id := queue[0]
+			queue = queue[1:]
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			queue = append(queue, children[id]...)
+
+			switch todos[byID[id]].Status {
+			case StatusCancelled:
+			case StatusDone:
+				done++
+				total++
+			default:
+				total++
+			}
+		}
+
+		percent := 100
+		if total > 0 {
+			percent = done * 100 / total
+		}
+		todos[i].Progress = &percent
+	}
+}
+
+// tree orders todos depth first, subtasks right below their parent in the
+// current order. Tasks whose parent is not among todos are roots, and so
+// is the first task of a parent cycle in a hand edited store. With indent
+// the descriptions are decorated for humans: subtasks are indented and
+// parents show their progress.
+func (todos Todos) tree(indent bool) Todos {
+	present := map[int]bool{}
+	for _, t := range todos {
+		present[t.ID] = true
+	}
+	children := map[int]Todos{}
+	var roots Todos
+	for _, t := range todos {
+		if t.ParentID != 0 && present[t.ParentID] {
+			children[t.ParentID] = append(children[t.ParentID], t)
+		} else {
+			roots = append(roots, t)
+		}
+	}
+
+	result := Todos{}
+	visited := map[int]bool{}
+	// guides is the prefix drawn before the branches of ts, nested below
+	// the parents that still have siblings after them
+	var walk func(ts Todos, root bool, guides string)
+	walk = func(ts Todos, root bool, guides string) {
+		for i, t := range ts {
+			if visited[t.ID] {
+				continue
+			}
+			visited[t.ID] = true
+			if indent && t.Progress != nil {
+				t.Description += fmt.Sprintf(" [%d%%]", *t.Progress)
+			}
+			branch, below := "├─ ", "│  "
+			if i == len(ts)-1 {
+				branch, below = "└─ ", "   "
+			}
+			if root {
+				branch, below = "", ""
+			}
+			if indent {
+				t.Description = guides + branch + t.Description
+			}
+			result = append(result, t)
+			walk(children[t.ID], false, guides+below)
+		}
+	}
+	walk(roots, true, "")
+	for _, t := range todos {
+		walk(Todos{t}, true, "")
+	}
+	return result
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Helper to build a small project with subtasks and dependencies
+func depTodos() Todos {
+	return Todos{
+		{ID: 1, Description: "Release", Status: StatusTodo},
+		{ID: 2, Description: "Write code", Status: StatusDone, ParentID: 1},
+		{ID: 3, Description: "Write docs", Status: StatusTodo, ParentID: 1, BlockedBy: []int{2}},
+		{ID: 4, Description: "Proofread docs", Status: StatusTodo, ParentID: 3, BlockedBy: []int{3}},
+		{ID: 5, Description: "Old idea", Status: StatusCancelled, ParentID: 1},
+	}
+}
+
+func TestCheckParent(t *testing.T) {
+	todos := depTodos()
+
+	if err := todos.checkParent(4, 2); err != nil {
+		t.Errorf("Expected valid parent, got %v", err)
+	}
+	if err := todos.checkParent(1, 1); err == nil {
+		t.Error("Expected error for own parent, got nil")
+	}
+	if err := todos.checkParent(1, 4); err == nil {
+		t.Error("Expected cycle error for parent below the task, got nil")
+	}
+	if err := todos.checkParent(1, 9); err == nil {
+		t.Error("Expected error for unknown parent, got nil")
+	}
+}
+
+func TestCheckBlockers(t *testing.T) {
+	todos := depTodos()
+
+	if err := todos.checkBlockers(3, []int{5}); err != nil {
+		t.Errorf("Expected valid blocker, got %v", err)
+	}
+	if err := todos.checkBlockers(3, []int{3}); err == nil {
+		t.Error("Expected error for self block, got nil")
+	}
+	// 4 is blocked by 3 which is blocked by 2: 2 waiting on 4 closes the loop
+	if err := todos.checkBlockers(2, []int{4}); err == nil {
+		t.Error("Expected cycle error, got nil")
+	}
+	if err := todos.checkBlockers(2, []int{9}); err == nil {
+		t.Error("Expected error for unknown blocker, got nil")
+	}
+}
+
+func TestRollup(t *testing.T) {
+	todos := depTodos()
+	todos.rollup()
+
+	// Task 1: 2 done, 3 and 4 open, 5 cancelled and not counted
+	if todos[0].Progress == nil || *todos[0].Progress != 33 {
+		t.Errorf("Expected 33%% for task 1, got %v", todos[0].Progress)
+	}
+	if todos[2].Progress == nil || *todos[2].Progress != 0 {
+		t.Errorf("Expected 0%% for task 3, got %v", todos[2].Progress)
+	}
+	if todos[1].Progress != nil {
+		t.Errorf("Expected no progress for a task without subtasks, got %d", *todos[1].Progress)
+	}
+}
+
+func TestTree(t *testing.T) {
+	todos := depTodos()
+	todos = append(todos, Todo{ID: 6, Description: "Unrelated"})
+	todos[0], todos[3] = todos[3], todos[0]
+
+	got := todos.tree(false)
+	if want := []int{1, 2, 3, 4, 5, 6}; !equalIDs(ids(got), want) {
+		t.Errorf("Expected %v, got %v", want, ids(got))
+	}
+
+	// Without its parent a subtask becomes a root
+	if want := []int{4, 2}; !equalIDs(ids(Todos{todos[0], todos[1]}.tree(false)), want) {
+		t.Errorf("Expected orphan as root")
+	}
+
+	// Siblings before the last one branch off and keep the guide below them
+	var lines []string
+	for _, todo := range depTodos().tree(true) {
+		lines = append(lines, todo.Description)
+	}
+	want := []string{
+		"Release",
+		"├─ Write code",
+		"├─ Write docs",
+		"│  └─ Proofread docs",
+		"└─ Old idea",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected the tree\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(lines, "\n"))
+	}
+}
+
+func TestDeleteUnlinks(t *testing.T) {
+	todos := depTodos()
+	todos.delete(3)
+
+	if todos[2].ID != 4 || todos[2].ParentID != 1 || todos[2].BlockedBy != nil {
+		t.Errorf("Expected task 4 moved to task 1 and unblocked, got %+v", todos[2])
+	}
+}
+
+func TestAppDependencies(t *testing.T) {
+	app := newTestApp(t)
+	runCmd(t, app, "add", "Release")
+	runCmd(t, app, "add", "--parent", "1", "Write code")
+	runCmd(t, app, "add", "--parent", "1", "--blocked-by", "2", "Write docs")
+
+	code, _, stderr := runCmd(t, app, "mark", "3", "done")
+	if code != ExitError || !strings.Contains(stderr, "blocked by open tasks 2") {
+		t.Errorf("Expected refusal while task 2 is open, got %d: %s", code, stderr)
+	}
+	runCmd(t, app, "mark", "2", "done")
+	if code, _, stderr = runCmd(t, app, "mark", "3", "done"); code != ExitOK {
+		t.Errorf("Expected task 3 done once unblocked, got %d: %s", code, stderr)
+	}
+
+	_, stdout, _ := runCmd(t, app, "list", "--tree", "--fields", "id,description")
+	if !strings.Contains(stdout, "Release [100%]") || !strings.Contains(stdout, "└─ Write docs") {
+		t.Errorf("Expected tree with rollup, got '%s'", stdout)
+	}
+	_, stdout, _ = runCmd(t, app, "list", "--output", "jsonl", "--fields", "id,parent,progress", "--limit", "2")
+	if stdout != "{\"id\":1,\"parent\":null,\"progress\":100}\n{\"id\":2,\"parent\":1,\"progress\":null}\n" {
+		t.Errorf("Unexpected JSON: %q", stdout)
+	}
+
+	testCases := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"Unknown parent", []string{"add", "--parent", "9", "Orphan"}, ExitError},
+		{"Parent cycle", []string{"update", "--parent", "3", "1"}, ExitError},
+		{"Dependency cycle", []string{"update", "--blocked-by", "3", "2"}, ExitError},
+		{"Bad id", []string{"add", "--blocked-by", "x", "Bad"}, ExitUsage},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if code, _, _ := runCmd(t, app, tc.args...); code != tc.code {
+				t.Errorf("Expected exit code %d, got %d", tc.code, code)
+			}
+		})
+	}
+
+	runCmd(t, app, "update", "--unblock", "2", "--parent", "none", "3")
+	todos := loadTodos(t, app)
+	if todos[2].ParentID != 0 || todos[2].BlockedBy != nil {
+		t.Errorf("Expected task 3 detached, got %+v", todos[2])
+	}
+}
@@ -0,0 +1,480 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// doctor checks the files of lists for damage, old versions and
+// inconsistent tasks, and repairs them in fix mode.
+type doctor struct {
+	ctx       *Context
+	fix       bool
+	found     int
+	unfixable int
+}
+
+// storedList is a list as stored, without the repairs TodoList makes when
+// it is decoded.
+type storedList struct {
+	NextID int   `json:"nextId"`
+	Todos  Todos `json:"todos"`
+}
+
+func (d *doctor) report(path, format string, a ...any) {
+	fmt.Fprintf(d.ctx.Stdout, "%s: %s\n", filepath.Base(path), fmt.Sprintf(format, a...))
+	d.found++
+}
+
+// load reads the versioned file at path into v. It reports whether v was
+// read, and whether the file has to be written back because it is damaged
+// or in an old version, which is backed up in fix mode. A missing file is
+// neither.
+func (d *doctor) load(path, schema string, v any) (read, rewrite bool, err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	raw, version, err := decodeStored(data, schema)
+	if err == nil {
+		err = json.Unmarshal(raw, v)
+	}
+	current := schemaVersion(schema)
+	switch {
+	case version > current:
+		d.report(path, "%v", err)
+		d.unfixable++
+		return false, false, nil
+	case err != nil:
+		return false, true, err
+	case version < current:
+		d.report(path, "stored in version %d of the %s schema, the current version is %d", version, schema, current)
+		if !d.fix {
+			return true, true, nil
+		}
+		if err := backupFile(path, version, data); err != nil {
+			d.report(path, "cannot keep a backup before the upgrade: %v", err)
+			d.unfixable++
+			return false, false, nil
+		}
+		return true, true, nil
+	}
+	return true, false, nil
+}
+
+// keepDamaged moves a damaged file out of the way before it is replaced by
+// what could be recovered.
+func keepDamaged(path string) error {
+	return os.Rename(path, path+".corrupt")
+}
+
+// checkList checks the list stored at path along with its archive,
+// journal and sync state.
+func (d *doctor) checkList(backend, path string) error {
+	lock, err := LockStore(path, d.fix)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	list, ok, rewrite, damaged, err := d.loadList(backend, path)
+	if err != nil || !ok {
+		return err
+	}
+	before := cloneTodos(list.Todos)
+
+	var archive Archive
+	_, rewriteArchive, err := d.load(archivePath(path), "archive", &archive)
+	archiveDamaged := err != nil
+	if archiveDamaged {
+		data, _ := os.ReadFile(archivePath(path))
+		archive.Todos = salvageTodos(data)
+		d.report(archivePath(path), "damaged, %d archived %s can be recovered: %v", len(archive.Todos), plural(len(archive.Todos), "task"), err)
+	}
+	found, archived := d.found, cloneTodos(archive.Todos)
+	d.checkTodos(path, &list, &archive)
+	rewrite = rewrite || damaged || !slices.EqualFunc(before, list.Todos, sameTodo) || d.found > found
+	rewriteArchive = rewriteArchive || archiveDamaged || !slices.EqualFunc(archived, archive.Todos, sameTodo)
+
+	var journal Journal
+	_, rewriteJournal, err := d.load(journalPath(path), "journal", &journal)
+	if err != nil {
+		d.report(journalPath(path), "damaged, the undo history is lost: %v", err)
+	} else if journal.Position < 0 || journal.Position > len(journal.Entries) {
+		d.report(journalPath(path), "position %d is outside the %d entries", journal.Position, len(journal.Entries))
+		journal.Position = min(max(journal.Position, 0), len(journal.Entries))
+		rewriteJournal = true
+	}
+	journalDamaged := err != nil
+
+	var state SyncState
+	_, rewriteState, err := d.load(syncStatePath(path), "sync-state", &state)
+	if err != nil {
+		d.report(syncStatePath(path), "damaged, the next sync treats every task as new: %v", err)
+	}
+	stateDamaged := err != nil
+
+	if !d.fix {
+		return nil
+	}
+	if journalDamaged {
+		if err := keepDamaged(journalPath(path)); err != nil {
+			return err
+		}
+	} else if rewriteJournal {
+		if err := NewStorage[Journal](journalPath(path)).Save(journal); err != nil {
+			return err
+		}
+	}
+	if stateDamaged {
+		if err := keepDamaged(syncStatePath(path)); err != nil {
+			return err
+		}
+	} else if rewriteState {
+		if err := NewStorage[SyncState](syncStatePath(path)).Save(state); err != nil {
+			return err
+		}
+	}
+	if archiveDamaged {
+		if err := keepDamaged(archivePath(path)); err != nil {
+			return err
+		}
+	}
+	if rewriteArchive {
+		if err := NewStorage[Archive](archivePath(path)).Save(archive); err != nil {
+			return err
+		}
+	}
+
+	if !rewrite {
+		return nil
+	}
+	if damaged {
+		if err := keepDamaged(path); err != nil {
+			return err
+		}
+	}
+	store, _ := OpenStore(backend, path)
+	if err := store.Save(list); err != nil {
+		return err
+	}
+	j, err := OpenJournal(journalPath(path))
+	if err != nil {
+		return err
+	}
+	args := []string{"doctor", "--fix"}
+	j.Record(commandLine(args), before, list.Todos, nil, time.Now())
+	if err := j.Save(); err != nil {
+		return err
+	}
+	d.ctx.List = &list
+	return d.ctx.commitGit(path, args, before)
+}
+
+// loadList reads the list at path as stored. It reports whether there is
+// a list to check, and whether it has to be written back because it is in
+// an old version or damaged. Of a damaged list, the tasks that can be
+// recovered are returned.
+func (d *doctor) loadList(backend, path string) (list TodoList, ok, rewrite, damaged bool, err error) {
+	switch backend {
+	case "json":
+		var stored storedList
+		read, rewrite, err := d.load(path, "tasks", &stored)
+		if err != nil {
+			data, _ := os.ReadFile(path)
+			stored.Todos = salvageTodos(data)
+			d.report(path, "damaged, %d %s can be recovered: %v", len(stored.Todos), plural(len(stored.Todos), "task"), err)
+			return TodoList{Todos: stored.Todos}, true, true, true, nil
+		}
+		return TodoList{NextID: stored.NextID, Todos: stored.Todos}, read, rewrite, false, nil
+	case "jsonl":
+		err := NewJSONLStore(path).Load(&list)
+		if errors.Is(err, fs.ErrNotExist) {
+			return list, false, false, false, nil
+		}
+		if err != nil {
+			list, skipped := salvageJSONL(path)
+			d.report(path, "damaged, skipping %d unreadable %s: %v", skipped, plural(skipped, "record"), err)
+			return list, true, true, true, nil
+		}
+		return list, true, false, false, nil
+	default:
+		store, _ := OpenStore(backend, path)
+		err := store.Load(&list)
+		if errors.Is(err, fs.ErrNotExist) {
+			return list, false, false, false, nil
+		}
+		if err != nil {
+			d.report(path, "damaged, restore it from a backup or an export: %v", err)
+			d.unfixable++
+			return list, false, false, false, nil
+		}
+		return list, true, false, false, nil
+	}
+}
+
+// checkTodos reports the inconsistencies of a list and its archive,
+// repairing them in place.
+func (d *doctor) checkTodos(path string, l *TodoList, archive *Archive) {
+	task := func(t Todo, format string, a ...any) {
+		d.report(path, "task %d: %s", t.ID, fmt.Sprintf(format, a...))
+	}
+
+	seen := map[int]bool{}
+	for _, t := range l.Todos {
+		switch {
+		case t.ID < 1:
+			d.report(path, "task %q has no ID, it gets a new one", t.Description)
+		case seen[t.ID]:
+			task(t, "ID is not unique, the task gets a new one")
+		}
+		seen[t.ID] = true
+	}
+	highest := 0
+	for _, todos := range []Todos{l.Todos, archive.Todos} {
+		for _, t := range todos {
+			highest = max(highest, t.ID)
+		}
+	}
+	if highest > 0 && l.NextID <= highest {
+		d.report(path, "next ID %d is not above the highest ID %d", l.NextID, highest)
+		l.NextID = highest + 1
+	}
+	l.normalizeIDs()
+	for i := range archive.Todos {
+		if t := &archive.Todos[i]; l.lookup(t.ID) != nil {
+			d.report(archivePath(path), "archived task %d has the ID of a task in the list, it becomes task %d", t.ID, l.NextID)
+			t.ID = l.NextID
+			l.NextID++
+		}
+	}
+
+	var running []*Todo
+	for i := range l.Todos {
+		t := &l.Todos[i]
+		if !t.Status.Valid() {
+			status := normalizeStatus(t.Status)
+			if !status.Valid() {
+				status = StatusTodo
+			}
+			task(*t, "unknown status %q, set to %s", t.Status, status)
+			t.Status = status
+		}
+		if t.Description == "" {
+			task(*t, "no description")
+			t.Description = fmt.Sprintf("Task %d", t.ID)
+		}
+		if tags := addTags(nil, t.Tags...); !slices.Equal(tags, t.Tags) {
+			task(*t, "tags are not sorted or repeat")
+			t.Tags = tags
+		}
+
+		switch {
+		case t.Status == StatusDone && t.CompletedAt == nil:
+			task(*t, "done without a completion time")
+			done := lastChange(*t)
+			t.CompletedAt = &done
+		case t.Status != StatusDone && t.CompletedAt != nil:
+			task(*t, "completion time set but the task is %s", t.Status)
+			t.CompletedAt = nil
+		}
+
+		var intervals []Interval
+		for j, iv := range t.Intervals {
+			switch {
+			case iv.End != nil && iv.End.Before(iv.Start):
+				task(*t, "tracked interval ends before it starts")
+				continue
+			case iv.End == nil && j < len(t.Intervals)-1:
+				task(*t, "tracked interval left running")
+				iv.End = &t.Intervals[j+1].Start
+			}
+			intervals = append(intervals, iv)
+		}
+		t.Intervals = intervals
+		if t.Running() && t.Status.Finished() {
+			task(*t, "timer running although the task is %s", t.Status)
+			t.stopTimer(lastChange(*t))
+		}
+		if t.Running() {
+			running = append(running, t)
+		}
+	}
+	// Only the timer started last keeps running, as with start
+	started := func(t *Todo) time.Time { return t.Intervals[len(t.Intervals)-1].Start }
+	slices.SortFunc(running, func(a, b *Todo) int { return started(b).Compare(started(a)) })
+	for _, t := range running[min(1, len(running)):] {
+		task(*t, "timer running along with the one of task %d", running[0].ID)
+		t.stopTimer(started(running[0]))
+	}
+
+	for i := range l.Todos {
+		t := &l.Todos[i]
+		switch {
+		case t.ParentID == 0:
+		case l.lookup(t.ParentID) == nil:
+			task(*t, "parent %d does not exist", t.ParentID)
+			t.ParentID = 0
+		case parentCycle(l.Todos, t.ID):
+			task(*t, "parent %d makes a cycle", t.ParentID)
+			t.ParentID = 0
+		}
+
+		blockers := t.BlockedBy
+		t.BlockedBy = nil
+		for _, b := range blockers {
+			switch {
+			case b == t.ID:
+				task(*t, "blocks itself")
+			case l.lookup(b) == nil:
+				task(*t, "blocked by %d, which does not exist", b)
+			case slices.Contains(t.BlockedBy, b):
+				task(*t, "blocked by %d twice", b)
+			case l.Todos.dependsOn(b, t.ID):
+				task(*t, "blocked by %d makes a cycle", b)
+			default:
+				t.BlockedBy = append(t.BlockedBy, b)
+			}
+		}
+	}
+}
+
+// parentCycle reports whether following the parents of task id leads back
+// to it.
+func parentCycle(todos Todos, id int) bool {
+	seen := map[int]bool{}
+	for p := id; p != 0 && !seen[p]; {
+		seen[p] = true
+		index, err := todos.IndexOf(p)
+		if err != nil {
+			return false
+		}
+		p = todos[index].ParentID
+		if p == id {
+			return true
+		}
+	}
+	return false
+}
+
+// salvageTodos recovers the tasks of a damaged list or archive: those in
+// its todos array up to the first one that cannot be read.
+func salvageTodos(data []byte) Todos {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for first := true; ; first = false {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil
+		}
+		// The todos key, or the bare array of the first versions
+		if tok != "todos" && !(first && tok == json.Delim('[')) {
+			continue
+		}
+		if tok == "todos" {
+			if tok, err = dec.Token(); err != nil || tok != json.Delim('[') {
+				continue
+			}
+		}
+		var todos Todos
+		for dec.More() {
+			var t Todo
+			if err := dec.Decode(&t); err != nil {
+				break
+			}
+			todos = append(todos, t)
+		}
+		return todos
+	}
+}
+
+// salvageJSONL replays the records of a damaged log that can be read and
+// returns the list with the number of records skipped.
+func salvageJSONL(path string) (TodoList, int) {
+	list, skipped := TodoList{}, 0
+	data, _ := os.ReadFile(path)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		record := bytes.TrimSpace(scanner.Bytes())
+		if len(record) == 0 {
+			continue
+		}
+		var rec jsonlRecord
+		if json.Unmarshal(record, &rec) != nil || list.apply(rec) != nil {
+			skipped++
+		}
+	}
+	return list, skipped
+}
+
+func newDoctorCmd() *Command {
+	flags := newFlagSet("doctor")
+	fix := flags.Bool("fix", false, "repair the problems found")
+	all := flags.Bool("all", false, "check every list instead of the current one")
+
+	return &Command{
+		Name:    "doctor",
+		Summary: "Check the stored lists for damage and inconsistencies, and repair them with -fix.",
+		Flags:   flags,
+		NoStore: true,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) > 0 {
+				return usageErrorf("unexpected argument %q", args[0])
+			}
+			backend := ctx.App.StoreBackend()
+			var paths []string
+			if *all {
+				names, err := ListNames(backend)
+				if err != nil {
+					return err
+				}
+				for _, name := range names {
+					path, err := StorePath(backend, "", name)
+					if err != nil {
+						return err
+					}
+					paths = append(paths, path)
+				}
+			} else {
+				path, err := ctx.App.StorePath()
+				if err != nil {
+					return err
+				}
+				paths = append(paths, path)
+			}
+
+			d := &doctor{ctx: ctx, fix: *fix}
+			for _, path := range paths {
+				if err := d.checkList(backend, path); err != nil {
+					return fmt.Errorf("%s: %w", filepath.Base(path), err)
+				}
+			}
+
+			fixable := d.found - d.unfixable
+			switch {
+			case d.found == 0:
+				fmt.Fprintf(ctx.Stdout, "No problems found in %d %s\n", len(paths), plural(len(paths), "list"))
+				return nil
+			case !d.fix && fixable > 0:
+				return fmt.Errorf("found %d %s, run doctor -fix to repair them", d.found, plural(d.found, "problem"))
+			case d.fix && fixable > 0:
+				fmt.Fprintf(ctx.Stdout, "Repaired %d %s\n", fixable, plural(fixable, "problem"))
+			}
+			if d.unfixable > 0 {
+				return fmt.Errorf("%d %s cannot be repaired", d.unfixable, plural(d.unfixable, "problem"))
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckTodos(t *testing.T) {
+	now := time.Date(2026, 10, 12, 9, 0, 0, 0, time.Local)
+	later := now.Add(time.Hour)
+	list := TodoList{NextID: 2, Todos: Todos{
+		{ID: 1, Description: "Write", Status: "in-progres", CompletedAt: &now, BlockedBy: []int{2, 9, 1}},
+		{ID: 2, Description: "Review", Status: StatusTodo, ParentID: 3, BlockedBy: []int{1}},
+		{ID: 3, Description: "", Status: StatusDone, ParentID: 2, Tags: []string{"z", "a"}},
+		{ID: 3, Description: "Timed", Status: StatusInProgress, Intervals: []Interval{{Start: now}}},
+		{ID: 5, Description: "Timed too", Status: StatusInProgress, Intervals: []Interval{{Start: later, End: &now}, {Start: later}}},
+	}}
+	archive := &Archive{Todos: Todos{{ID: 5, Description: "Archived", Status: StatusDone}}}
+	var buf bytes.Buffer
+	d := &doctor{ctx: &Context{Stdout: &buf}}
+	d.checkTodos("todos.json", &list, archive)
+
+	want := []string{
+		"task 3: ID is not unique",
+		"next ID 2 is not above the highest ID 5",
+		"archived task 5 has the ID of a task in the list, it becomes task 7",
+		`task 1: unknown status "in-progres", set to in-progress`,
+		"task 1: completion time set but the task is in-progress",
+		"task 3: no description",
+		"task 3: tags are not sorted or repeat",
+		"task 3: done without a completion time",
+		"task 5: tracked interval ends before it starts",
+		"task 6: timer running along with the one of task 5",
+		"task 1: blocked by 2 makes a cycle",
+		"task 1: blocked by 9, which does not exist",
+		"task 1: blocks itself",
+		"task 2: parent 3 makes a cycle",
+	}
+	for _, w := range want {
+		if !strings.Contains(buf.String(), w) {
+			t.Errorf("Expected %q, got:\n%s", w, buf.String())
+		}
+	}
+	if d.found != len(want) {
+		t.Errorf("Expected %d problems, got %d:\n%s", len(want), d.found, buf.String())
+	}
+
+	// The repaired list passes
+	if list.Todos[3].ID != 6 || list.NextID != 8 || archive.Todos[0].ID != 7 {
+		t.Errorf("Expected new IDs for the duplicates, got %+v, %+v", list, archive.Todos)
+	}
+	buf.Reset()
+	d.found = 0
+	d.checkTodos("todos.json", &list, archive)
+	if d.found != 0 {
+		t.Errorf("Expected no problems after the repair, got:\n%s", buf.String())
+	}
+}
+
+func TestAppDoctor(t *testing.T) {
+	app := newTestApp(t)
+	runCmd(t, app, "add", "Write")
+	runCmd(t, app, "add", "Review")
+	if code, stdout, _ := runCmd(t, app, "doctor"); code != 0 || !strings.Contains(stdout, "No problems found in 1 list") {
+		t.Fatalf("Expected no problems, got %d %q", code, stdout)
+	}
+
+	// An old list with a broken link
+	old := `{"nextId": 3, "todos": [{"ID": 1, "description": "Write", "status": "todo"}, {"ID": 2, "description": "Review", "status": "todo", "parentId": 7}]}`
+	os.WriteFile(app.File, []byte(old), 0o644)
+	code, stdout, stderr := runCmd(t, app, "doctor")
+	if code != 1 || !strings.Contains(stdout, "stored in version 1") || !strings.Contains(stdout, "task 2: parent 7 does not exist") ||
+		!strings.Contains(stderr, "found 2 problems") {
+		t.Fatalf("Expected two problems, got %d %q %q", code, stdout, stderr)
+	}
+	if data, _ := os.ReadFile(app.File); string(data) != old {
+		t.Errorf("Expected the list left alone without -fix, got %s", data)
+	}
+
+	code, stdout, _ = runCmd(t, app, "doctor", "-fix")
+	if code != 0 || !strings.Contains(stdout, "Repaired 2 problems") {
+		t.Fatalf("Expected the problems repaired, got %d %q", code, stdout)
+	}
+	if backup, err := os.ReadFile(backupPath(app.File, 1)); err != nil || string(backup) != old {
+		t.Errorf("Expected the old list backed up, got %q, %v", backup, err)
+	}
+	if todos := loadTodos(t, app); todos[1].ParentID != 0 {
+		t.Errorf("Expected the parent unlinked, got %+v", todos[1])
+	}
+	if code, _, _ := runCmd(t, app, "doctor"); code != 0 {
+		t.Errorf("Expected no problems after the repair, got %d", code)
+	}
+
+	// The repair is undoable like any change
+	_, stdout, _ = runCmd(t, app, "history")
+	if !strings.Contains(stdout, "doctor --fix") {
+		t.Errorf("Expected the repair in the history, got %q", stdout)
+	}
+}
+
+func TestAppDoctorDamagedFiles(t *testing.T) {
+	app := newTestApp(t)
+	runCmd(t, app, "add", "Write")
+	runCmd(t, app, "add", "Review")
+	runCmd(t, app, "add", "Ship")
+
+	// Cut off in the third task, e.g. by a disk running full
+	data, _ := os.ReadFile(app.File)
+	damaged := data[:bytes.Index(data, []byte(`"Ship"`))]
+	os.WriteFile(app.File, damaged, 0o644)
+	os.WriteFile(journalPath(app.File), []byte("{not json"), 0o644)
+
+	if code, _, _ := runCmd(t, app, "list"); code != 1 {
+		t.Errorf("Expected a damaged list to fail, got %d", code)
+	}
+	code, stdout, _ := runCmd(t, app, "doctor", "-fix")
+	if code != 0 || !strings.Contains(stdout, "damaged, 2 tasks can be recovered") || !strings.Contains(stdout, "journal: damaged") {
+		t.Fatalf("Expected the list recovered, got %d %q", code, stdout)
+	}
+	if todos := loadTodos(t, app); len(todos) != 2 || todos[1].Description != "Review" {
+		t.Errorf("Expected two tasks recovered, got %+v", todos)
+	}
+	if kept, _ := os.ReadFile(app.File + ".corrupt"); !bytes.Equal(kept, damaged) {
+		t.Errorf("Expected the damaged list kept, got %q", kept)
+	}
+	if _, err := os.Stat(journalPath(app.File) + ".corrupt"); err != nil {
+		t.Errorf("Expected the damaged journal kept, got %v", err)
+	}
+	if _, stdout, _ := runCmd(t, app, "add", "Ship"); !strings.Contains(stdout, "Added task 3") {
+		t.Errorf("Expected IDs to continue after the recovered tasks, got %q", stdout)
+	}
+}
+
+func TestSalvageJSONL(t *testing.T) {
+	app := newTestApp(t)
+	t.Setenv("TASK_STORE", "jsonl")
+	app.File = strings.TrimSuffix(app.File, ".json") + ".jsonl"
+	runCmd(t, app, "add", "Write")
+	runCmd(t, app, "add", "Review")
+
+	data, _ := os.ReadFile(app.File)
+	lines := strings.SplitAfter(string(data), "\n")
+	lines = append(lines[:1], append([]string{"{\"op\":\"explode\"}\n"}, lines[1:]...)...)
+	os.WriteFile(app.File, []byte(strings.Join(lines, "")), 0o644)
+
+	code, stdout, _ := runCmd(t, app, "doctor", "-fix")
+	if code != 0 || !strings.Contains(stdout, "skipping 1 unreadable record") {
+		t.Fatalf("Expected the bad record skipped, got %d %q", code, stdout)
+	}
+	if todos := loadTodos(t, app); len(todos) != 2 {
+		t.Errorf("Expected both tasks kept, got %+v", todos)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"cmp"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// dueNone clears a due date, e.g. `task update -due none 3`.
+const dueNone = "none"
+
+// Deadline is the moment the task becomes overdue. A due date without a
+// time of day lasts until the end of that day.
+func (t Todo) Deadline() (time.Time, bool) {
+	if t.DueAt == nil {
+		return time.Time{}, false
+	}
+	if isAllDay(*t.DueAt) {
+		return t.DueAt.AddDate(0, 0, 1), true
+	}
+	return *t.DueAt, true
+}
+
+// Overdue reports whether an open task is past its deadline at now.
+func (t Todo) Overdue(now time.Time) bool {
+	deadline, ok := t.Deadline()
+	return ok && !t.Status.Finished() && !now.Before(deadline)
+}
+
+// Overdue returns the open tasks past their deadline, most overdue first.
+func (todos Todos) Overdue(now time.Time) Todos {
+	matched := Todos{}
+	for _, t := range todos {
+		if t.Overdue(now) {
+			matched = append(matched, t)
+		}
+	}
+	matched.Sort("due", false)
+	return matched
+}
+
+// Upcoming returns the open tasks due within the given window from now that
+// are not overdue yet, soonest first.
+func (todos Todos) Upcoming(now time.Time, within time.Duration) Todos {
+	matched := Todos{}
+	for _, t := range todos {
+		if t.DueAt == nil || t.Status.Finished() || t.Overdue(now) {
+			continue
+		}
+		if t.DueAt.Before(now.Add(within)) {
+			matched = append(matched, t)
+		}
+	}
+	matched.Sort("due", false)
+	return matched
+}
+
+// compareDue orders tasks by due date, tasks without one last.
+func compareDue(a, b Todo) int {
+	switch {
+	case a.DueAt == nil && b.DueAt == nil:
+		return 0
+	case a.DueAt == nil:
+		return 1
+	case b.DueAt == nil:
+		return -1
+	}
+	return cmp.Compare(a.DueAt.UnixNano(), b.DueAt.UnixNano())
+}
+
+// parseDue resolves a -due flag value. It returns nil for "none".
+func parseDue(value string, now time.Time) (*time.Time, error) {
+	if strings.EqualFold(value, dueNone) {
+		return nil, nil
+	}
+	due, err := ParseDate(value, now)
+	if err != nil {
+		return nil, usageErrorf("%v", err)
+	}
+	return &due, nil
+}
+
+// colorOutput decides whether table output may use ANSI colours: only on a
+// terminal and only when NO_COLOR is unset.
+var colorOutput = func(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+const (
+	ansiRed   = "\x1b[31m"
+	ansiReset = "\x1b[0m"
+)
+
+const dueFields = "id,description,status,due"
+
+func newOverdueCmd() *Command {
+	flags := newFlagSet("overdue")
+	output := addOutputFlags(flags, dueFields)
+
+	return &Command{
+		Name:     "overdue",
+		Summary:  "List open tasks past their due date.",
+		Flags:    flags,
+		ReadOnly: true,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) > 0 {
+				return usageErrorf("unexpected argument %q", args[0])
+			}
+			return output.render(ctx.Stdout, ctx.List.Overdue(time.Now()))
+		},
+	}
+}
+
+func newUpcomingCmd() *Command {
+	flags := newFlagSet("upcoming")
+	within := flags.String("within", "7d", "how far ahead to look, e.g. 3d, 2w or 12h")
+	output := addOutputFlags(flags, dueFields)
+
+	return &Command{
+		Name:     "upcoming",
+		Summary:  "List open tasks due soon.",
+		Flags:    flags,
+		ReadOnly: true,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) > 0 {
+				return usageErrorf("unexpected argument %q", args[0])
+			}
+			window, err := parseDuration(*within)
+			if err != nil {
+				return usageErrorf("%v", err)
+			}
+			return output.render(ctx.Stdout, ctx.List.Upcoming(time.Now(), window))
+		},
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Helper to build tasks due relative to now
+func dueTodos(now time.Time) Todos {
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+	today := startOfDay(now)
+	tomorrow := today.AddDate(0, 0, 1)
+	lastWeek := today.AddDate(0, 0, -7)
+
+	return Todos{
+		{ID: 1, Description: "No due date", Status: StatusTodo},
+		{ID: 2, Description: "Due an hour ago", Status: StatusInProgress, DueAt: at(-time.Hour)},
+		{ID: 3, Description: "Due today", Status: StatusTodo, DueAt: &today},
+		{ID: 4, Description: "Due tomorrow", Status: StatusTodo, DueAt: &tomorrow},
+		{ID: 5, Description: "Done last week", Status: StatusDone, DueAt: &lastWeek},
+		{ID: 6, Description: "Due last week", Status: StatusBlocked, DueAt: &lastWeek},
+		{ID: 7, Description: "Due next month", Status: StatusTodo, DueAt: at(30 * 24 * time.Hour)},
+	}
+}
+
+func TestTodoOverdue(t *testing.T) {
+	now := time.Date(2026, 10, 17, 15, 0, 0, 0, time.Local)
+	todos := dueTodos(now)
+
+	// All-day tasks stay on time until the day is over
+	if todos[2].Overdue(now) {
+		t.Error("Expected task due today not to be overdue")
+	}
+	if !todos[2].Overdue(startOfDay(now).AddDate(0, 0, 1)) {
+		t.Error("Expected task due today to be overdue tomorrow")
+	}
+
+	got := ids(todos.Overdue(now))
+	if want := []int{6, 2}; !equalIDs(got, want) {
+		t.Errorf("Expected overdue %v, got %v", want, got)
+	}
+}
+
+func TestTodosUpcoming(t *testing.T) {
+	now := time.Date(2026, 10, 17, 15, 0, 0, 0, time.Local)
+	todos := dueTodos(now)
+
+	got := ids(todos.Upcoming(now, 7*24*time.Hour))
+	if want := []int{3, 4}; !equalIDs(got, want) {
+		t.Errorf("Expected upcoming %v, got %v", want, got)
+	}
+}
+
+func TestTodosSortDue(t *testing.T) {
+	todos := dueTodos(time.Now())
+	todos.Sort("due", false)
+
+	// Tasks without a due date come last
+	if got := ids(todos); got[len(got)-1] != 1 {
+		t.Errorf("Expected task without due date last, got %v", got)
+	}
+}
+
+func TestRenderTableHighlightsOverdue(t *testing.T) {
+	defer func(orig func(io.Writer) bool) { colorOutput = orig }(colorOutput)
+	todos := dueTodos(time.Now())
+	fields, _ := lookupFields(dueFields)
+
+	var buf bytes.Buffer
+	colorOutput = func(io.Writer) bool { return false }
+	renderTable(&buf, todos, fields)
+	if strings.Contains(buf.String(), ansiRed) {
+		t.Errorf("Expected no colours, got '%s'", buf.String())
+	}
+
+	buf.Reset()
+	colorOutput = func(io.Writer) bool { return true }
+	renderTable(&buf, todos, fields)
+	for _, line := range strings.Split(buf.String(), "\n") {
+		overdue := strings.Contains(line, "Due an hour ago") || strings.Contains(line, "Due last week")
+		if overdue != strings.Contains(line, ansiRed) {
+			t.Errorf("Expected highlight only on overdue rows, got '%s'", line)
+		}
+	}
+}
+
+func TestAppDue(t *testing.T) {
+	app := newTestApp(t)
+	runCmd(t, app, "add", "--due", "yesterday", "Pay rent")
+	runCmd(t, app, "add", "Call mum", "--due", "+2d")
+	runCmd(t, app, "add", "Someday")
+
+	todos := loadTodos(t, app)
+	if todos[0].DueAt == nil || todos[1].DueAt == nil || todos[2].DueAt != nil {
+		t.Fatalf("Expected due dates on the first two tasks, got %+v", todos)
+	}
+	if want := startOfDay(time.Now()).AddDate(0, 0, 2); !todos[1].DueAt.Equal(want) {
+		t.Errorf("Expected due %v, got %v", want, todos[1].DueAt)
+	}
+
+	code, stdout, stderr := runCmd(t, app, "overdue", "--output", "jsonl", "--fields", "id")
+	if code != ExitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", ExitOK, code, stderr)
+	}
+	if stdout != "{\"id\":1}\n" {
+		t.Errorf("Expected only task 1 overdue, got %q", stdout)
+	}
+
+	_, stdout, _ = runCmd(t, app, "upcoming", "--within", "3d", "--output", "jsonl", "--fields", "id")
+	if stdout != "{\"id\":2}\n" {
+		t.Errorf("Expected only task 2 upcoming, got %q", stdout)
+	}
+	_, stdout, _ = runCmd(t, app, "upcoming", "--within", "1d", "--output", "jsonl")
+	if stdout != "" {
+		t.Errorf("Expected nothing due within a day, got %q", stdout)
+	}
+
+	// Changing and clearing a due date
+	runCmd(t, app, "update", "--due", "none", "1")
+	runCmd(t, app, "update", "--due", "2026-11-01", "3")
+	todos = loadTodos(t, app)
+	if todos[0].DueAt != nil || todos[0].Description != "Pay rent" {
+		t.Errorf("Expected due date cleared and description kept, got %+v", todos[0])
+	}
+	if todos[2].DueAt == nil || todos[2].DueAt.Format("2006-01-02") != "2026-11-01" {
+		t.Errorf("Expected due 2026-11-01, got %v", todos[2].DueAt)
+	}
+
+	code, _, _ = runCmd(t, app, "add", "--due", "soonish", "Bad")
+	if code != ExitUsage {
+		t.Errorf("Expected exit code %d for bad due date, got %d", ExitUsage, code)
+	}
+	code, _, _ = runCmd(t, app, "upcoming", "--within", "forever")
+	if code != ExitUsage {
+		t.Errorf("Expected exit code %d for bad window, got %d", ExitUsage, code)
+	}
+}
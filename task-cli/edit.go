@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// editHelp heads the document edit opens.
+const editHelp = `# Edit task %d, then save and close the editor to apply the changes.
+# Clear a field to unset it. Notes are one per line after their time;
+# lines without a time become new notes. Save an empty file to cancel.
+`
+
+// editField is a header field of the document, set through the update
+// flag of the same name.
+type editField struct {
+	name, flag string
+	value      func(t Todo) string
+}
+
+var editFields = []editField{
+	{"Description", "", func(t Todo) string { return t.Description }},
+	{"Status", "", func(t Todo) string { return string(t.Status) }},
+	{"Priority", "priority", func(t Todo) string { return string(t.Priority) }},
+	{"Project", "project", func(t Todo) string { return t.Project }},
+	{"Tags", "tag", func(t Todo) string { return strings.Join(t.Tags, ", ") }},
+	{"Due", "due", func(t Todo) string { return formatDueInput(t.DueAt) }},
+	{"Parent", "parent", func(t Todo) string {
+		if t.ParentID == 0 {
+			return ""
+		}
+		return strconv.Itoa(t.ParentID)
+	}},
+	{"Blocked-By", "blocked-by", func(t Todo) string { return strings.ReplaceAll(formatValue(t.BlockedBy, ""), ",", ", ") }},
+	{"Recur", "recur", func(t Todo) string {
+		if t.Recur == nil {
+			return ""
+		}
+		return t.Recur.String()
+	}},
+}
+
+// formatDueInput renders a due date the way ParseDate reads it back.
+func formatDueInput(due *time.Time) string {
+	switch {
+	case due == nil:
+		return ""
+	case isAllDay(*due):
+		return due.Format("2006-01-02")
+	default:
+		return due.Format("2006-01-02 15:04")
+	}
+}
+
+// renderEdit writes t as the document edit opens.
+func renderEdit(t Todo) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, editHelp, t.ID)
+	for _, f := range editFields {
+		fmt.Fprintf(&b, "%s: %s\n", f.name, f.value(t))
+	}
+	fmt.Fprintf(&b, "\nDetails:\n%s\n", t.Details)
+	fmt.Fprintln(&b, "\nNotes:")
+	for _, n := range t.Notes {
+		fmt.Fprintf(&b, "%s  %s\n", n.At.Format(noteLayout), n.Text)
+	}
+	return b.String()
+}
+
+// taskDocument is an edited document, see renderEdit.
+type taskDocument struct {
+	fields  map[string]string
+	details string
+	notes   []Note
+}
+
+// parseEdit reads back an edited document. It returns nil when the
+// document was emptied. Notes keep their time unless it was changed; new
+// ones are stamped now.
+func parseEdit(text string, t Todo, now time.Time) (*taskDocument, error) {
+	doc := &taskDocument{fields: map[string]string{}}
+	var details []string
+	section := ""
+	empty := true
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		empty = empty && strings.TrimSpace(line) == ""
+		switch {
+		case line == "Details:" && section == "":
+			section = "details"
+		case line == "Notes:" && section != "notes":
+			section = "notes"
+		case section == "details":
+			details = append(details, line)
+		case section == "notes":
+			if note, ok := parseNoteLine(line, t.Notes, now); ok {
+				doc.notes = append(doc.notes, note)
+			}
+		case strings.TrimSpace(line) == "":
+		default:
+			name, value, ok := strings.Cut(line, ":")
+			i := slices.IndexFunc(editFields, func(f editField) bool { return strings.EqualFold(f.name, strings.TrimSpace(name)) })
+			if !ok || i < 0 {
+				return nil, fmt.Errorf("line %d: expected a field such as Description: text, got %q", n, line)
+			}
+			doc.fields[editFields[i].name] = strings.TrimSpace(value)
+		}
+	}
+	if empty {
+		return nil, nil
+	}
+	doc.details = strings.Trim(strings.Join(details, "\n"), "\n")
+	return doc, scanner.Err()
+}
+
+// parseNoteLine reads a note line. A time matching an existing note keeps
+// that note's exact time, preferring the note with the same text when
+// several were made in the same minute.
+func parseNoteLine(line string, notes []Note, now time.Time) (Note, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return Note{}, false
+	}
+	if len(line) > len(noteLayout) {
+		stamp := line[:len(noteLayout)]
+		if at, err := time.ParseInLocation(noteLayout, stamp, now.Location()); err == nil {
+			text := strings.TrimSpace(line[len(noteLayout):])
+			matched := false
+			for _, n := range notes {
+				if n.At.Format(noteLayout) == stamp && (!matched || n.Text == text) {
+					at, matched = n.At, true
+					if n.Text == text {
+						break
+					}
+				}
+			}
+			return Note{At: at, Text: text}, text != ""
+		}
+	}
+	return Note{At: now, Text: line}, true
+}
+
+// apply changes t as the document says, checking the fields like update
+// does. It reports whether anything changed.
+func (doc *taskDocument) apply(l *TodoList, t *Todo, now time.Time) (bool, error) {
+	before := cloneTodo(*t)
+	fs := newFlagSet("edit")
+	attrs := addTaskFlags(fs, true)
+	var status Status
+	for _, f := range editFields {
+		value, ok := doc.fields[f.name]
+		if !ok || value == f.value(before) {
+			continue
+		}
+		switch f.name {
+		case "Description":
+			if err := setText(t, value); err != nil {
+				return false, err
+			}
+		case "Status":
+			s, err := ParseStatus(value)
+			if err != nil {
+				return false, usageErrorf("%v", err)
+			}
+			status = s
+		case "Tags", "Blocked-By":
+			if f.name == "Tags" {
+				t.Tags = nil
+			} else {
+				t.BlockedBy = nil
+			}
+			if err := fs.Set(f.flag, value); err != nil {
+				return false, fmt.Errorf("%s: %w", f.name, err)
+			}
+		default:
+			if value == "" {
+				value = "none"
+			}
+			if err := fs.Set(f.flag, value); err != nil {
+				return false, fmt.Errorf("%s: %w", f.name, err)
+			}
+		}
+	}
+	if err := attrs.apply(l.Todos, t, now); err != nil {
+		return false, err
+	}
+	t.Details = doc.details
+	t.Notes = doc.notes
+
+	if status != "" {
+		index, _ := l.IndexOf(t.ID)
+		l.Todos[index] = *t
+		if err := l.StatusChange(status, t.ID); err != nil {
+			return false, err
+		}
+		*t = l.Todos[index]
+	}
+	if sameTodo(*t, before) {
+		return false, nil
+	}
+	t.UpdatedAt = &now
+	return true, nil
+}
+
+// editor returns the command line of the user's editor.
+func editor() []string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(name)); len(fields) > 0 {
+			return fields
+		}
+	}
+	return []string{"vi"}
+}
+
+// runEditor opens path in the user's editor and waits for it to close.
+var runEditor = func(ctx *Context, path string) error {
+	args := editor()
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = ctx.App.Stdin, ctx.Stdout, ctx.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("running %s: %w", args[0], err)
+	}
+	return nil
+}
+
+func newEditCmd() *Command {
+	return &Command{
+		Name:    "edit",
+		Args:    "<id>",
+		Summary: "Edit a task with its details and notes in $EDITOR.",
+		Flags:   newFlagSet("edit"),
+		Run: func(ctx *Context, args []string) error {
+			if len(args) != 1 {
+				return usageErrorf("expected exactly one task id")
+			}
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			index, err := ctx.List.IndexOf(id)
+			if err != nil {
+				return err
+			}
+
+			f, err := os.CreateTemp("", fmt.Sprintf("task-%d-*.txt", id))
+			if err != nil {
+				return err
+			}
+			path := f.Name()
+			_, err = f.WriteString(renderEdit(ctx.List.Todos[index]))
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err == nil {
+				err = runEditor(ctx, path)
+			}
+			var data []byte
+			if err == nil {
+				data, err = os.ReadFile(path)
+			}
+			if err != nil {
+				os.Remove(path)
+				return err
+			}
+
+			// The edit is kept when it cannot be applied, so it is not lost
+			now := time.Now()
+			doc, err := parseEdit(string(data), ctx.List.Todos[index], now)
+			var changed bool
+			if err == nil && doc != nil {
+				t := cloneTodo(ctx.List.Todos[index])
+				if changed, err = doc.apply(ctx.List, &t, now); err == nil {
+					index, _ = ctx.List.IndexOf(id)
+					ctx.List.Todos[index] = t
+				}
+			}
+			if err != nil {
+				var usageErr *UsageError
+				if errors.As(err, &usageErr) {
+					err = errors.New(usageErr.Msg)
+				}
+				return fmt.Errorf("%w, the edit is kept in %s", err, filepath.ToSlash(path))
+			}
+			os.Remove(path)
+
+			if t := ctx.List.Todos[index]; t.Status == StatusDone && changed {
+				if next, err := ctx.List.spawnNext(id, now); err != nil {
+					return err
+				} else if next != nil {
+					fmt.Fprintf(ctx.Stdout, "Next occurrence is task %d, due %s\n", next.ID, formatValue(next.DueAt, humanLayout))
+				}
+			}
+			switch {
+			case doc == nil:
+				fmt.Fprintln(ctx.Stdout, "Edit cancelled, nothing changed")
+			case !changed:
+				fmt.Fprintf(ctx.Stdout, "Task %d unchanged\n", id)
+			default:
+				fmt.Fprintf(ctx.Stdout, "Updated task %d\n", id)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseEdit(t *testing.T) {
+	now := time.Date(2026, 10, 12, 9, 30, 15, 0, time.Local)
+	noted := now.Add(-time.Hour)
+	todo := Todo{ID: 3, Description: "Order parts", Status: StatusTodo, Tags: []string{"shop"},
+		Notes: []Note{{At: noted, Text: "called vendor"}}}
+
+	doc, err := parseEdit(renderEdit(todo), todo, now)
+	if err != nil || doc == nil {
+		t.Fatalf("Expected the rendered document to parse, got %v", err)
+	}
+	if doc.fields["Description"] != "Order parts" || doc.fields["Tags"] != "shop" || doc.fields["Due"] != "" {
+		t.Errorf("Unexpected fields: %v", doc.fields)
+	}
+	if len(doc.notes) != 1 || !doc.notes[0].At.Equal(noted) {
+		t.Errorf("Expected the note with its exact time, got %+v", doc.notes)
+	}
+
+	text := "description: Order more parts\nDetails:\n\nLine one\n\nLine two\n\nNotes:\n2026-10-12 08:30  called vendor\nnew note\n"
+	doc, err = parseEdit(text, todo, now)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if doc.fields["Description"] != "Order more parts" || doc.details != "Line one\n\nLine two" {
+		t.Errorf("Unexpected document: %+v", doc)
+	}
+	if len(doc.notes) != 2 || !doc.notes[0].At.Equal(noted) || doc.notes[1].Text != "new note" || !doc.notes[1].At.Equal(now) {
+		t.Errorf("Expected the old and a new note, got %+v", doc.notes)
+	}
+
+	if _, err := parseEdit("Owner: me\n", todo, now); err == nil {
+		t.Error("Expected error for an unknown field, got nil")
+	}
+	if doc, err := parseEdit("# only comments\n\n", todo, now); err != nil || doc != nil {
+		t.Errorf("Expected an emptied document to cancel, got %+v, %v", doc, err)
+	}
+}
+
+// Helper to replace the editor with one that rewrites the document
+func fakeEditor(t *testing.T, edit func(text string) string) {
+	t.Helper()
+	orig := runEditor
+	t.Cleanup(func() { runEditor = orig })
+	runEditor = func(ctx *Context, path string) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, []byte(edit(string(data))), 0o600)
+	}
+}
+
+func TestAppEdit(t *testing.T) {
+	app := newTestApp(t)
+	runCmd(t, app, "add", "Release")
+	runCmd(t, app, "add", "Order parts", "-tag", "shop")
+	runCmd(t, app, "note", "2", "called vendor")
+
+	fakeEditor(t, func(text string) string {
+		text = strings.Replace(text, "Priority: \n", "Priority: high\n", 1)
+		text = strings.Replace(text, "Tags: shop\n", "Tags: shop, errand\n", 1)
+		text = strings.Replace(text, "Parent: \n", "Parent: 1\n", 1)
+		text = strings.Replace(text, "Details:\n", "Details:\nTwo boxes of screws\n", 1)
+		return text + "parts ship Friday\n"
+	})
+	code, stdout, stderr := runCmd(t, app, "edit", "2")
+	if code != 0 || !strings.Contains(stdout, "Updated task 2") {
+		t.Fatalf("Expected task updated, got %d %q %q", code, stdout, stderr)
+	}
+	todo := loadTodos(t, app)[1]
+	if todo.Priority != PriorityHigh || len(todo.Tags) != 2 || todo.ParentID != 1 || todo.Details != "Two boxes of screws" {
+		t.Errorf("Unexpected task after edit: %+v", todo)
+	}
+	if len(todo.Notes) != 2 || todo.Notes[0].Text != "called vendor" || todo.Notes[1].Text != "parts ship Friday" {
+		t.Errorf("Expected the note kept and one added, got %+v", todo.Notes)
+	}
+
+	// Unchanged and emptied documents change nothing
+	fakeEditor(t, func(text string) string { return text })
+	if _, stdout, _ := runCmd(t, app, "edit", "2"); !strings.Contains(stdout, "Task 2 unchanged") {
+		t.Errorf("Expected task unchanged, got %q", stdout)
+	}
+	fakeEditor(t, func(string) string { return "" })
+	if _, stdout, _ := runCmd(t, app, "edit", "2"); !strings.Contains(stdout, "Edit cancelled") {
+		t.Errorf("Expected edit cancelled, got %q", stdout)
+	}
+
+	// Status changes go through the usual transitions
+	fakeEditor(t, func(text string) string { return strings.Replace(text, "Status: todo", "Status: done", 1) })
+	runCmd(t, app, "edit", "2")
+	if todo := loadTodos(t, app)[1]; todo.Status != StatusDone || todo.CompletedAt == nil {
+		t.Errorf("Expected task done with a completion time, got %+v", todo)
+	}
+}
+
+func TestAppEditInvalid(t *testing.T) {
+	app := newTestApp(t)
+	runCmd(t, app, "add", "Order parts")
+
+	fakeEditor(t, func(text string) string { return strings.Replace(text, "Due: ", "Due: someday soon", 1) })
+	code, _, stderr := runCmd(t, app, "edit", "1")
+	if code != 1 || !strings.Contains(stderr, "the edit is kept in") {
+		t.Fatalf("Expected error keeping the edit, got %d %q", code, stderr)
+	}
+	path := strings.TrimSpace(stderr[strings.Index(stderr, "kept in ")+len("kept in "):])
+	defer os.Remove(path)
+	if data, err := os.ReadFile(path); err != nil || !strings.Contains(string(data), "someday soon") {
+		t.Errorf("Expected the edit in %s, got %q, %v", path, data, err)
+	}
+	if todo := loadTodos(t, app)[0]; todo.DueAt != nil {
+		t.Errorf("Expected task unchanged, got %+v", todo)
+	}
+
+	if code, _, _ := runCmd(t, app, "edit", "9"); code != 1 {
+		t.Errorf("Expected error for a missing task, got %d", code)
+	}
+}
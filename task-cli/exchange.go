@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// importedTask is a task read from another format. Its ID is not set yet;
+// links to other tasks use the keys of the source and are resolved once
+// every imported task has an ID.
+type importedTask struct {
+	Todo
+	where    string
+	key      string
+	parent   string
+	blockers []string
+}
+
+// exchangeReport collects the records an import or export skipped and the
+// details it could not carry over.
+type exchangeReport struct {
+	notes   []string
+	skipped int
+}
+
+func (r *exchangeReport) skip(where, format string, a ...any) {
+	r.notes = append(r.notes, fmt.Sprintf("%s: skipped, %s", where, fmt.Sprintf(format, a...)))
+	r.skipped++
+}
+
+func (r *exchangeReport) lossy(where, format string, a ...any) {
+	r.notes = append(r.notes, fmt.Sprintf("%s: %s", where, fmt.Sprintf(format, a...)))
+}
+
+func (r *exchangeReport) print(w io.Writer) {
+	for _, note := range r.notes {
+		fmt.Fprintln(w, "  "+note)
+	}
+}
+
+// exchangeFormat reads and writes tasks in the format of another tool.
+type exchangeFormat struct {
+	ext   string
+	read  func(r io.Reader, report *exchangeReport) ([]importedTask, error)
+	write func(w io.Writer, todos Todos, report *exchangeReport) error
+}
+
+var exchangeFormats = map[string]exchangeFormat{
+	"todotxt":     {".txt", readTodoTxt, writeTodoTxt},
+	"taskwarrior": {".json", readTaskwarrior, writeTaskwarrior},
+	"csv":         {".csv", readCSV, writeCSV},
+	"ical":        {".ics", readICal, writeICal},
+}
+
+// exchangeFormatFor returns the format called name or, without a name, the
+// one matching the extension of path.
+func exchangeFormatFor(name, path string) (exchangeFormat, error) {
+	if name != "" {
+		f, ok := exchangeFormats[name]
+		if !ok {
+			return f, usageErrorf("unknown format %q (available: %s)", name, strings.Join(sortedKeys(exchangeFormats), ", "))
+		}
+		return f, nil
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, f := range exchangeFormats {
+		if ext != "" && f.ext == ext {
+			return f, nil
+		}
+	}
+	if path == "" || path == "-" {
+		return exchangeFormat{}, usageErrorf("-format is required when reading or writing the terminal")
+	}
+	return exchangeFormat{}, usageErrorf("cannot tell the format of %s, pass -format", path)
+}
+
+// importTasks adds the imported tasks to the list with new IDs, linking
+// them as in the source where the linked task was imported too. It returns
+// the new IDs.
+func (l *TodoList) importTasks(tasks []importedTask, report *exchangeReport, now time.Time) []int {
+	ids := make([]int, len(tasks))
+	keys := map[string]int{}
+	for i, it := range tasks {
+		t := l.add(it.Description)
+		id := t.ID
+		*t = it.Todo
+		t.ID = id
+		t.ParentID, t.BlockedBy = 0, nil
+		if t.CreatedAt.IsZero() {
+			t.CreatedAt = now
+		}
+		if t.Status == "" {
+			t.Status = StatusTodo
+		}
+		if t.Status == StatusDone && t.CompletedAt == nil {
+			t.CompletedAt = &now
+		}
+		ids[i] = id
+		if it.key != "" {
+			keys[it.key] = id
+		}
+	}
+
+	for i, it := range tasks {
+		index, _ := l.IndexOf(ids[i])
+		if it.parent != "" {
+			parent, ok := keys[it.parent]
+			switch err := l.Todos.checkParent(ids[i], parent); {
+			case !ok:
+				report.lossy(it.where, "parent %s was not imported", it.parent)
+			case err != nil:
+				report.lossy(it.where, "parent dropped: %v", err)
+			default:
+				l.Todos[index].ParentID = parent
+			}
+		}
+		for _, key := range it.blockers {
+			blocker, ok := keys[key]
+			switch err := l.Todos.checkBlockers(ids[i], []int{blocker}); {
+			case !ok:
+				report.lossy(it.where, "blocking task %s was not imported", key)
+			case err != nil:
+				report.lossy(it.where, "blocking task dropped: %v", err)
+			default:
+				l.Todos[index].BlockedBy = append(l.Todos[index].BlockedBy, blocker)
+			}
+		}
+	}
+	return ids
+}
+
+// importLabels sets the project and tags of an imported task from names in
+// the source, reporting the ones that are no valid labels.
+func (it *importedTask) importLabels(report *exchangeReport, project string, tags ...string) {
+	if project != "" {
+		if name, err := normalizeLabel(project, '+'); err == nil {
+			it.Project = name
+		} else {
+			report.lossy(it.where, "invalid project %q dropped", project)
+		}
+	}
+	for _, tag := range tags {
+		if name, err := normalizeLabel(tag, '#'); err == nil {
+			it.Tags = addTags(it.Tags, name)
+		} else if tag != "" {
+			report.lossy(it.where, "invalid tag %q dropped", tag)
+		}
+	}
+}
+
+// exportLosses reports what a format drops from t of its subtasks,
+// dependencies, recurrence, time tracking, details and notes. The kept
+// parameter names those the format does carry.
+func exportLosses(report *exchangeReport, t Todo, kept ...string) {
+	where := fmt.Sprintf("task %d", t.ID)
+	if t.ParentID != 0 && !slices.Contains(kept, "parent") {
+		report.lossy(where, "parent %d dropped", t.ParentID)
+	}
+	if len(t.BlockedBy) > 0 && !slices.Contains(kept, "blocked-by") {
+		report.lossy(where, "blocked by %s dropped", formatValue(t.BlockedBy, ""))
+	}
+	if t.Recur != nil && !slices.Contains(kept, "recur") {
+		report.lossy(where, "recurrence %s dropped", t.Recur)
+	}
+	if len(t.Intervals) > 0 && !slices.Contains(kept, "intervals") {
+		report.lossy(where, "%d tracked %s dropped", len(t.Intervals), plural(len(t.Intervals), "interval"))
+	}
+	if t.Details != "" && !slices.Contains(kept, "details") {
+		report.lossy(where, "details dropped")
+	}
+	if len(t.Notes) > 0 && !slices.Contains(kept, "notes") {
+		report.lossy(where, "%d %s dropped", len(t.Notes), plural(len(t.Notes), "note"))
+	}
+}
+
+func newImportCmd() *Command {
+	flags := newFlagSet("import")
+	format := flags.String("format", "", "input format: "+strings.Join(sortedKeys(exchangeFormats), ", ")+" (default: from the file extension)")
+	dryRun := flags.Bool("dry-run", false, "show what would be imported without changing anything")
+
+	return &Command{
+		Name:    "import",
+		Args:    "[file]",
+		Summary: "Import tasks from todo.txt, Taskwarrior JSON, CSV or iCalendar.",
+		Flags:   flags,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) > 1 {
+				return usageErrorf("expected at most one file")
+			}
+			path := "-"
+			if len(args) == 1 {
+				path = args[0]
+			}
+			f, err := exchangeFormatFor(*format, path)
+			if err != nil {
+				return err
+			}
+
+			in := ctx.App.Stdin
+			if path != "-" {
+				file, err := os.Open(path)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				in = file
+			}
+			report := &exchangeReport{}
+			tasks, err := f.read(in, report)
+			if err != nil {
+				return err
+			}
+
+			if *dryRun {
+				preview := TodoList{NextID: ctx.List.NextID, Todos: slices.Clone(ctx.List.Todos)}
+				ids := preview.importTasks(tasks, report, time.Now())
+				fmt.Fprintf(ctx.Stdout, "Would import %d %s:\n", len(ids), plural(len(ids), "task"))
+				fields, _ := lookupFields("id,description,status,priority,project,tags,due")
+				if err := renderTable(ctx.Stdout, preview.Todos[len(ctx.List.Todos):], fields); err != nil {
+					return err
+				}
+			} else {
+				ids := ctx.List.importTasks(tasks, report, time.Now())
+				fmt.Fprintf(ctx.Stdout, "Imported %d %s", len(ids), plural(len(ids), "task"))
+				if len(ids) > 0 {
+					fmt.Fprintf(ctx.Stdout, " as %s", formatValue(ids, ""))
+				}
+				fmt.Fprintln(ctx.Stdout)
+			}
+			if len(report.notes) > 0 {
+				fmt.Fprintf(ctx.Stdout, "%d skipped, %d %s:\n", report.skipped, len(report.notes), plural(len(report.notes), "note"))
+				report.print(ctx.Stdout)
+			}
+			return nil
+		},
+	}
+}
+
+func newExportCmd() *Command {
+	flags := newFlagSet("export")
+	format := flags.String("format", "", "output format: "+strings.Join(sortedKeys(exchangeFormats), ", ")+" (default: from the file extension)")
+
+	return &Command{
+		Name:     "export",
+		Args:     "[file]",
+		Summary:  "Export tasks to todo.txt, Taskwarrior JSON, CSV or iCalendar.",
+		Flags:    flags,
+		ReadOnly: true,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) > 1 {
+				return usageErrorf("expected at most one file")
+			}
+			path := "-"
+			if len(args) == 1 {
+				path = args[0]
+			}
+			f, err := exchangeFormatFor(*format, path)
+			if err != nil {
+				return err
+			}
+
+			report := &exchangeReport{}
+			if path == "-" {
+				if err := f.write(ctx.Stdout, ctx.List.Todos, report); err != nil {
+					return err
+				}
+				// Standard output carries the tasks, so the report goes elsewhere
+				report.print(ctx.Stderr)
+				return nil
+			}
+
+			var b strings.Builder
+			if err := f.write(&b, ctx.List.Todos, report); err != nil {
+				return err
+			}
+			if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+				return err
+			}
+			fmt.Fprintf(ctx.Stdout, "Exported %d %s to %s\n", len(ctx.List.Todos), plural(len(ctx.List.Todos), "task"), path)
+			report.print(ctx.Stdout)
+			return nil
+		},
+	}
+}
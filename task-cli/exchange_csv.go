@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// csvAliases maps column names spreadsheets commonly use to field names.
+var csvAliases = map[string]string{
+	"title": "description",
+	"task":  "description",
+	"name":  "description",
+	"tag":   "tags",
+}
+
+// readCSV reads tasks from CSV with a header row. Columns are matched by
+// field name or heading, as written by "list -format csv"; unknown columns
+// are reported and ignored, computed ones such as progress silently.
+func readCSV(r io.Reader, report *exchangeReport) ([]importedTask, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+
+	columns := make([]string, len(header))
+	var unknown []string
+	for i, name := range header {
+		columns[i] = csvColumn(name)
+		if columns[i] == "" && strings.TrimSpace(name) != "" {
+			unknown = append(unknown, fmt.Sprintf("%q", name))
+		}
+	}
+	if len(unknown) > 0 {
+		report.lossy("header", "%s %s ignored", plural(len(unknown), "column"), strings.Join(unknown, ", "))
+	}
+
+	var tasks []importedTask
+	for n := 2; ; n++ {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		it := importedTask{where: fmt.Sprintf("row %d", n)}
+		if err != nil {
+			report.skip(it.where, "%v", err)
+			continue
+		}
+
+		var project string
+		var tags []string
+		for i, value := range row {
+			value = strings.TrimSpace(value)
+			if i >= len(columns) || value == "" {
+				continue
+			}
+			switch columns[i] {
+			case "id":
+				it.key = value
+			case "description":
+				it.Description = value
+			case "status":
+				status, err := ParseStatus(value)
+				if err != nil {
+					report.lossy(it.where, "unknown status %q read as todo", value)
+				}
+				it.Status = status
+			case "priority":
+				p, err := ParsePriority(value)
+				if err != nil {
+					report.lossy(it.where, "unknown priority %q dropped", value)
+				}
+				it.Priority = p
+			case "project":
+				project = value
+			case "tags":
+				tags = strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' })
+			case "parent":
+				it.parent = value
+			case "blocked-by":
+				for _, key := range strings.Split(value, ",") {
+					it.blockers = append(it.blockers, strings.TrimSpace(key))
+				}
+			case "recur":
+				recur, err := ParseRecurrence(value)
+				if err != nil {
+					report.lossy(it.where, "invalid recurrence %q dropped", value)
+				}
+				it.Recur = recur
+			case "created", "updated", "started", "completed", "due":
+				at, err := ParseDate(value, time.Now())
+				if err != nil {
+					report.lossy(it.where, "invalid %s date %q dropped", columns[i], value)
+					continue
+				}
+				switch columns[i] {
+				case "created":
+					it.CreatedAt = at
+				case "updated":
+					it.UpdatedAt = &at
+				case "started":
+					it.StartedAt = &at
+				case "completed":
+					it.CompletedAt = &at
+				case "due":
+					it.DueAt = &at
+				}
+			}
+		}
+		it.importLabels(report, project, tags...)
+
+		if it.Description == "" {
+			report.skip(it.where, "no description")
+			continue
+		}
+		if it.Status == "" && it.CompletedAt != nil {
+			it.Status = StatusDone
+		}
+		tasks = append(tasks, it)
+	}
+	return tasks, nil
+}
+
+// csvColumn returns the field a CSV heading stands for, or "" if none.
+func csvColumn(heading string) string {
+	name := strings.ToLower(strings.TrimSpace(heading))
+	if alias, ok := csvAliases[name]; ok {
+		return alias
+	}
+	for _, f := range Fields {
+		if f.Name == name || strings.ToLower(f.Header) == name {
+			return f.Name
+		}
+	}
+	return ""
+}
+
+// writeCSV writes every field of the tasks, as "list -format csv -fields"
+// with all fields would.
+func writeCSV(w io.Writer, todos Todos, report *exchangeReport) error {
+	for _, t := range todos {
+		exportLosses(report, t, "parent", "blocked-by", "recur")
+	}
+	return renderCSV(w, todos, Fields)
+}
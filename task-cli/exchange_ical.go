@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	icalDateTime = "20060102T150405Z"
+	icalDate     = "20060102"
+	icalFloating = "20060102T150405"
+)
+
+var icalStatuses = map[Status]string{
+	StatusTodo:       "NEEDS-ACTION",
+	StatusInProgress: "IN-PROCESS",
+	StatusBlocked:    "NEEDS-ACTION",
+	StatusDone:       "COMPLETED",
+	StatusCancelled:  "CANCELLED",
+}
+
+// iCalendar priorities run from 1 (highest) to 9 (lowest), 0 meaning none.
+var icalPriorities = map[Priority]int{
+	PriorityUrgent: 1,
+	PriorityHigh:   3,
+	PriorityMedium: 5,
+	PriorityLow:    9,
+}
+
+// icalIgnored are properties that carry nothing a task keeps.
+var icalIgnored = map[string]bool{"DTSTAMP": true, "SEQUENCE": true, "CLASS": true}
+
+var (
+	icalEscaper   = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`, "\r", "")
+	icalUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+)
+
+// icalProperty is one unfolded content line, e.g.
+// DUE;VALUE=DATE:20261101.
+type icalProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+func parseICalLine(line string) (icalProperty, bool) {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return icalProperty{}, false
+	}
+	parts := strings.Split(head, ";")
+	p := icalProperty{name: strings.ToUpper(parts[0]), params: map[string]string{}, value: value}
+	for _, param := range parts[1:] {
+		k, v, _ := strings.Cut(param, "=")
+		p.params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return p, true
+}
+
+// time parses a DATE or DATE-TIME value. Times without a zone are local.
+func (p icalProperty) time() (time.Time, error) {
+	loc := time.Local
+	if tzid := p.params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	switch {
+	case p.params["VALUE"] == "DATE" || len(p.value) == len(icalDate):
+		return time.ParseInLocation(icalDate, p.value, time.Local)
+	case strings.HasSuffix(p.value, "Z"):
+		t, err := time.Parse(icalDateTime, p.value)
+		return t.Local(), err
+	default:
+		return time.ParseInLocation(icalFloating, p.value, loc)
+	}
+}
+
+// readICal reads the VTODO components of an iCalendar file. Other
+// components such as events are skipped.
+func readICal(r io.Reader, report *exchangeReport) ([]importedTask, error) {
+	lines, err := unfoldICal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []importedTask
+	var it *importedTask
+	var dropped, stack []string
+	for _, line := range lines {
+		p, ok := parseICalLine(line.text)
+		if !ok {
+			continue
+		}
+		switch p.name {
+		case "BEGIN":
+			component := strings.ToUpper(p.value)
+			parent := ""
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+			stack = append(stack, component)
+			switch {
+			case component == "VTODO":
+				it = &importedTask{where: fmt.Sprintf("line %d", line.n)}
+				it.Status = StatusTodo
+				dropped = nil
+			case parent == "VTODO":
+				dropped = append(dropped, component)
+			case parent == "VCALENDAR" && component != "VTIMEZONE":
+				report.skip(fmt.Sprintf("line %d", line.n), "%s is not a task", component)
+			}
+		case "END":
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			if strings.EqualFold(p.value, "VTODO") && it != nil {
+				if len(dropped) > 0 {
+					report.lossy(it.where, "%s dropped", strings.Join(dropped, ", "))
+				}
+				if it.Description == "" {
+					report.skip(it.where, "no summary")
+				} else {
+					tasks = append(tasks, *it)
+				}
+				it = nil
+			}
+		default:
+			if it != nil && stack[len(stack)-1] == "VTODO" {
+				it.icalProperty(p, report, &dropped)
+			}
+		}
+	}
+	return tasks, nil
+}
+
+func (it *importedTask) icalProperty(p icalProperty, report *exchangeReport, dropped *[]string) {
+	value := icalUnescaper.Replace(p.value)
+	switch p.name {
+	case "UID":
+		it.key = value
+	case "SUMMARY":
+		it.Description = strings.Join(strings.Fields(value), " ")
+	case "DESCRIPTION":
+		it.Details = strings.TrimSpace(value)
+	case "STATUS":
+		switch strings.ToUpper(value) {
+		case "NEEDS-ACTION":
+			it.Status = StatusTodo
+		case "IN-PROCESS":
+			it.Status = StatusInProgress
+		case "COMPLETED":
+			it.Status = StatusDone
+		case "CANCELLED":
+			it.Status = StatusCancelled
+		default:
+			report.lossy(it.where, "unknown status %q read as todo", value)
+		}
+	case "PRIORITY":
+		n, err := strconv.Atoi(value)
+		switch {
+		case err != nil || n < 0 || n > 9:
+			report.lossy(it.where, "invalid priority %q dropped", value)
+		case n == 0:
+		case n <= 2:
+			it.Priority = PriorityUrgent
+		case n <= 4:
+			it.Priority = PriorityHigh
+		case n == 5:
+			it.Priority = PriorityMedium
+		default:
+			it.Priority = PriorityLow
+		}
+	case "CATEGORIES":
+		for _, tag := range strings.Split(p.value, ",") {
+			it.importLabels(report, "", icalUnescaper.Replace(tag))
+		}
+	case "X-TASK-PROJECT":
+		it.importLabels(report, value)
+	case "RELATED-TO":
+		switch strings.ToUpper(p.params["RELTYPE"]) {
+		case "", "PARENT":
+			it.parent = value
+		case "DEPENDS-ON":
+			it.blockers = append(it.blockers, value)
+		default:
+			report.lossy(it.where, "relation %s to %s dropped", p.params["RELTYPE"], value)
+		}
+	case "DUE", "CREATED", "LAST-MODIFIED", "COMPLETED", "DTSTART":
+		at, err := p.time()
+		if err != nil {
+			report.lossy(it.where, "invalid %s %q dropped", p.name, p.value)
+			return
+		}
+		switch p.name {
+		case "DUE":
+			it.DueAt = &at
+		case "CREATED":
+			it.CreatedAt = at
+		case "LAST-MODIFIED":
+			it.UpdatedAt = &at
+		case "COMPLETED":
+			it.CompletedAt = &at
+		case "DTSTART":
+			it.StartedAt = &at
+		}
+	default:
+		if !icalIgnored[p.name] {
+			*dropped = append(*dropped, p.name)
+		}
+	}
+}
+
+type icalLine struct {
+	n    int
+	text string
+}
+
+// unfoldICal joins continuation lines, which start with a space or tab,
+// to the line before.
+func unfoldICal(r io.Reader) ([]icalLine, error) {
+	var lines []icalLine
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t")) {
+			lines[len(lines)-1].text += text[1:]
+			continue
+		}
+		if text != "" {
+			lines = append(lines, icalLine{n, text})
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// icalWriter writes content lines with CRLF endings, folded at 75 bytes.
+type icalWriter struct {
+	w *bufio.Writer
+}
+
+func (iw icalWriter) line(name, value string) {
+	line := name + ":" + value
+	// Continuation lines start with a space, which counts towards the 75
+	for limit := 75; len(line) > limit; limit = 74 {
+		cut := limit
+		for cut > 1 && line[cut]&0xC0 == 0x80 {
+			// Do not split a UTF-8 sequence
+			cut--
+		}
+		iw.w.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+	}
+	iw.w.WriteString(line + "\r\n")
+}
+
+func (iw icalWriter) time(name string, t *time.Time) {
+	if t == nil {
+		return
+	}
+	if name == "DUE" && isAllDay(*t) {
+		iw.line(name+";VALUE=DATE", t.Format(icalDate))
+		return
+	}
+	iw.line(name, t.UTC().Format(icalDateTime))
+}
+
+func icalUID(id int) string {
+	return fmt.Sprintf("task-%d@task-cli", id)
+}
+
+// writeICal writes the tasks as VTODO components. The project goes into
+// X-TASK-PROJECT, subtasks and blockers into RELATED-TO.
+func writeICal(w io.Writer, todos Todos, report *exchangeReport) error {
+	iw := icalWriter{bufio.NewWriter(w)}
+	now := time.Now()
+	iw.line("BEGIN", "VCALENDAR")
+	iw.line("VERSION", "2.0")
+	iw.line("PRODID", "-//task-cli//task//EN")
+	for _, t := range todos {
+		iw.line("BEGIN", "VTODO")
+		iw.line("UID", icalUID(t.ID))
+		iw.time("DTSTAMP", &now)
+		iw.line("SUMMARY", icalEscaper.Replace(t.Description))
+		if t.Details != "" {
+			iw.line("DESCRIPTION", icalEscaper.Replace(t.Details))
+		}
+		iw.line("STATUS", icalStatuses[t.Status])
+		if t.Status == StatusBlocked && len(t.BlockedBy) == 0 {
+			report.lossy(fmt.Sprintf("task %d", t.ID), "status blocked without blocking tasks written as NEEDS-ACTION")
+		}
+		if p, ok := icalPriorities[t.Priority]; ok {
+			iw.line("PRIORITY", strconv.Itoa(p))
+		}
+		iw.time("CREATED", &t.CreatedAt)
+		iw.time("LAST-MODIFIED", t.UpdatedAt)
+		iw.time("DTSTART", t.StartedAt)
+		iw.time("DUE", t.DueAt)
+		iw.time("COMPLETED", t.CompletedAt)
+		if t.Project != "" {
+			iw.line("X-TASK-PROJECT", t.Project)
+		}
+		if len(t.Tags) > 0 {
+			iw.line("CATEGORIES", strings.Join(t.Tags, ","))
+		}
+		if t.ParentID != 0 {
+			iw.line("RELATED-TO", icalUID(t.ParentID))
+		}
+		for _, id := range t.BlockedBy {
+			iw.line("RELATED-TO;RELTYPE=DEPENDS-ON", icalUID(id))
+		}
+		exportLosses(report, t, "parent", "blocked-by", "details")
+		iw.line("END", "VTODO")
+	}
+	iw.line("END", "VCALENDAR")
+	return iw.w.Flush()
+}
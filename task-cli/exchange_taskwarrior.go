@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// twLayout is the UTC timestamp format of Taskwarrior exports.
+const twLayout = "20060102T150405Z"
+
+// twTime is a Taskwarrior timestamp.
+type twTime time.Time
+
+func (t twTime) MarshalText() ([]byte, error) {
+	return []byte(time.Time(t).UTC().Format(twLayout)), nil
+}
+
+func (t *twTime) UnmarshalText(text []byte) error {
+	parsed, err := time.Parse(twLayout, string(text))
+	if err != nil {
+		// Some tools write RFC 3339 instead
+		if parsed, err = time.Parse(time.RFC3339, string(text)); err != nil {
+			return fmt.Errorf("invalid timestamp %q", text)
+		}
+	}
+	*t = twTime(parsed.Local())
+	return nil
+}
+
+func (t *twTime) time() *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := time.Time(*t)
+	return &v
+}
+
+func newTWTime(t *time.Time) *twTime {
+	if t == nil {
+		return nil
+	}
+	v := twTime(*t)
+	return &v
+}
+
+// twDepends is a list of task UUIDs. Taskwarrior before 2.6 wrote it as a
+// comma separated string.
+type twDepends []string
+
+func (d *twDepends) UnmarshalJSON(data []byte) error {
+	var list string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*d = strings.Split(list, ",")
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(d))
+}
+
+// twAnnotation is a Taskwarrior annotation, read as a note.
+type twAnnotation struct {
+	Entry       *twTime `json:"entry,omitempty"`
+	Description string  `json:"description"`
+}
+
+// twTask is a task in the JSON that "task export" writes and "task import"
+// reads.
+type twTask struct {
+	UUID        string         `json:"uuid"`
+	Description string         `json:"description"`
+	Status      string         `json:"status"`
+	Entry       *twTime        `json:"entry,omitempty"`
+	Modified    *twTime        `json:"modified,omitempty"`
+	Start       *twTime        `json:"start,omitempty"`
+	End         *twTime        `json:"end,omitempty"`
+	Due         *twTime        `json:"due,omitempty"`
+	Priority    string         `json:"priority,omitempty"`
+	Project     string         `json:"project,omitempty"`
+	Tags        []string       `json:"tags,omitempty"`
+	Depends     twDepends      `json:"depends,omitempty"`
+	Annotations []twAnnotation `json:"annotations,omitempty"`
+}
+
+// twKnown are the attributes readTaskwarrior maps or can safely ignore.
+var twKnown = map[string]bool{
+	"id": true, "uuid": true, "description": true, "status": true, "entry": true, "modified": true,
+	"start": true, "end": true, "due": true, "priority": true, "project": true, "tags": true,
+	"depends": true, "annotations": true, "urgency": true, "mask": true, "imask": true,
+}
+
+var twPriorities = map[string]Priority{"H": PriorityHigh, "M": PriorityMedium, "L": PriorityLow}
+
+// readTaskwarrior reads a Taskwarrior export, a JSON array of tasks or one
+// task per line. Pending tasks with a start time are in progress, deleted
+// ones cancelled; recurring templates are skipped since their instances
+// are exported as tasks of their own.
+func readTaskwarrior(r io.Reader, report *exchangeReport) ([]importedTask, error) {
+	br := bufio.NewReader(r)
+	var raw []json.RawMessage
+	if first, err := peekNonSpace(br); err == nil && first == '[' {
+		if err := json.NewDecoder(br).Decode(&raw); err != nil {
+			return nil, fmt.Errorf("reading Taskwarrior export: %w", err)
+		}
+	} else {
+		dec := json.NewDecoder(br)
+		for dec.More() {
+			var msg json.RawMessage
+			if err := dec.Decode(&msg); err != nil {
+				return nil, fmt.Errorf("reading Taskwarrior export: %w", err)
+			}
+			raw = append(raw, msg)
+		}
+	}
+
+	var tasks []importedTask
+	for n, msg := range raw {
+		it := importedTask{where: fmt.Sprintf("record %d", n+1)}
+		var tw twTask
+		if err := json.Unmarshal(msg, &tw); err != nil {
+			report.skip(it.where, "%v", err)
+			continue
+		}
+		if tw.Description == "" {
+			report.skip(it.where, "no description")
+			continue
+		}
+		it.where = fmt.Sprintf("record %d (%s)", n+1, tw.UUID)
+
+		switch tw.Status {
+		case "pending", "waiting", "":
+			it.Status = StatusTodo
+			if tw.Start != nil {
+				it.Status = StatusInProgress
+			}
+		case "completed":
+			it.Status = StatusDone
+			it.CompletedAt = tw.End.time()
+		case "deleted":
+			it.Status = StatusCancelled
+		case "recurring":
+			report.skip(it.where, "recurring template")
+			continue
+		default:
+			report.lossy(it.where, "unknown status %q read as todo", tw.Status)
+			it.Status = StatusTodo
+		}
+		if tw.Status == "waiting" {
+			report.lossy(it.where, "waiting task read as todo")
+		}
+
+		it.key = tw.UUID
+		it.Description = tw.Description
+		if tw.Entry != nil {
+			it.CreatedAt = time.Time(*tw.Entry)
+		}
+		it.UpdatedAt = tw.Modified.time()
+		it.StartedAt = tw.Start.time()
+		it.DueAt = tw.Due.time()
+		if tw.Priority != "" {
+			p, ok := twPriorities[tw.Priority]
+			if !ok {
+				report.lossy(it.where, "unknown priority %q dropped", tw.Priority)
+			}
+			it.Priority = p
+		}
+		it.importLabels(report, tw.Project, tw.Tags...)
+		for _, dep := range tw.Depends {
+			if dep = strings.TrimSpace(dep); dep != "" {
+				it.blockers = append(it.blockers, dep)
+			}
+		}
+		for _, a := range tw.Annotations {
+			if a.Description == "" {
+				continue
+			}
+			note := Note{At: it.CreatedAt, Text: a.Description}
+			if a.Entry != nil {
+				note.At = time.Time(*a.Entry)
+			}
+			it.Notes = append(it.Notes, note)
+		}
+
+		var attrs map[string]json.RawMessage
+		json.Unmarshal(msg, &attrs)
+		var dropped []string
+		for _, name := range sortedKeys(attrs) {
+			if !twKnown[name] {
+				dropped = append(dropped, name)
+			}
+		}
+		if len(dropped) > 0 {
+			report.lossy(it.where, "%s %s dropped", plural(len(dropped), "attribute"), strings.Join(dropped, ", "))
+		}
+		tasks = append(tasks, it)
+	}
+	return tasks, nil
+}
+
+// peekNonSpace returns the first byte after leading white space without
+// consuming it.
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		if !bytes.ContainsAny(b, " \t\r\n") {
+			return b[0], nil
+		}
+		br.ReadByte()
+	}
+}
+
+// twUUID derives a stable UUID for the task id, so exporting the same list
+// again updates the tasks in Taskwarrior instead of duplicating them.
+func twUUID(id int) string {
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", id)
+}
+
+// writeTaskwarrior writes the tasks as a JSON array "task import" accepts.
+func writeTaskwarrior(w io.Writer, todos Todos, report *exchangeReport) error {
+	tasks := make([]twTask, 0, len(todos))
+	for _, t := range todos {
+		where := fmt.Sprintf("task %d", t.ID)
+		tw := twTask{
+			UUID:        twUUID(t.ID),
+			Description: t.Description,
+			Status:      "pending",
+			Entry:       newTWTime(&t.CreatedAt),
+			Modified:    newTWTime(t.UpdatedAt),
+			Start:       newTWTime(t.StartedAt),
+			Due:         newTWTime(t.DueAt),
+			Project:     t.Project,
+			Tags:        t.Tags,
+		}
+		switch t.Status {
+		case StatusDone:
+			tw.Status = "completed"
+			tw.End = newTWTime(t.CompletedAt)
+		case StatusCancelled:
+			tw.Status = "deleted"
+			end := lastChange(t)
+			tw.End = newTWTime(&end)
+		case StatusTodo:
+			tw.Start = nil
+		case StatusBlocked:
+			if len(t.BlockedBy) == 0 {
+				report.lossy(where, "status blocked without blocking tasks written as pending")
+			}
+		}
+
+		switch t.Priority {
+		case PriorityUrgent:
+			tw.Priority = "H"
+			report.lossy(where, "priority urgent written as H")
+		case PriorityHigh, PriorityMedium, PriorityLow:
+			tw.Priority = strings.ToUpper(string(t.Priority[:1]))
+		}
+		for _, id := range t.BlockedBy {
+			tw.Depends = append(tw.Depends, twUUID(id))
+		}
+		for _, n := range t.Notes {
+			tw.Annotations = append(tw.Annotations, twAnnotation{Entry: newTWTime(&n.At), Description: n.Text})
+		}
+		if t.Details != "" {
+			// Taskwarrior has no long description, annotations come closest
+			tw.Annotations = append(tw.Annotations, twAnnotation{Entry: newTWTime(&t.CreatedAt), Description: t.Details})
+			report.lossy(where, "details written as an annotation")
+		}
+		exportLosses(report, t, "blocked-by", "notes", "details")
+		tasks = append(tasks, tw)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(tasks)
+}
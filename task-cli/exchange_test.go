@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadTodoTxt(t *testing.T) {
+	input := "(A) 2026-10-01 Call mom +family @phone due:2026-10-20\n" +
+		"x 2026-10-05 2026-10-01 Pay rent +home +money\n" +
+		"\n" +
+		"(F) +only @labels\n"
+	report := &exchangeReport{}
+	tasks, err := readTodoTxt(strings.NewReader(input), report)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("Expected 2 tasks, got %d", len(tasks))
+	}
+
+	call := tasks[0]
+	if call.Description != "Call mom" || call.Priority != PriorityUrgent || call.Project != "family" ||
+		call.Tags[0] != "phone" || call.DueAt == nil || call.DueAt.Day() != 20 {
+		t.Errorf("Unexpected first task: %+v", call.Todo)
+	}
+	rent := tasks[1]
+	if rent.Status != StatusDone || rent.CompletedAt == nil || rent.CompletedAt.Day() != 5 || rent.CreatedAt.Day() != 1 {
+		t.Errorf("Expected a done task with its dates, got %+v", rent.Todo)
+	}
+	if rent.Project != "home" || len(rent.Tags) != 1 || rent.Tags[0] != "money" {
+		t.Errorf("Expected the second project as a tag, got %q %v", rent.Project, rent.Tags)
+	}
+
+	if report.skipped != 1 || len(report.notes) != 3 || !strings.HasPrefix(report.notes[2], "line 4: skipped") {
+		t.Errorf("Unexpected report: %q", report.notes)
+	}
+}
+
+func TestReadTodoTxtInvalidDates(t *testing.T) {
+	input := "2024-13-45 Buy milk\n" +
+		"x 2024-02-30 Pay rent due:2024-00-10\n"
+	report := &exchangeReport{}
+	tasks, err := readTodoTxt(strings.NewReader(input), report)
+	if err != nil || len(tasks) != 2 {
+		t.Fatalf("Expected 2 tasks, got %d %v", len(tasks), err)
+	}
+	if tasks[0].Description != "Buy milk" || !tasks[0].CreatedAt.IsZero() {
+		t.Errorf("Expected the creation date dropped, got %+v", tasks[0].Todo)
+	}
+	if tasks[1].Description != "Pay rent" || tasks[1].CompletedAt != nil || tasks[1].DueAt != nil {
+		t.Errorf("Expected the completion and due dates dropped, got %+v", tasks[1].Todo)
+	}
+
+	want := []string{
+		`line 1: invalid creation date "2024-13-45" dropped`,
+		`line 2: invalid completion date "2024-02-30" dropped`,
+		`line 2: invalid due date "2024-00-10" dropped`,
+	}
+	if strings.Join(report.notes, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected notes %q, got %q", want, report.notes)
+	}
+}
+
+func TestWriteTodoTxt(t *testing.T) {
+	created := time.Date(2026, 10, 1, 9, 0, 0, 0, time.Local)
+	done := time.Date(2026, 10, 5, 9, 0, 0, 0, time.Local)
+	todos := Todos{
+		{ID: 1, Description: "Call mom", Status: StatusTodo, Priority: PriorityUrgent, CreatedAt: created, Project: "family", Tags: []string{"phone"}},
+		{ID: 2, Description: "Pay rent", Status: StatusDone, Priority: PriorityHigh, CreatedAt: created, CompletedAt: &done, Tags: []string{"money"}},
+	}
+	var buf bytes.Buffer
+	if err := writeTodoTxt(&buf, todos, &exchangeReport{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The creation date follows the completion date, the priority moves to pri:
+	want := "(A) 2026-10-01 Call mom +family @phone\n" +
+		"x 2026-10-05 2026-10-01 Pay rent @money pri:B\n"
+	if buf.String() != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, buf.String())
+	}
+	tasks, _ := readTodoTxt(&buf, &exchangeReport{})
+	if len(tasks) != 2 || tasks[1].Priority != PriorityHigh || tasks[1].CreatedAt.Day() != 1 || tasks[1].Description != "Pay rent" {
+		t.Errorf("Expected the done task read back, got %+v", tasks)
+	}
+}
+
+func TestTaskwarriorRoundTrip(t *testing.T) {
+	due := time.Date(2026, 11, 1, 9, 30, 0, 0, time.UTC)
+	todos := Todos{
+		{ID: 1, Description: "Write spec", Status: StatusDone, CreatedAt: due.AddDate(0, 0, -7), CompletedAt: &due, Priority: PriorityUrgent},
+		{ID: 2, Description: "Implement", Status: StatusInProgress, CreatedAt: due, StartedAt: &due, DueAt: &due,
+			Project: "work", Tags: []string{"go"}, BlockedBy: []int{1}, ParentID: 1},
+	}
+	var buf bytes.Buffer
+	report := &exchangeReport{}
+	if err := writeTaskwarrior(&buf, todos, report); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(report.notes) != 2 {
+		t.Errorf("Expected the urgent priority and the parent reported, got %q", report.notes)
+	}
+
+	tasks, err := readTaskwarrior(&buf, &exchangeReport{})
+	if err != nil || len(tasks) != 2 {
+		t.Fatalf("Expected 2 tasks back, got %d, %v", len(tasks), err)
+	}
+	if tasks[0].Status != StatusDone || !tasks[0].CompletedAt.Equal(due) || tasks[0].Priority != PriorityHigh {
+		t.Errorf("Unexpected first task: %+v", tasks[0].Todo)
+	}
+	if tasks[1].Status != StatusInProgress || !tasks[1].DueAt.Equal(due) || tasks[1].Project != "work" ||
+		len(tasks[1].blockers) != 1 || tasks[1].blockers[0] != tasks[0].key {
+		t.Errorf("Unexpected second task: %+v", tasks[1])
+	}
+}
+
+func TestReadTaskwarriorLines(t *testing.T) {
+	input := `{"uuid":"a","description":"Recurring","status":"recurring","recur":"weekly"}
+{"uuid":"b","description":"Wait","status":"waiting","depends":"c,d","annotations":[{"entry":"20261001T120000Z","description":"called vendor"}]}
+{"uuid":"c","description":"Gone","status":"deleted","priority":"X"}
+`
+	report := &exchangeReport{}
+	tasks, err := readTaskwarrior(strings.NewReader(input), report)
+	if err != nil || len(tasks) != 2 {
+		t.Fatalf("Expected 2 tasks, got %d, %v", len(tasks), err)
+	}
+	if len(tasks[0].blockers) != 2 || tasks[1].Status != StatusCancelled {
+		t.Errorf("Unexpected tasks: %+v", tasks)
+	}
+	if len(tasks[0].Notes) != 1 || tasks[0].Notes[0].Text != "called vendor" || tasks[0].Notes[0].At.UTC().Hour() != 12 {
+		t.Errorf("Expected the annotation read as a note, got %+v", tasks[0].Notes)
+	}
+	if report.skipped != 1 || len(report.notes) != 3 {
+		t.Errorf("Expected the template skipped and three notes, got %q", report.notes)
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	input := "Title,Status,Due,Tags,Owner\n" +
+		"Buy milk,done,2026-10-01,\"home, errand\",me\n" +
+		",todo,,,\n" +
+		"Fix bug,wip,tomorrow,,you\n"
+	report := &exchangeReport{}
+	tasks, err := readCSV(strings.NewReader(input), report)
+	if err != nil || len(tasks) != 2 {
+		t.Fatalf("Expected 2 tasks, got %d, %v", len(tasks), err)
+	}
+	if tasks[0].Description != "Buy milk" || tasks[0].Status != StatusDone || len(tasks[0].Tags) != 2 {
+		t.Errorf("Unexpected first task: %+v", tasks[0].Todo)
+	}
+	if tasks[1].Status != "" || tasks[1].DueAt == nil {
+		t.Errorf("Expected an unknown status left to the default, got %+v", tasks[1].Todo)
+	}
+	want := []string{`header: column "Owner" ignored`, "row 3: skipped, no description", `row 4: unknown status "wip" read as todo`}
+	if strings.Join(report.notes, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected notes %q, got %q", want, report.notes)
+	}
+}
+
+func TestICalRoundTrip(t *testing.T) {
+	due := time.Date(2026, 11, 1, 0, 0, 0, 0, time.Local)
+	long := strings.Repeat("Ünïcode, text; ", 8)
+	todos := Todos{
+		{ID: 1, Description: long, Status: StatusTodo, CreatedAt: due, DueAt: &due, Priority: PriorityMedium, Tags: []string{"a", "b"}},
+		{ID: 2, Description: "Child", Status: StatusCancelled, CreatedAt: due, ParentID: 1, Details: "Two lines;\nwith, commas"},
+	}
+	var buf bytes.Buffer
+	if err := writeICal(&buf, todos, &exchangeReport{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("Expected lines folded at 75 bytes, got %d: %q", len(line), line)
+		}
+	}
+
+	input := strings.Replace(buf.String(), "END:VCALENDAR", "BEGIN:VEVENT\r\nSUMMARY:Meeting\r\nEND:VEVENT\r\nEND:VCALENDAR", 1)
+	report := &exchangeReport{}
+	tasks, err := readICal(strings.NewReader(input), report)
+	if err != nil || len(tasks) != 2 {
+		t.Fatalf("Expected 2 tasks back, got %d, %v", len(tasks), err)
+	}
+	if tasks[0].Description != strings.TrimSpace(long) || !tasks[0].DueAt.Equal(due) || tasks[0].Priority != PriorityMedium ||
+		len(tasks[0].Tags) != 2 {
+		t.Errorf("Unexpected first task: %+v", tasks[0].Todo)
+	}
+	if tasks[1].Status != StatusCancelled || tasks[1].parent != tasks[0].key || tasks[1].Details != todos[1].Details {
+		t.Errorf("Unexpected second task: %+v", tasks[1])
+	}
+	if report.skipped != 1 {
+		t.Errorf("Expected the event skipped, got %q", report.notes)
+	}
+}
+
+func TestAppImportExport(t *testing.T) {
+	app := newTestApp(t)
+	runCmd(t, app, "add", "Existing")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tasks.txt")
+	os.WriteFile(path, []byte("Write docs +work\nx Review @team\n"), 0o644)
+
+	_, stdout, _ := runCmd(t, app, "import", "--dry-run", path)
+	if !strings.Contains(stdout, "Would import 2 tasks") || len(loadTodos(t, app)) != 1 {
+		t.Errorf("Expected a preview only, got '%s'", stdout)
+	}
+	if code, stdout, stderr := runCmd(t, app, "import", path); code != ExitOK || stdout != "Imported 2 tasks as 2,3\n" {
+		t.Fatalf("Expected the tasks imported, got %d: %q %s", code, stdout, stderr)
+	}
+	todos := loadTodos(t, app)
+	if len(todos) != 3 || todos[1].Project != "work" || todos[2].Status != StatusDone {
+		t.Errorf("Unexpected tasks: %+v", todos)
+	}
+
+	// One undo reverts the whole import
+	runCmd(t, app, "undo")
+	if len(loadTodos(t, app)) != 1 {
+		t.Error("Expected undo to remove the imported tasks")
+	}
+
+	_, stdout, _ = runCmd(t, app, "export", "--format", "ical")
+	if !strings.HasPrefix(stdout, "BEGIN:VCALENDAR") || !strings.Contains(stdout, "SUMMARY:Existing") {
+		t.Errorf("Expected iCalendar output, got '%s'", stdout)
+	}
+	if code, _, _ := runCmd(t, app, "export"); code != ExitUsage {
+		t.Errorf("Expected exit code %d without a format, got %d", ExitUsage, code)
+	}
+	if code, _, _ := runCmd(t, app, "import", filepath.Join(dir, "tasks.xml")); code != ExitUsage {
+		t.Errorf("Expected exit code %d for an unknown extension, got %d", ExitUsage, code)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// todo.txt priorities are letters, A the most important. Letters past D
+// are read as low.
+var todoTxtPriorities = map[Priority]string{
+	PriorityUrgent: "A",
+	PriorityHigh:   "B",
+	PriorityMedium: "C",
+	PriorityLow:    "D",
+}
+
+var (
+	todoTxtDate     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	todoTxtPriority = regexp.MustCompile(`^\([A-Z]\)$`)
+)
+
+const todoTxtLayout = "2006-01-02"
+
+// readTodoTxt reads one task per line in the todo.txt format: an optional
+// "x" and completion date, priority, creation date, then the description
+// with +project, @context and key:value words. Contexts become tags, due:
+// the due date; other key:value words stay in the description.
+func readTodoTxt(r io.Reader, report *exchangeReport) ([]importedTask, error) {
+	var tasks []importedTask
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		words := strings.Fields(scanner.Text())
+		if len(words) == 0 {
+			continue
+		}
+		it := importedTask{where: fmt.Sprintf("line %d", n)}
+		it.Status = StatusTodo
+
+		if words[0] == "x" {
+			it.Status = StatusDone
+			words = words[1:]
+			if len(words) > 0 && todoTxtDate.MatchString(words[0]) {
+				it.CompletedAt = it.todoTxtDate(report, "completion", words[0])
+				words = words[1:]
+			}
+		}
+		if len(words) > 0 && todoTxtPriority.MatchString(words[0]) {
+			it.Priority = it.todoTxtPriority(report, words[0][1:2])
+			words = words[1:]
+		}
+		if len(words) > 0 && todoTxtDate.MatchString(words[0]) {
+			if created := it.todoTxtDate(report, "creation", words[0]); created != nil {
+				it.CreatedAt = *created
+			}
+			words = words[1:]
+		}
+
+		var description, projects []string
+		for _, word := range words {
+			key, value, isPair := strings.Cut(word, ":")
+			switch {
+			case len(word) > 1 && word[0] == '+':
+				projects = append(projects, word[1:])
+			case len(word) > 1 && word[0] == '@':
+				it.importLabels(report, "", word[1:])
+			case isPair && key == "due" && todoTxtDate.MatchString(value):
+				it.DueAt = it.todoTxtDate(report, "due", value)
+			case isPair && key == "pri" && len(value) == 1:
+				it.Priority = it.todoTxtPriority(report, strings.ToUpper(value))
+			default:
+				description = append(description, word)
+			}
+		}
+		if len(projects) > 0 {
+			it.importLabels(report, projects[0])
+		}
+		if len(projects) > 1 {
+			report.lossy(it.where, "a task belongs to one project, kept +%s and tagged the others", it.Project)
+			it.importLabels(report, "", projects[1:]...)
+		}
+
+		it.Description = strings.Join(description, " ")
+		if it.Description == "" {
+			report.skip(it.where, "no description")
+			continue
+		}
+		tasks = append(tasks, it)
+	}
+	return tasks, scanner.Err()
+}
+
+// todoTxtDate parses a word that looks like a date, dropping it with a
+// note when it is not a real one, e.g. 2024-13-45.
+func (it *importedTask) todoTxtDate(report *exchangeReport, kind, word string) *time.Time {
+	t, err := time.ParseInLocation(todoTxtLayout, word, time.Local)
+	if err != nil {
+		report.lossy(it.where, "invalid %s date %q dropped", kind, word)
+		return nil
+	}
+	return &t
+}
+
+func (it *importedTask) todoTxtPriority(report *exchangeReport, letter string) Priority {
+	for p, l := range todoTxtPriorities {
+		if l == letter {
+			return p
+		}
+	}
+	report.lossy(it.where, "priority (%s) read as low", letter)
+	return PriorityLow
+}
+
+// writeTodoTxt writes the tasks in the todo.txt format. Cancelled tasks
+// are written as done, statuses other than done as open tasks.
+func writeTodoTxt(w io.Writer, todos Todos, report *exchangeReport) error {
+	bw := bufio.NewWriter(w)
+	for _, t := range todos {
+		where := fmt.Sprintf("task %d", t.ID)
+		var words []string
+		if t.Status.Finished() {
+			words = append(words, "x")
+			if t.CompletedAt != nil {
+				words = append(words, t.CompletedAt.Format(todoTxtLayout))
+			} else {
+				words = append(words, lastChange(t).Format(todoTxtLayout))
+			}
+		}
+		switch t.Status {
+		case StatusInProgress, StatusBlocked:
+			report.lossy(where, "status %s written as open", t.Status)
+		case StatusCancelled:
+			report.lossy(where, "status cancelled written as done")
+		}
+		// The creation date has to follow the completion date, so finished
+		// tasks keep their priority as a pri: tag
+		if t.Priority != "" && !t.Status.Finished() {
+			words = append(words, "("+todoTxtPriorities[t.Priority]+")")
+		}
+		words = append(words, t.CreatedAt.Format(todoTxtLayout), t.Description)
+		if t.Project != "" {
+			words = append(words, "+"+t.Project)
+		}
+		for _, tag := range t.Tags {
+			words = append(words, "@"+tag)
+		}
+		if t.Priority != "" && t.Status.Finished() {
+			words = append(words, "pri:"+todoTxtPriorities[t.Priority])
+		}
+		if t.DueAt != nil {
+			words = append(words, "due:"+t.DueAt.Format(todoTxtLayout))
+			if !isAllDay(*t.DueAt) {
+				report.lossy(where, "time of the due date dropped")
+			}
+		}
+		exportLosses(report, t)
+		fmt.Fprintln(bw, strings.Join(words, " "))
+	}
+	return bw.Flush()
+}
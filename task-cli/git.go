@@ -0,0 +1,428 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// gitIgnore keeps everything but the lists out of the repository. Journals,
+// archives and locks belong to one machine. Its first line marks a
+// repository task created.
+const (
+	gitIgnoreHeader = "# Only the task lists are tracked, journals, archives and locks stay local\n"
+	gitIgnore       = gitIgnoreHeader + "*\n!*.json\n!.gitignore\n"
+)
+
+func checkGit(value string) error {
+	if value != "on" && value != "off" {
+		return fmt.Errorf("invalid value %q, use on or off", value)
+	}
+	return nil
+}
+
+// gitEnabled reports whether changes to the lists are committed to git.
+func gitEnabled(app *App) bool {
+	value, _ := app.Setting("git")
+	return value == "on"
+}
+
+// gitRepo is the git repository in the directory of a store.
+type gitRepo struct {
+	dir string
+}
+
+// checkGitStore reports why changes to the store at storePath cannot be
+// committed. Stores are merged as JSON, so other backends are refused, and
+// so are repositories task did not create, e.g. a project a -file store
+// lives in. App.Run checks this before a command changes anything.
+func checkGitStore(app *App, storePath string) error {
+	if backend := app.StoreBackend(); backend != "json" {
+		return usageErrorf("git history needs the json store, not %s", backend)
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return errors.New("git history needs git installed")
+	}
+	dir := filepath.Dir(storePath)
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	ignore, _ := os.ReadFile(filepath.Join(dir, ".gitignore"))
+	if !strings.HasPrefix(string(ignore), gitIgnoreHeader) {
+		return fmt.Errorf("%s holds a git repository task did not create, keep the list in a directory of its own for git history", dir)
+	}
+	return nil
+}
+
+// openGitRepo returns the repository holding the store at storePath,
+// creating it on first use.
+func openGitRepo(app *App, storePath string) (*gitRepo, error) {
+	if err := checkGitStore(app, storePath); err != nil {
+		return nil, err
+	}
+	r := &gitRepo{dir: filepath.Dir(storePath)}
+	if _, err := os.Stat(filepath.Join(r.dir, ".git")); err == nil {
+		return r, nil
+	}
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return nil, err
+	}
+	if _, err := r.run("init", "-q"); err != nil {
+		return nil, err
+	}
+	if !r.ok("var", "GIT_COMMITTER_IDENT") {
+		// Commits must not fail for want of a name, e.g. on a fresh server
+		r.run("config", "user.name", "task")
+		r.run("config", "user.email", "task@localhost")
+	}
+	ignore := gitIgnore
+	if name := filepath.Base(storePath); !strings.HasSuffix(name, ".json") {
+		ignore += "!" + name + "\n"
+	}
+	return r, os.WriteFile(filepath.Join(r.dir, ".gitignore"), []byte(ignore), 0o644)
+}
+
+// run runs git in the repository and returns its trimmed output. Errors
+// carry what git printed on stderr.
+func (r *gitRepo) run(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// ok runs a git command that answers with its exit status.
+func (r *gitRepo) ok(args ...string) bool {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.dir
+	return cmd.Run() == nil
+}
+
+// listAt reads the list stored as name in the commit rev. A list missing
+// there is empty.
+func (r *gitRepo) listAt(rev, name string) (TodoList, error) {
+	list := TodoList{}
+	if rev == "" || !r.ok("cat-file", "-e", rev+":"+name) {
+		return list, nil
+	}
+	data, err := r.run("show", rev+":"+name)
+	if err != nil {
+		return list, err
+	}
+	raw, _, err := decodeStored([]byte(data), list.schema())
+	if err == nil {
+		err = json.Unmarshal(raw, &list)
+	}
+	if err != nil {
+		return list, fmt.Errorf("%s in %.7s: %w", name, rev, err)
+	}
+	return list, nil
+}
+
+// commitGitLists commits the lists at paths after a command that works on
+// lists rather than a loaded store, such as list-rename, wrote them. Lists
+// that no longer exist are removed from the repository.
+func commitGitLists(app *App, args []string, paths ...string) error {
+	if !gitEnabled(app) {
+		return nil
+	}
+	repo, err := openGitRepo(app, paths[0])
+	if err != nil {
+		return err
+	}
+	if _, err := repo.run("add", "--", ".gitignore"); err != nil {
+		return err
+	}
+	for _, path := range paths {
+		name := filepath.Base(path)
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			_, err = repo.run("rm", "-q", "--cached", "--ignore-unmatch", "--", name)
+		} else {
+			_, err = repo.run("add", "--", name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := repo.run("rev-parse", "-q", "--verify", "HEAD"); err == nil && repo.ok("diff", "--cached", "--quiet") {
+		return nil
+	}
+	name := strings.TrimSuffix(filepath.Base(paths[0]), ".json")
+	_, err = repo.run("commit", "-q", "-m", name+": "+commandLine(args))
+	return err
+}
+
+// commitGit commits the lists after a command changed them, with the
+// command as the subject and the changed tasks in the body. Only the store
+// and the other lists the command wrote are staged. After a sync it
+// concludes the merge and pushes.
+func (ctx *Context) commitGit(storePath string, args []string, before Todos) error {
+	if !gitEnabled(ctx.App) {
+		return nil
+	}
+	repo, err := openGitRepo(ctx.App, storePath)
+	if err != nil {
+		return err
+	}
+	paths := append([]string{"add", "--", ".gitignore", filepath.Base(storePath)}, ctx.gitFiles...)
+	if _, err := repo.run(paths...); err != nil {
+		return err
+	}
+
+	name := strings.TrimSuffix(filepath.Base(storePath), ".json")
+	message := gitMessage(name+": "+commandLine(args), before, ctx.List.Todos)
+	head, headErr := repo.run("rev-parse", "-q", "--verify", "HEAD")
+	switch {
+	case ctx.gitMerge != "":
+		tree, err := repo.run("write-tree")
+		if err != nil {
+			return err
+		}
+		theirsTree, err := repo.run("rev-parse", ctx.gitMerge+"^{tree}")
+		if err != nil {
+			return err
+		}
+		commit := ctx.gitMerge
+		if tree != theirsTree || headErr == nil && !repo.ok("merge-base", "--is-ancestor", "HEAD", ctx.gitMerge) {
+			parents := []string{"-p", ctx.gitMerge}
+			if headErr == nil {
+				parents = append([]string{"-p", head}, parents...)
+			}
+			if commit, err = repo.run(append(append([]string{"commit-tree", tree}, parents...), "-m", message)...); err != nil {
+				return err
+			}
+		}
+		if _, err := repo.run("update-ref", "HEAD", commit); err != nil {
+			return err
+		}
+	case headErr != nil || !repo.ok("diff", "--cached", "--quiet"):
+		if _, err := repo.run("commit", "-q", "-m", message); err != nil {
+			return err
+		}
+	}
+
+	if ctx.gitPush == "" {
+		return nil
+	}
+	head, headErr = repo.run("rev-parse", "-q", "--verify", "HEAD")
+	pushed, _ := repo.run("rev-parse", "-q", "--verify", "refs/remotes/origin/"+ctx.gitPush)
+	if headErr != nil || head == pushed {
+		return nil
+	}
+	if _, err := repo.run("push", "-q", "origin", "HEAD"); err != nil {
+		return fmt.Errorf("%w (run sync again if the remote changed meanwhile)", err)
+	}
+	fmt.Fprintf(ctx.Stdout, "Pushed to origin/%s\n", ctx.gitPush)
+	return nil
+}
+
+// gitMessage describes the tasks a command changed, one per line.
+func gitMessage(subject string, before, after Todos) string {
+	lines := []string{subject}
+	for i, c := range diffTodos(before, after) {
+		if i == 0 {
+			lines = append(lines, "")
+		}
+		switch {
+		case c.Before == nil:
+			lines = append(lines, fmt.Sprintf("Added task %d: %s", c.ID, c.After.Description))
+		case c.After == nil:
+			lines = append(lines, fmt.Sprintf("Removed task %d: %s", c.ID, c.Before.Description))
+		default:
+			lines = append(lines, fmt.Sprintf("Changed task %d: %s", c.ID, c.After.Description))
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// gitSync merges the lists on the remote's branch into the local ones and
+// returns the number of tasks it changed. The merge is committed and pushed
+// by commitGit once the lists are saved.
+func gitSync(ctx *Context) (int, error) {
+	path, err := ctx.App.StorePath()
+	if err != nil {
+		return 0, err
+	}
+	repo, err := openGitRepo(ctx.App, path)
+	if err != nil {
+		return 0, err
+	}
+	remotes, err := repo.run("remote")
+	if err != nil {
+		return 0, err
+	}
+	if !slices.Contains(strings.Fields(remotes), "origin") {
+		return 0, errors.New("the git repository of the list has no remote, add one with task git remote add origin <url>")
+	}
+	branch, err := repo.run("symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return 0, err
+	}
+	ctx.gitPush = branch
+	if _, err := repo.run("fetch", "-q", "origin"); err != nil {
+		return 0, err
+	}
+
+	theirs, err := repo.run("rev-parse", "-q", "--verify", "refs/remotes/origin/"+branch)
+	if err != nil {
+		// Nothing pushed there yet
+		return 0, nil
+	}
+	if repo.ok("merge-base", "--is-ancestor", theirs, "HEAD") {
+		if head, _ := repo.run("rev-parse", "HEAD"); head == theirs {
+			fmt.Fprintf(ctx.Stdout, "Already up to date with origin/%s\n", branch)
+		}
+		return 0, nil
+	}
+	base, _ := repo.run("merge-base", "HEAD", theirs)
+	names, err := repo.run("ls-tree", "--name-only", theirs)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, name := range strings.Split(names, "\n") {
+		if !strings.HasSuffix(name, ".json") && name != filepath.Base(path) {
+			continue
+		}
+		baseList, err := repo.listAt(base, name)
+		if err != nil {
+			return 0, err
+		}
+		theirsList, err := repo.listAt(theirs, name)
+		if err != nil {
+			return 0, err
+		}
+
+		if name == filepath.Base(path) {
+			merged, renumbered, notes := mergeLists(baseList, *ctx.List, theirsList)
+			for _, note := range notes {
+				fmt.Fprintln(ctx.Stdout, note)
+			}
+			state, err := ctx.SyncState()
+			if err != nil {
+				return 0, err
+			}
+			renumberLocal(ctx.Journal, state, renumbered)
+			ctx.renumbered = renumbered
+			changed += len(diffTodos(ctx.List.Todos, merged.Todos))
+			*ctx.List = merged
+			continue
+		}
+		n, err := mergeListFile(ctx, filepath.Join(repo.dir, name), baseList, theirsList)
+		if err != nil {
+			return changed, err
+		}
+		changed += n
+	}
+	ctx.gitMerge = theirs
+	fmt.Fprintf(ctx.Stdout, "Merged %d %s from origin/%s\n", changed, plural(changed, "change"), branch)
+	return changed, nil
+}
+
+// mergeListFile merges theirs into another list of the repository, the
+// way moveTodo changes it: locked and recorded in its journal.
+func mergeListFile(ctx *Context, path string, base, theirs TodoList) (int, error) {
+	lock, err := LockStore(path, true)
+	if err != nil {
+		return 0, err
+	}
+	defer lock.Unlock()
+
+	store := NewStorage[TodoList](path)
+	list := TodoList{}
+	err = store.Load(&list)
+	missing := errors.Is(err, fs.ErrNotExist)
+	if err != nil && !missing {
+		return 0, err
+	}
+	merged, renumbered, notes := mergeLists(base, list, theirs)
+	ctx.gitFiles = append(ctx.gitFiles, filepath.Base(path))
+	name := strings.TrimSuffix(filepath.Base(path), ".json")
+	for _, note := range notes {
+		fmt.Fprintf(ctx.Stdout, "List %s: %s\n", name, note)
+	}
+	changed := len(diffTodos(list.Todos, merged.Todos))
+	if changed == 0 && !missing {
+		return 0, nil
+	}
+
+	journal, err := OpenJournal(journalPath(path))
+	if err != nil {
+		return 0, err
+	}
+	state, err := OpenSyncState(syncStatePath(path))
+	if err != nil {
+		return 0, err
+	}
+	renumberLocal(journal, state, renumbered)
+	list.renumber(renumbered)
+	if err := store.Save(merged); err != nil {
+		return 0, err
+	}
+	if err := state.Save(); err != nil {
+		return 0, err
+	}
+	journal.Record("sync", list.Todos, merged.Todos, nil, time.Now())
+	return changed, journal.Save()
+}
+
+// renumberLocal moves the journal entries and server sync links of the
+// tasks a merge renumbered to their new IDs. The entry recording the merge
+// has to start from the new IDs too. The caller saves both after the list.
+func renumberLocal(journal *Journal, state *SyncState, ids map[int]int) {
+	if len(ids) == 0 {
+		return
+	}
+	journal.renumber(ids)
+	state.renumber(ids)
+}
+
+func newGitCmd() *Command {
+	return &Command{
+		Name:    "git",
+		Args:    "<git arguments>",
+		Summary: "Run git in the repository of the list, e.g. to add a remote or read the log.",
+		Flags:   newFlagSet("git"),
+		NoStore: true,
+		RawArgs: true,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) == 0 {
+				return usageErrorf("expected git arguments, e.g. log")
+			}
+			path, err := ctx.App.StorePath()
+			if err != nil {
+				return err
+			}
+			repo, err := openGitRepo(ctx.App, path)
+			if err != nil {
+				return err
+			}
+			cmd := exec.Command("git", args...)
+			cmd.Dir = repo.dir
+			cmd.Stdin, cmd.Stdout, cmd.Stderr = ctx.App.Stdin, ctx.Stdout, ctx.Stderr
+			return cmd.Run()
+		},
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Helper to set up two machines sharing a bare repository
+func newGitApps(t *testing.T) (*App, *App) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	if out, err := exec.Command("git", "init", "-q", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("Failed to create the remote: %s", out)
+	}
+
+	apps := []*App{newTestApp(t), newTestApp(t)}
+	t.Setenv("TASK_GIT", "on")
+	for _, app := range apps {
+		if code, _, stderr := runCmd(t, app, "git", "init", "-q"); code != ExitOK {
+			t.Fatalf("Failed to create the repository: %s", stderr)
+		}
+		runCmd(t, app, "git", "remote", "add", "origin", remote)
+	}
+	return apps[0], apps[1]
+}
+
+// Helper to read the log of the app's repository
+func gitLog(t *testing.T, app *App, args ...string) string {
+	t.Helper()
+	_, stdout, stderr := runCmd(t, app, append([]string{"git", "log"}, args...)...)
+	if stderr != "" {
+		t.Fatalf("Failed to read the log: %s", stderr)
+	}
+	return stdout
+}
+
+func TestGitCommitsEveryChange(t *testing.T) {
+	app, _ := newGitApps(t)
+	runCmd(t, app, "add", "Write docs")
+	runCmd(t, app, "mark", "1", "done")
+	runCmd(t, app, "list")
+
+	if log := gitLog(t, app, "--format=%s"); log != "todos: mark 1 done\ntodos: add \"Write docs\"\n" {
+		t.Errorf("Expected a commit per changing command, got %q", log)
+	}
+	if body := gitLog(t, app, "-1", "--format=%b"); !strings.Contains(body, "Changed task 1: Write docs") {
+		t.Errorf("Expected the changed task in the message, got %q", body)
+	}
+	if _, stdout, _ := runCmd(t, app, "git", "ls-files"); stdout != ".gitignore\ntodos.json\n" {
+		t.Errorf("Expected only the list tracked, got %q", stdout)
+	}
+
+	t.Setenv("TASK_STORE", "jsonl")
+	app.File = strings.TrimSuffix(app.File, ".json") + ".jsonl"
+	if code, _, stderr := runCmd(t, app, "add", "Other"); code != ExitUsage || !strings.Contains(stderr, "needs the json store") {
+		t.Errorf("Expected other stores refused, got %d: %s", code, stderr)
+	}
+	if _, err := os.Stat(app.File); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing saved to the refused store, got %v", err)
+	}
+}
+
+func TestGitRefusesForeignRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	app := newTestApp(t)
+	t.Setenv("TASK_GIT", "on")
+	project := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", project).CombinedOutput(); err != nil {
+		t.Fatalf("Failed to create the project: %s", out)
+	}
+	os.WriteFile(filepath.Join(project, "notes.txt"), []byte("private"), 0o644)
+	app.File = filepath.Join(project, "todos.json")
+
+	if code, _, stderr := runCmd(t, app, "add", "Write docs"); code != ExitError || !strings.Contains(stderr, "did not create") {
+		t.Errorf("Expected the project repository refused, got %d: %s", code, stderr)
+	}
+	cmd := exec.Command("git", "rev-parse", "-q", "--verify", "HEAD")
+	cmd.Dir = project
+	if cmd.Run() == nil {
+		t.Error("Expected nothing committed to the project")
+	}
+	if _, err := os.Stat(app.File); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing saved to the list, got %v", err)
+	}
+}
+
+func TestGitMoveCommitsBothLists(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	app := newTestApp(t)
+	app.File = ""
+	t.Setenv("TASK_GIT", "on")
+	runCmd(t, app, "list-create", "home")
+	runCmd(t, app, "add", "Buy milk")
+	runCmd(t, app, "move", "1", "--to", "home")
+
+	if files := gitLog(t, app, "-1", "--name-only", "--format="); strings.TrimSpace(files) != "default.json\nhome.json" {
+		t.Errorf("Expected both lists in the commit of the move, got %q", files)
+	}
+}
+
+func TestGitCommitsListCreateAndRename(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	app := newTestApp(t)
+	app.File = ""
+	t.Setenv("TASK_GIT", "on")
+	runCmd(t, app, "add", "Buy milk")
+	runCmd(t, app, "list-create", "work")
+	if code, _, stderr := runCmd(t, app, "list-rename", "default", "home"); code != ExitOK {
+		t.Fatalf("Expected the list renamed, got %d: %s", code, stderr)
+	}
+
+	// The old name leaves the repository, so a sync does not bring it back
+	if log := gitLog(t, app, "--format=%s"); log != "home: list-rename default home\nwork: list-create work\ndefault: add \"Buy milk\"\n" {
+		t.Errorf("Expected a commit per list command, got %q", log)
+	}
+	if _, stdout, _ := runCmd(t, app, "git", "ls-files"); stdout != ".gitignore\nhome.json\nwork.json\n" {
+		t.Errorf("Expected the renamed and created lists tracked, got %q", stdout)
+	}
+	if _, stdout, _ := runCmd(t, app, "git", "status", "--porcelain"); stdout != "" {
+		t.Errorf("Expected a clean working tree, got %q", stdout)
+	}
+}
+
+func TestGitSync(t *testing.T) {
+	laptop, desktop := newGitApps(t)
+	runCmd(t, laptop, "add", "Write docs")
+	runCmd(t, laptop, "add", "Fix bug")
+	if _, stdout, stderr := runCmd(t, laptop, "sync"); stdout != "Pushed to origin/"+gitBranch(t, laptop)+"\n" {
+		t.Fatalf("Expected the first sync to push, got %q %s", stdout, stderr)
+	}
+
+	// Both machines add a task 1 before they first sync
+	runCmd(t, desktop, "add", "Walk dog")
+	_, stdout, _ := runCmd(t, desktop, "sync")
+	if !strings.Contains(stdout, "Renumbered task 1 to 3") {
+		t.Errorf("Expected the local task renumbered, got %q", stdout)
+	}
+	if todos := loadTodos(t, desktop); len(todos) != 3 || todos[2].Description != "Walk dog" {
+		t.Fatalf("Expected both machines' tasks, got %+v", todos)
+	}
+
+	// Concurrent edits of different fields and tasks merge cleanly
+	runCmd(t, laptop, "update", "1", "Write the docs")
+	runCmd(t, desktop, "mark", "1", "done")
+	runCmd(t, desktop, "delete", "2")
+	runCmd(t, desktop, "sync")
+	_, stdout, _ = runCmd(t, laptop, "sync")
+	if strings.Contains(stdout, "Conflict") {
+		t.Errorf("Expected no conflict, got %q", stdout)
+	}
+	runCmd(t, desktop, "sync")
+	for _, app := range []*App{laptop, desktop} {
+		todos := loadTodos(t, app)
+		if len(todos) != 2 || todos[0].Description != "Write the docs" || todos[0].Status != StatusDone {
+			t.Errorf("Expected the edits of both machines, got %+v", todos)
+		}
+	}
+	if _, stdout, _ = runCmd(t, laptop, "sync"); !strings.HasPrefix(stdout, "Already up to date") {
+		t.Errorf("Expected nothing left to sync, got %q", stdout)
+	}
+
+	// The merge is one journal entry
+	runCmd(t, desktop, "undo")
+	if todos := loadTodos(t, desktop); todos[0].Description != "Write docs" {
+		t.Errorf("Expected undo to revert the merge, got %+v", todos[0])
+	}
+}
+
+// Helper to read the branch git created the repository with
+func gitBranch(t *testing.T, app *App) string {
+	t.Helper()
+	_, stdout, _ := runCmd(t, app, "git", "symbolic-ref", "--short", "HEAD")
+	return strings.TrimSpace(stdout)
+}
+
+func TestGitSyncRenumbersLinksAndJournal(t *testing.T) {
+	laptop, desktop := newGitApps(t)
+	runCmd(t, laptop, "add", "Write docs")
+	runCmd(t, laptop, "sync")
+
+	// The desktop's task 1 is linked to a todo of the server
+	runCmd(t, desktop, "add", "Walk dog")
+	NewStorage[SyncState](syncStatePath(desktop.File)).Save(SyncState{URL: "http://localhost", Links: []syncLink{{ID: 1, RemoteID: 7}}})
+	if _, stdout, _ := runCmd(t, desktop, "sync"); !strings.Contains(stdout, "Renumbered task 1 to 2") {
+		t.Fatalf("Expected the local task renumbered, got %q", stdout)
+	}
+	state, err := OpenSyncState(syncStatePath(desktop.File))
+	if err != nil || len(state.Links) != 1 || state.Links[0].ID != 2 {
+		t.Errorf("Expected the link to follow the task, got %+v, %v", state, err)
+	}
+
+	// Undo steps back through the merge to the task under its new ID
+	runCmd(t, desktop, "undo")
+	if todos := loadTodos(t, desktop); len(todos) != 1 || todos[0].ID != 2 || todos[0].Description != "Walk dog" {
+		t.Errorf("Expected only the local task after undoing the merge, got %+v", todos)
+	}
+	if code, _, stderr := runCmd(t, desktop, "undo"); code != ExitOK {
+		t.Fatalf("Expected the add undone, got %d: %s", code, stderr)
+	}
+	if todos := loadTodos(t, desktop); len(todos) != 0 {
+		t.Errorf("Expected the list empty, got %+v", todos)
+	}
+}
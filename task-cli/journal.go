@@ -0,0 +1,362 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxJournalEntries bounds the journal; the oldest entries are dropped.
+const maxJournalEntries = 200
+
+// Change is one task before and after a command. Before is nil for an
+// added task, After for a deleted one. Archived is set when the task moved
+// between the list and its archive rather than being added or deleted,
+// MovedTo when it moved to another list.
+type Change struct {
+	ID       int        `json:"id"`
+	Before   *Todo      `json:"before,omitempty"`
+	After    *Todo      `json:"after,omitempty"`
+	Archived bool       `json:"archived,omitempty"`
+	MovedTo  *movedTodo `json:"movedTo,omitempty"`
+}
+
+// movedTodo is the copy of a task that move added to the list stored at
+// Path.
+type movedTodo struct {
+	Path string `json:"path"`
+	Todo Todo   `json:"todo"`
+}
+
+// JournalEntry records the changes made by one mutating command.
+type JournalEntry struct {
+	Seq     int       `json:"seq"`
+	At      time.Time `json:"at"`
+	Command string    `json:"command"`
+	Changes []Change  `json:"changes"`
+}
+
+// Journal is the undo history kept next to the store. Entries before
+// Position are applied, the ones from Position on have been undone and can
+// be redone until the next command records a new entry.
+type Journal struct {
+	Entries  []JournalEntry `json:"entries"`
+	Position int            `json:"position"`
+
+	path  string
+	dirty bool
+}
+
+// journalPath returns the journal of the store at storePath.
+func journalPath(storePath string) string {
+	return storePath + ".journal"
+}
+
+// OpenJournal loads the journal at path. A missing journal is empty.
+func OpenJournal(path string) (*Journal, error) {
+	j := &Journal{}
+	if err := NewStorage[Journal](path).Load(j); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("reading journal: %w", err)
+	}
+	j.path = path
+	j.Position = min(max(j.Position, 0), len(j.Entries))
+	return j, nil
+}
+
+// Save writes the journal back if it changed.
+func (j *Journal) Save() error {
+	if !j.dirty {
+		return nil
+	}
+	if err := NewStorage[Journal](j.path).Save(*j); err != nil {
+		return fmt.Errorf("writing journal: %w", err)
+	}
+	j.dirty = false
+	return nil
+}
+
+// Record appends an entry for the difference between before and after,
+// discarding anything that could have been redone. Commands that changed
+// nothing are not recorded. archived lists the tasks that moved between the
+// list and the archive.
+func (j *Journal) Record(command string, before, after Todos, archived []int, now time.Time) {
+	changes := diffTodos(before, after)
+	if len(changes) == 0 {
+		return
+	}
+	for i, c := range changes {
+		changes[i].Archived = slices.Contains(archived, c.ID) && (c.Before == nil || c.After == nil)
+	}
+
+	seq := 1
+	if len(j.Entries) > 0 {
+		seq = j.Entries[len(j.Entries)-1].Seq + 1
+	}
+	j.Entries = append(j.Entries[:j.Position], JournalEntry{Seq: seq, At: now, Command: command, Changes: changes})
+	if len(j.Entries) > maxJournalEntries {
+		j.Entries = j.Entries[len(j.Entries)-maxJournalEntries:]
+	}
+	j.Position = len(j.Entries)
+	j.dirty = true
+}
+
+// markMoved notes the copies of the tasks a command moved to other lists on
+// the changes of the entry it just recorded.
+func (j *Journal) markMoved(moved map[int]movedTodo) {
+	if len(moved) == 0 || j.Position == 0 {
+		return
+	}
+	changes := j.Entries[j.Position-1].Changes
+	for i, c := range changes {
+		if m, ok := moved[c.ID]; ok && c.After == nil {
+			changes[i].MovedTo = &m
+		}
+	}
+}
+
+// Undo reverts the last applied entry on list.
+func (j *Journal) Undo(list *TodoList) (JournalEntry, error) {
+	if j.Position == 0 {
+		return JournalEntry{}, errors.New("nothing to undo")
+	}
+	entry := j.Entries[j.Position-1]
+	if err := list.applyChanges(entry.Changes, true); err != nil {
+		return entry, fmt.Errorf("cannot undo %q: %w", entry.Command, err)
+	}
+	j.Position--
+	j.dirty = true
+	return entry, nil
+}
+
+// Redo applies the last undone entry on list again.
+func (j *Journal) Redo(list *TodoList) (JournalEntry, error) {
+	if j.Position == len(j.Entries) {
+		return JournalEntry{}, errors.New("nothing to redo")
+	}
+	entry := j.Entries[j.Position]
+	if err := list.applyChanges(entry.Changes, false); err != nil {
+		return entry, fmt.Errorf("cannot redo %q: %w", entry.Command, err)
+	}
+	j.Position++
+	j.dirty = true
+	return entry, nil
+}
+
+// diffTodos lists the tasks that differ between before and after, by ID.
+func diffTodos(before, after Todos) []Change {
+	var changes []Change
+	for i := range before {
+		old := before[i]
+		index, err := after.IndexOf(old.ID)
+		if err != nil {
+			changes = append(changes, Change{ID: old.ID, Before: &old})
+			continue
+		}
+		if !sameTodo(old, after[index]) {
+			changed := after[index]
+			changes = append(changes, Change{ID: old.ID, Before: &old, After: &changed})
+		}
+	}
+	for i := range after {
+		if _, err := before.IndexOf(after[i].ID); err != nil {
+			added := after[i]
+			changes = append(changes, Change{ID: added.ID, After: &added})
+		}
+	}
+
+	slices.SortFunc(changes, func(a, b Change) int { return a.ID - b.ID })
+	return changes
+}
+
+// applyChanges moves every changed task from one side of the change to the
+// other: back to Before when undoing, forward to After otherwise. It fails
+// without touching the list if a task no longer looks like the journal
+// expects, e.g. after the store was edited by hand.
+func (l *TodoList) applyChanges(changes []Change, undo bool) error {
+	for _, c := range changes {
+		from, _ := c.sides(undo)
+		index, err := l.IndexOf(c.ID)
+		switch {
+		case from == nil && err == nil:
+			return fmt.Errorf("task %d exists again", c.ID)
+		case from != nil && err != nil:
+			return fmt.Errorf("task %d no longer exists", c.ID)
+		case from != nil && !sameTodo(l.Todos[index], *from):
+			return fmt.Errorf("task %d was changed since", c.ID)
+		}
+	}
+
+	for _, c := range changes {
+		_, to := c.sides(undo)
+		index, err := l.IndexOf(c.ID)
+		switch {
+		case to == nil:
+			l.Todos.delete(c.ID)
+		case err == nil:
+			l.Todos[index] = cloneTodo(*to)
+		default:
+			l.insert(cloneTodo(*to))
+		}
+	}
+	return nil
+}
+
+// insert puts a task that was removed back at its place in ID order.
+func (l *TodoList) insert(t Todo) {
+	at, _ := slices.BinarySearchFunc(l.Todos, t.ID, func(t Todo, id int) int { return t.ID - id })
+	l.Todos = slices.Insert(l.Todos, at, t)
+	if t.ID >= l.NextID {
+		l.NextID = t.ID + 1
+	}
+}
+
+// sides returns the state a task is expected in and the one it is moved to.
+func (c Change) sides(undo bool) (from, to *Todo) {
+	if undo {
+		return c.After, c.Before
+	}
+	return c.Before, c.After
+}
+
+// cloneTodos deep copies todos, so commands mutating tags or transitions in
+// place do not alter a snapshot taken before they ran.
+func cloneTodos(todos Todos) Todos {
+	clone := make(Todos, len(todos))
+	for i, t := range todos {
+		clone[i] = cloneTodo(t)
+	}
+	return clone
+}
+
+func cloneTodo(t Todo) Todo {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return t
+	}
+	var clone Todo
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return t
+	}
+	return clone
+}
+
+// commandLine renders the arguments of a command for the history, quoting
+// those that contain spaces.
+func commandLine(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'") {
+			arg = strconv.Quote(arg)
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+func newUndoCmd() *Command {
+	return &Command{
+		Name:      "undo",
+		Summary:   "Revert the last command that changed tasks.",
+		Flags:     newFlagSet("undo"),
+		NoJournal: true,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) > 0 {
+				return usageErrorf("unexpected argument %q", args[0])
+			}
+			entry, err := ctx.Journal.Undo(ctx.List)
+			if err != nil {
+				return err
+			}
+			if err := ctx.syncArchive(entry, true); err != nil {
+				return err
+			}
+			if err := ctx.syncMoves(entry, true); err != nil {
+				return err
+			}
+			fmt.Fprintf(ctx.Stdout, "Undid #%d: %s\n", entry.Seq, entry.Command)
+			return nil
+		},
+	}
+}
+
+func newRedoCmd() *Command {
+	return &Command{
+		Name:      "redo",
+		Summary:   "Apply the last undone command again.",
+		Flags:     newFlagSet("redo"),
+		NoJournal: true,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) > 0 {
+				return usageErrorf("unexpected argument %q", args[0])
+			}
+			entry, err := ctx.Journal.Redo(ctx.List)
+			if err != nil {
+				return err
+			}
+			if err := ctx.syncArchive(entry, false); err != nil {
+				return err
+			}
+			if err := ctx.syncMoves(entry, false); err != nil {
+				return err
+			}
+			fmt.Fprintf(ctx.Stdout, "Redid #%d: %s\n", entry.Seq, entry.Command)
+			return nil
+		},
+	}
+}
+
+func newHistoryCmd() *Command {
+	flags := newFlagSet("history")
+	limit := flags.Int("limit", 20, "show at most this many entries (0 for all)")
+
+	return &Command{
+		Name:     "history",
+		Summary:  "Show the journal of recent changes, newest first.",
+		Flags:    flags,
+		ReadOnly: true,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) > 0 {
+				return usageErrorf("unexpected argument %q", args[0])
+			}
+			if *limit < 0 {
+				return usageErrorf("-limit must not be negative")
+			}
+
+			path, err := ctx.App.StorePath()
+			if err != nil {
+				return err
+			}
+			j, err := OpenJournal(journalPath(path))
+			if err != nil {
+				return err
+			}
+
+			shown := 0
+			for i := len(j.Entries) - 1; i >= 0 && (*limit == 0 || shown < *limit); i-- {
+				e := j.Entries[i]
+				state := ""
+				if i >= j.Position {
+					state = "  (undone)"
+				}
+				fmt.Fprintf(ctx.Stdout, "#%-4d %s  %s  [%d %s]%s\n", e.Seq, e.At.Format("2006-01-02 15:04:05"), e.Command, len(e.Changes), plural(len(e.Changes), "task"), state)
+				shown++
+			}
+			if shown == 0 {
+				fmt.Fprintln(ctx.Stdout, "No history yet")
+			}
+			return nil
+		},
+	}
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
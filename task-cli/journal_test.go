@@ -0,0 +1,134 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffTodos(t *testing.T) {
+	before := Todos{
+		{ID: 1, Description: "Keep", Status: StatusTodo},
+		{ID: 2, Description: "Change", Status: StatusTodo},
+		{ID: 3, Description: "Delete", Status: StatusTodo},
+	}
+	after := cloneTodos(before)
+	after[1].Tags = []string{"new"}
+	after.delete(3)
+	after = append(after, Todo{ID: 4, Description: "Add", Status: StatusTodo})
+
+	changes := diffTodos(before, after)
+	if len(changes) != 3 {
+		t.Fatalf("Expected 3 changes, got %+v", changes)
+	}
+	if changes[0].ID != 2 || changes[0].Before == nil || changes[0].After == nil {
+		t.Errorf("Expected task 2 changed, got %+v", changes[0])
+	}
+	if changes[1].ID != 3 || changes[1].After != nil {
+		t.Errorf("Expected task 3 deleted, got %+v", changes[1])
+	}
+	if changes[2].ID != 4 || changes[2].Before != nil {
+		t.Errorf("Expected task 4 added, got %+v", changes[2])
+	}
+}
+
+func TestJournalUndoRedo(t *testing.T) {
+	list := TodoList{NextID: 3, Todos: Todos{
+		{ID: 1, Description: "First", Status: StatusTodo},
+		{ID: 2, Description: "Second", Status: StatusTodo},
+	}}
+	j := &Journal{}
+	now := time.Now()
+
+	before := cloneTodos(list.Todos)
+	list.Todos.delete(1)
+	j.Record("delete 1", before, list.Todos, nil, now)
+
+	if _, err := j.Undo(&list); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(list.Todos) != 2 || list.Todos[0].ID != 1 {
+		t.Fatalf("Expected task 1 restored in place, got %+v", list.Todos)
+	}
+	if _, err := j.Undo(&list); err == nil {
+		t.Error("Expected nothing to undo, got nil")
+	}
+
+	if _, err := j.Redo(&list); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(list.Todos) != 1 || list.Todos[0].ID != 2 {
+		t.Errorf("Expected task 1 deleted again, got %+v", list.Todos)
+	}
+
+	// A task changed behind the journal's back is not overwritten
+	j.Undo(&list)
+	list.Todos[0].Description = "Edited by hand"
+	if _, err := j.Redo(&list); err == nil {
+		t.Error("Expected conflict error, got nil")
+	}
+	if list.Todos[0].Description != "Edited by hand" {
+		t.Errorf("Expected list untouched after conflict, got %+v", list.Todos)
+	}
+
+	// Recording a new change drops what could have been redone
+	before = cloneTodos(list.Todos)
+	list.add("Third")
+	j.Record("add Third", before, list.Todos, nil, now)
+	if len(j.Entries) != 1 || j.Position != 1 {
+		t.Errorf("Expected only the new entry, got %d entries at %d", len(j.Entries), j.Position)
+	}
+}
+
+func TestAppUndoRedo(t *testing.T) {
+	app := newTestApp(t)
+	runCmd(t, app, "add", "Task 1")
+	runCmd(t, app, "add", "Task 2")
+	runCmd(t, app, "mark", "1", "done")
+	runCmd(t, app, "delete", "2")
+
+	code, stdout, stderr := runCmd(t, app, "undo")
+	if code != ExitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", ExitOK, code, stderr)
+	}
+	if stdout != "Undid #4: delete 2\n" {
+		t.Errorf("Unexpected output: %q", stdout)
+	}
+	runCmd(t, app, "undo")
+
+	todos := loadTodos(t, app)
+	if len(todos) != 2 || todos[0].Status != StatusTodo || todos[0].CompletedAt != nil {
+		t.Fatalf("Expected both tasks back and task 1 reopened, got %+v", todos)
+	}
+
+	runCmd(t, app, "redo")
+	if todos = loadTodos(t, app); todos[0].Status != StatusDone {
+		t.Errorf("Expected task 1 done again, got %s", todos[0].Status)
+	}
+
+	_, stdout, _ = runCmd(t, app, "history")
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 history entries, got %q", stdout)
+	}
+	if !strings.Contains(lines[0], "delete 2") || !strings.Contains(lines[0], "(undone)") {
+		t.Errorf("Expected undone delete first, got %q", lines[0])
+	}
+	if !strings.Contains(lines[3], `add "Task 1"`) || strings.Contains(lines[1], "(undone)") {
+		t.Errorf("Unexpected history: %q", stdout)
+	}
+
+	// New IDs are still never reused after undoing an add
+	runCmd(t, app, "undo")
+	runCmd(t, app, "undo")
+	runCmd(t, app, "undo")
+	runCmd(t, app, "add", "Task 3")
+	if todos = loadTodos(t, app); todos[len(todos)-1].ID != 3 {
+		t.Errorf("Expected new task to get ID 3, got %+v", todos)
+	}
+
+	code, _, _ = runCmd(t, app, "redo")
+	if code != ExitError {
+		t.Errorf("Expected exit code %d with nothing to redo, got %d", ExitError, code)
+	}
+}
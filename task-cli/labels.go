@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// validLabel reports whether s can be used as a tag or project name: it
+// starts with a letter and continues with letters, digits or -_./
+func validLabel(s string) bool {
+	for i, r := range s {
+		switch {
+		case unicode.IsLetter(r):
+		case i > 0 && (unicode.IsDigit(r) || strings.ContainsRune("-_./", r)):
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
+
+// normalizeLabel strips the optional sigil ('#' or '+') and lowercases s.
+func normalizeLabel(s string, sigil byte) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	name = strings.TrimPrefix(name, string(sigil))
+	if !validLabel(name) {
+		return "", fmt.Errorf("invalid label %q, use letters, digits and -_./ starting with a letter", s)
+	}
+	return name, nil
+}
+
+// parseLabels pulls #tag and +project words out of text typed with add,
+// returning the remaining description. Words like "#12" that are no valid
+// label stay part of the description.
+func parseLabels(text string) (description string, tags []string, project string, err error) {
+	var words []string
+	for _, word := range strings.Fields(text) {
+		if len(word) > 1 && validLabel(word[1:]) {
+			switch word[0] {
+			case '#':
+				tags = addTags(tags, strings.ToLower(word[1:]))
+				continue
+			case '+':
+				name := strings.ToLower(word[1:])
+				if project != "" && project != name {
+					return "", nil, "", fmt.Errorf("a task belongs to one project, got +%s and +%s", project, name)
+				}
+				project = name
+				continue
+			}
+		}
+		words = append(words, word)
+	}
+	return strings.Join(words, " "), tags, project, nil
+}
+
+// addTags returns tags with more added, sorted and without duplicates.
+func addTags(tags []string, more ...string) []string {
+	for _, tag := range more {
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	slices.Sort(tags)
+	return tags
+}
+
+func removeTags(tags []string, remove ...string) []string {
+	return slices.DeleteFunc(tags, func(tag string) bool {
+		return slices.Contains(remove, tag)
+	})
+}
+
+// inProject reports whether project is name or one of its sub-projects,
+// e.g. "work.reports" is in "work".
+func inProject(project, name string) bool {
+	return project == name || strings.HasPrefix(project, name+".")
+}
+
+// tagList collects -tag flags, each a comma separated list.
+type tagList []string
+
+func (l *tagList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *tagList) Set(value string) error {
+	if value == "" {
+		*l = nil
+		return nil
+	}
+	for _, part := range strings.Split(value, ",") {
+		tag, err := normalizeLabel(part, '#')
+		if err != nil {
+			return err
+		}
+		*l = append(*l, tag)
+	}
+	return nil
+}
+
+// projectFlag is a -project value, "none" meaning no project.
+type projectFlag struct {
+	name string
+	set  bool
+}
+
+func (f *projectFlag) String() string {
+	return f.name
+}
+
+func (f *projectFlag) Set(value string) error {
+	f.name, f.set = "", value != ""
+	if value == "" || strings.EqualFold(value, "none") {
+		return nil
+	}
+	name, err := normalizeLabel(value, '+')
+	if err != nil {
+		return err
+	}
+	f.name = name
+	return nil
+}
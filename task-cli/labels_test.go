@@ -0,0 +1,107 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseLabels(t *testing.T) {
+	description, tags, project, err := parseLabels("Fix issue #12 for +Work.reports  #urgent #home #urgent +3d")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if description != "Fix issue #12 for +3d" {
+		t.Errorf("Expected labels removed from description, got %q", description)
+	}
+	if !slices.Equal(tags, []string{"home", "urgent"}) {
+		t.Errorf("Expected sorted unique tags, got %v", tags)
+	}
+	if project != "work.reports" {
+		t.Errorf("Expected project 'work.reports', got %q", project)
+	}
+
+	if _, _, _, err := parseLabels("Plan +home +work"); err == nil {
+		t.Error("Expected error for two projects, got nil")
+	}
+}
+
+func TestTagHelpers(t *testing.T) {
+	tags := addTags([]string{"work"}, "errand", "work")
+	if !slices.Equal(tags, []string{"errand", "work"}) {
+		t.Errorf("Expected [errand work], got %v", tags)
+	}
+	tags = removeTags(tags, "work", "missing")
+	if !slices.Equal(tags, []string{"errand"}) {
+		t.Errorf("Expected [errand], got %v", tags)
+	}
+
+	if !inProject("work.reports", "work") || !inProject("work", "work") || inProject("workshop", "work") {
+		t.Error("Expected sub-projects to match their parent only")
+	}
+
+	var list tagList
+	if err := list.Set("#Home,errand"); err != nil || !slices.Equal(list, tagList{"home", "errand"}) {
+		t.Errorf("Expected [home errand], got %v (%v)", list, err)
+	}
+	if err := list.Set("two words"); err == nil {
+		t.Error("Expected error for invalid tag, got nil")
+	}
+}
+
+func TestAppLabels(t *testing.T) {
+	app := newTestApp(t)
+	runCmd(t, app, "add", "Write report #work +q3", "--priority", "high")
+	runCmd(t, app, "add", "--tag", "home,errand", "--project", "chores", "Buy milk")
+	runCmd(t, app, "add", "Call mum #family")
+
+	todos := loadTodos(t, app)
+	if todos[0].Description != "Write report" || todos[0].Project != "q3" || todos[0].Priority != PriorityHigh {
+		t.Errorf("Unexpected first task: %+v", todos[0])
+	}
+	if !slices.Equal(todos[1].Tags, []string{"errand", "home"}) || todos[1].Project != "chores" {
+		t.Errorf("Unexpected second task: %+v", todos[1])
+	}
+
+	// Filters
+	_, stdout, _ := runCmd(t, app, "list", "--tag", "home", "--output", "jsonl", "--fields", "id")
+	if stdout != "{\"id\":2}\n" {
+		t.Errorf("Expected task 2 for tag home, got %q", stdout)
+	}
+	_, stdout, _ = runCmd(t, app, "list", "--priority", "none", "--output", "jsonl", "--fields", "id")
+	if stdout != "{\"id\":2}\n{\"id\":3}\n" {
+		t.Errorf("Expected tasks 2 and 3 without priority, got %q", stdout)
+	}
+	_, stdout, _ = runCmd(t, app, "list", "--sort", "priority", "--output", "jsonl", "--fields", "id,priority")
+	if !strings.HasPrefix(stdout, "{\"id\":1,\"priority\":\"high\"}") {
+		t.Errorf("Expected high priority task first, got %q", stdout)
+	}
+
+	// Changing labels
+	runCmd(t, app, "update", "--untag", "errand", "--tag", "urgent", "--priority", "none", "--project", "none", "2")
+	todos = loadTodos(t, app)
+	if !slices.Equal(todos[1].Tags, []string{"home", "urgent"}) || todos[1].Project != "" {
+		t.Errorf("Expected tags [home urgent] and no project, got %+v", todos[1])
+	}
+
+	// Grouped table output
+	_, stdout, _ = runCmd(t, app, "list", "--group-by", "project")
+	if strings.Index(stdout, "project: q3 (1)") > strings.Index(stdout, "project: (none) (2)") {
+		t.Errorf("Expected q3 group before the unlabelled one, got '%s'", stdout)
+	}
+
+	testCases := [][]string{
+		{"add", "--priority", "critical", "Oops"},
+		{"add", "#only-tags"},
+		{"list", "--group-by", "colour"},
+		{"list", "--group-by", "tag", "--output", "json"},
+	}
+	for _, args := range testCases {
+		if code, _, _ := runCmd(t, app, args...); code != ExitUsage {
+			t.Errorf("%v: expected exit code %d, got %d", args, ExitUsage, code)
+		}
+	}
+	if got := len(loadTodos(t, app)); got != 3 {
+		t.Errorf("Expected rejected tasks not to be saved, got %d tasks", got)
+	}
+}
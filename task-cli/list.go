@@ -0,0 +1,288 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TodoFilter selects tasks for the list command. Zero values match
+// everything.
+type TodoFilter struct {
+	Statuses   []Status
+	Priorities []Priority
+	Project    string
+	Tags       []string
+	Recurring  bool
+	DateField  string
+	Since      time.Time
+	Until      time.Time
+	Grep       *regexp.Regexp
+}
+
+func (f TodoFilter) Match(t Todo) bool {
+	if len(f.Statuses) > 0 && !slices.Contains(f.Statuses, t.Status) {
+		return false
+	}
+	if len(f.Priorities) > 0 && !slices.Contains(f.Priorities, t.Priority) {
+		return false
+	}
+	if f.Project != "" && !inProject(t.Project, f.Project) {
+		return false
+	}
+	if f.Recurring && t.Recur == nil {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !slices.Contains(t.Tags, tag) {
+			return false
+		}
+	}
+
+	date := t.CreatedAt
+	if f.DateField == "updated" && t.UpdatedAt != nil {
+		date = *t.UpdatedAt
+	}
+	if !f.Since.IsZero() && date.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !date.Before(f.Until) {
+		return false
+	}
+
+	if f.Grep != nil && !f.Grep.MatchString(t.Description) {
+		return false
+	}
+	return true
+}
+
+// Filter returns the tasks matching f, keeping their order.
+func (todos Todos) Filter(f TodoFilter) Todos {
+	matched := Todos{}
+	for _, t := range todos {
+		if f.Match(t) {
+			matched = append(matched, t)
+		}
+	}
+	return matched
+}
+
+// sortKeys compares two tasks for each value accepted by list -sort.
+var sortKeys = map[string]func(a, b Todo) int{
+	"id": func(a, b Todo) int {
+		return cmp.Compare(a.ID, b.ID)
+	},
+	"created": func(a, b Todo) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	},
+	"updated": func(a, b Todo) int {
+		return lastChange(a).Compare(lastChange(b))
+	},
+	"status": func(a, b Todo) int {
+		return cmp.Compare(statusRank(a.Status), statusRank(b.Status))
+	},
+	"due": compareDue,
+	"priority": func(a, b Todo) int {
+		return cmp.Compare(a.Priority.Rank(), b.Priority.Rank())
+	},
+	"project": func(a, b Todo) int {
+		return compareLabels(a.Project, b.Project)
+	},
+}
+
+// compareLabels orders names alphabetically, empty ones last.
+func compareLabels(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	return strings.Compare(a, b)
+}
+
+func sortKeyNames() string {
+	names := make([]string, 0, len(sortKeys))
+	for name := range sortKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// lastChange is when a task was last touched, for tasks never updated that
+// is their creation time.
+func lastChange(t Todo) time.Time {
+	if t.UpdatedAt != nil {
+		return *t.UpdatedAt
+	}
+	return t.CreatedAt
+}
+
+// statusRank orders statuses by workflow position, unknown ones last.
+func statusRank(s Status) int {
+	if i := slices.Index(Statuses, s); i >= 0 {
+		return i
+	}
+	return len(Statuses)
+}
+
+// Sort orders the tasks in place by key. Ties keep their current order and
+// are broken by ID.
+func (todos Todos) Sort(key string, reverse bool) error {
+	compare, ok := sortKeys[key]
+	if !ok {
+		return fmt.Errorf("unknown sort key %q (valid: %s)", key, sortKeyNames())
+	}
+
+	slices.SortStableFunc(todos, func(a, b Todo) int {
+		c := compare(a, b)
+		if c == 0 {
+			c = cmp.Compare(a.ID, b.ID)
+		}
+		if reverse {
+			return -c
+		}
+		return c
+	})
+	return nil
+}
+
+// statusList collects -status flags, each a comma separated list.
+type statusList []Status
+
+func (l *statusList) String() string {
+	names := make([]string, len(*l))
+	for i, s := range *l {
+		names[i] = string(s)
+	}
+	return strings.Join(names, ",")
+}
+
+func (l *statusList) Set(value string) error {
+	if value == "" {
+		*l = nil
+		return nil
+	}
+	for _, part := range strings.Split(value, ",") {
+		status, err := ParseStatus(part)
+		if err != nil {
+			return err
+		}
+		*l = append(*l, status)
+	}
+	return nil
+}
+
+// timeFlag is a point in time in any form ParseDate accepts. For an -until
+// date the whole day is included.
+type timeFlag struct {
+	time.Time
+	endOfDay bool
+}
+
+func (f *timeFlag) String() string {
+	if f.IsZero() {
+		return ""
+	}
+	return f.Format(time.RFC3339)
+}
+
+func (f *timeFlag) Set(value string) error {
+	if value == "" {
+		f.Time = time.Time{}
+		return nil
+	}
+	t, err := ParseDate(value, time.Now())
+	if err != nil {
+		return err
+	}
+	if f.endOfDay && isAllDay(t) {
+		t = t.AddDate(0, 0, 1)
+	}
+	f.Time = t
+	return nil
+}
+
+func newListCmd() *Command {
+	flags := newFlagSet("list")
+	var statuses statusList
+	var priorities priorityList
+	var tags tagList
+	var project projectFlag
+	since := &timeFlag{}
+	until := &timeFlag{endOfDay: true}
+	flags.Var(&statuses, "status", "only show tasks with these statuses (comma separated, repeatable)")
+	flags.Var(&priorities, "priority", "only show tasks with these priorities (comma separated, repeatable, none for unset)")
+	flags.Var(&project, "project", "only show tasks in this project or its sub-projects")
+	flags.Var(&tags, "tag", "only show tasks with all of these tags (comma separated, repeatable)")
+	dateField := flags.String("date", "created", "date used by -since and -until: created or updated")
+	flags.Var(since, "since", "only show tasks on or after this date")
+	flags.Var(until, "until", "only show tasks on or before this date")
+	grep := flags.String("grep", "", "only show tasks whose description matches this regular expression (case insensitive)")
+	sortKey := flags.String("sort", "id", "sort by: "+sortKeyNames())
+	reverse := flags.Bool("reverse", false, "reverse the sort order")
+	limit := flags.Int("limit", 0, "show at most this many tasks (0 for all)")
+	tree := flags.Bool("tree", false, "show subtasks indented below their parent")
+	recurring := flags.Bool("recurring", false, "only show recurring tasks, with their schedule")
+	output := addOutputFlags(flags, defaultFields)
+
+	return &Command{
+		Name:     "list",
+		Summary:  "List tasks, optionally filtered and sorted.",
+		Flags:    flags,
+		ReadOnly: true,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) > 0 {
+				return usageErrorf("unexpected argument %q", args[0])
+			}
+			if *dateField != "created" && *dateField != "updated" {
+				return usageErrorf("invalid -date %q, use created or updated", *dateField)
+			}
+			if *limit < 0 {
+				return usageErrorf("-limit must not be negative")
+			}
+
+			filter := TodoFilter{
+				Statuses:   statuses,
+				Priorities: priorities,
+				Project:    project.name,
+				Tags:       tags,
+				Recurring:  *recurring,
+				DateField:  *dateField,
+				Since:      since.Time,
+				Until:      until.Time,
+			}
+			if *grep != "" {
+				re, err := regexp.Compile("(?i)" + *grep)
+				if err != nil {
+					return usageErrorf("invalid -grep pattern: %v", err)
+				}
+				filter.Grep = re
+			}
+
+			if *recurring && *output.fields == defaultFields {
+				*output.fields = recurFields
+			}
+
+			todos := ctx.List.Filter(filter)
+			if err := todos.Sort(*sortKey, *reverse); err != nil {
+				return usageErrorf("%v", err)
+			}
+			if *limit > 0 && len(todos) > *limit {
+				todos = todos[:*limit]
+			}
+			if *tree {
+				todos = todos.tree(output.human())
+			}
+
+			return output.render(ctx.Stdout, todos)
+		},
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Helper to build a list with known timestamps
+func sampleTodos() Todos {
+	day := func(d int) time.Time {
+		return time.Date(2026, 3, d, 12, 0, 0, 0, time.Local)
+	}
+	updated := day(20)
+
+	return Todos{
+		{ID: 1, Description: "Write report", Status: "done", CreatedAt: day(1), UpdatedAt: &updated, Project: "work", Tags: []string{"writing"}},
+		{ID: 2, Description: "Buy milk", Status: "todo", CreatedAt: day(5), Priority: PriorityLow, Tags: []string{"errand"}},
+		{ID: 3, Description: "Review REPORT draft", Status: "in-progress", CreatedAt: day(10), Priority: PriorityUrgent, Project: "work.reports", Tags: []string{"review", "writing"}},
+		{ID: 4, Description: "Fix bike", Status: "blocked", CreatedAt: day(15), Priority: PriorityHigh},
+	}
+}
+
+func ids(todos Todos) []int {
+	result := []int{}
+	for _, t := range todos {
+		result = append(result, t.ID)
+	}
+	return result
+}
+
+func equalIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTodosFilter(t *testing.T) {
+	todos := sampleTodos()
+
+	testCases := []struct {
+		name   string
+		filter TodoFilter
+		want   []int
+	}{
+		{"No filter", TodoFilter{}, []int{1, 2, 3, 4}},
+		{"Single status", TodoFilter{Statuses: []Status{"todo"}}, []int{2}},
+		{"Several statuses", TodoFilter{Statuses: []Status{"done", "blocked"}}, []int{1, 4}},
+		{"Since created", TodoFilter{Since: time.Date(2026, 3, 5, 0, 0, 0, 0, time.Local)}, []int{2, 3, 4}},
+		{"Until created", TodoFilter{Until: time.Date(2026, 3, 6, 0, 0, 0, 0, time.Local)}, []int{1, 2}},
+		{"Since updated", TodoFilter{DateField: "updated", Since: time.Date(2026, 3, 16, 0, 0, 0, 0, time.Local)}, []int{1}},
+		{"Grep", TodoFilter{Grep: regexp.MustCompile("(?i)report")}, []int{1, 3}},
+		{"Priority", TodoFilter{Priorities: []Priority{PriorityHigh, PriorityUrgent}}, []int{3, 4}},
+		{"No priority", TodoFilter{Priorities: []Priority{""}}, []int{1}},
+		{"Project with sub-projects", TodoFilter{Project: "work"}, []int{1, 3}},
+		{"All tags", TodoFilter{Tags: []string{"writing", "review"}}, []int{3}},
+		{"Combined", TodoFilter{Statuses: []Status{"done"}, Grep: regexp.MustCompile("(?i)report")}, []int{1}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ids(todos.Filter(tc.filter))
+			if !equalIDs(got, tc.want) {
+				t.Errorf("Expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestTodosSort(t *testing.T) {
+	testCases := []struct {
+		key     string
+		reverse bool
+		want    []int
+	}{
+		{"id", false, []int{1, 2, 3, 4}},
+		{"id", true, []int{4, 3, 2, 1}},
+		{"created", true, []int{4, 3, 2, 1}},
+		{"updated", false, []int{2, 3, 4, 1}},
+		{"status", false, []int{2, 3, 4, 1}},
+		{"priority", false, []int{3, 4, 2, 1}},
+		{"project", false, []int{1, 3, 2, 4}},
+	}
+
+	for _, tc := range testCases {
+		todos := sampleTodos()
+		if err := todos.Sort(tc.key, tc.reverse); err != nil {
+			t.Fatalf("%s: expected no error, got %v", tc.key, err)
+		}
+		if got := ids(todos); !equalIDs(got, tc.want) {
+			t.Errorf("%s (reverse=%v): expected %v, got %v", tc.key, tc.reverse, tc.want, got)
+		}
+	}
+
+	todos := sampleTodos()
+	if err := todos.Sort("colour", false); err == nil {
+		t.Error("Expected error for unknown sort key, got nil")
+	}
+}
+
+func TestAppListFilters(t *testing.T) {
+	app := newTestApp(t)
+	runCmd(t, app, "add", "Write report")
+	runCmd(t, app, "add", "Buy milk")
+	runCmd(t, app, "add", "Review report draft")
+	runCmd(t, app, "mark", "1", "done")
+
+	code, stdout, stderr := runCmd(t, app, "list", "--grep", "REPORT", "--status", "todo,done")
+	if code != ExitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", ExitOK, code, stderr)
+	}
+	if !strings.Contains(stdout, "Write report") || !strings.Contains(stdout, "Review report draft") {
+		t.Errorf("Expected both report tasks, got '%s'", stdout)
+	}
+	if strings.Contains(stdout, "Buy milk") {
+		t.Errorf("Expected 'Buy milk' to be filtered out, got '%s'", stdout)
+	}
+
+	// Sorting and limiting
+	_, stdout, _ = runCmd(t, app, "list", "--sort", "created", "--reverse", "--limit", "2")
+	if strings.Contains(stdout, "Write report") {
+		t.Errorf("Expected oldest task to be cut by limit, got '%s'", stdout)
+	}
+	if strings.Index(stdout, "Review report draft") > strings.Index(stdout, "Buy milk") {
+		t.Errorf("Expected newest task first, got '%s'", stdout)
+	}
+
+	// Date range covering today includes everything
+	today := time.Now().Format("2006-01-02")
+	_, stdout, _ = runCmd(t, app, "list", "--since", today, "--until", today)
+	if !strings.Contains(stdout, "Buy milk") {
+		t.Errorf("Expected tasks created today, got '%s'", stdout)
+	}
+}
+
+func TestAppListInvalidFlags(t *testing.T) {
+	app := newTestApp(t)
+
+	testCases := []struct {
+		name string
+		args []string
+	}{
+		{"Unknown status", []string{"list", "--status", "someday"}},
+		{"Unknown sort key", []string{"list", "--sort", "colour"}},
+		{"Bad date", []string{"list", "--since", "yesterday-ish"}},
+		{"Bad date field", []string{"list", "--date", "due"}},
+		{"Bad regexp", []string{"list", "--grep", "("}},
+		{"Negative limit", []string{"list", "--limit", "-1"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			code, _, _ := runCmd(t, app, tc.args...)
+			if code != ExitUsage {
+				t.Errorf("Expected exit code %d, got %d", ExitUsage, code)
+			}
+		})
+	}
+}
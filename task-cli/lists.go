@@ -0,0 +1,469 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// defaultListName is the list used until another default is chosen.
+const defaultListName = "default"
+
+// dataDir returns the directory holding the named lists:
+// $XDG_DATA_HOME/task, or ~/.local/share/task when XDG_DATA_HOME is unset.
+func dataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "task"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot find the data directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "share", "task"), nil
+}
+
+func listsDir() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "lists"), nil
+}
+
+// checkListName rejects names that cannot be used as a file name.
+func checkListName(name string) error {
+	valid := name != "" && name[0] != '.' && name[0] != '-'
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_.", r) {
+			valid = false
+		}
+	}
+	if !valid {
+		return fmt.Errorf("invalid list name %q, use letters, digits and -_.", name)
+	}
+	return nil
+}
+
+// DefaultList returns the name of the list used when -list is not given,
+// the list setting.
+func DefaultList() (string, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return "", err
+	}
+	return cfg.Get("list"), nil
+}
+
+// ListNames returns the lists stored with backend, sorted by name.
+func ListNames(backend string) ([]string, error) {
+	dir, err := listsDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), storeBackends[backend].ext)
+		if ok && !e.IsDir() && checkListName(name) == nil {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// listExists reports whether the named list has a store file.
+func listExists(backend, name string) (string, bool, error) {
+	path, err := StorePath(backend, "", name)
+	if err != nil {
+		return "", false, err
+	}
+	_, err = os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return path, false, nil
+	}
+	return path, err == nil, err
+}
+
+func newListsCmd() *Command {
+	return &Command{
+		Name:    "lists",
+		Summary: "Show the named lists, marking the current one with *.",
+		Flags:   newFlagSet("lists"),
+		NoStore: true,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) > 0 {
+				return usageErrorf("unexpected argument %q", args[0])
+			}
+
+			current := ctx.App.ListName()
+			names, err := ListNames(ctx.App.StoreBackend())
+			if err != nil {
+				return err
+			}
+			if !slices.Contains(names, current) {
+				names = append(names, current)
+				slices.Sort(names)
+			}
+
+			for _, name := range names {
+				marker := " "
+				if name == current {
+					marker = "*"
+				}
+				fmt.Fprintf(ctx.Stdout, "%s %-20s %s\n", marker, name, listSummary(ctx.App.StoreBackend(), name))
+			}
+			return nil
+		},
+	}
+}
+
+// listSummary describes the size of a list for the lists command.
+func listSummary(backend, name string) string {
+	path, _ := StorePath(backend, "", name)
+	lock, err := LockStore(path, false)
+	if err != nil {
+		return "(in use)"
+	}
+	defer lock.Unlock()
+
+	store, _ := OpenStore(backend, path)
+	list := TodoList{}
+	if err := store.Load(&list); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "empty"
+		}
+		return "(unreadable)"
+	}
+
+	open := 0
+	for _, t := range list.Todos {
+		if !t.Status.Finished() {
+			open++
+		}
+	}
+	return fmt.Sprintf("%d %s, %d open", len(list.Todos), plural(len(list.Todos), "task"), open)
+}
+
+func newListCreateCmd() *Command {
+	return &Command{
+		Name:    "list-create",
+		Args:    "<name>",
+		Summary: "Create a new named list.",
+		Flags:   newFlagSet("list-create"),
+		NoStore: true,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) != 1 {
+				return usageErrorf("expected exactly one list name")
+			}
+			name := args[0]
+			if err := checkListName(name); err != nil {
+				return usageErrorf("%v", err)
+			}
+
+			path, exists, err := listExists(ctx.App.StoreBackend(), name)
+			if err != nil {
+				return err
+			}
+			if exists {
+				return fmt.Errorf("list %q already exists", name)
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return err
+			}
+			if gitEnabled(ctx.App) {
+				if err := checkGitStore(ctx.App, path); err != nil {
+					return err
+				}
+			}
+
+			lock, err := LockStore(path, true)
+			if err != nil {
+				return err
+			}
+			defer lock.Unlock()
+
+			store, _ := OpenStore(ctx.App.StoreBackend(), path)
+			if err := store.Save(TodoList{NextID: 1}); err != nil {
+				return err
+			}
+			if err := commitGitLists(ctx.App, append([]string{"list-create"}, args...), path); err != nil {
+				return err
+			}
+			fmt.Fprintf(ctx.Stdout, "Created list %s\n", name)
+			return nil
+		},
+	}
+}
+
+func newListRenameCmd() *Command {
+	return &Command{
+		Name:    "list-rename",
+		Args:    "<old> <new>",
+		Summary: "Rename a named list.",
+		Flags:   newFlagSet("list-rename"),
+		NoStore: true,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) != 2 {
+				return usageErrorf("expected the old and the new list name")
+			}
+			oldName, newName := args[0], args[1]
+			if err := checkListName(newName); err != nil {
+				return usageErrorf("%v", err)
+			}
+
+			oldPath, exists, err := listExists(ctx.App.StoreBackend(), oldName)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return fmt.Errorf("list %q does not exist", oldName)
+			}
+			newPath, exists, err := listExists(ctx.App.StoreBackend(), newName)
+			if err != nil {
+				return err
+			}
+			if exists {
+				return fmt.Errorf("list %q already exists", newName)
+			}
+			if gitEnabled(ctx.App) {
+				if err := checkGitStore(ctx.App, newPath); err != nil {
+					return err
+				}
+			}
+
+			lock, err := LockStore(oldPath, true)
+			if err != nil {
+				return err
+			}
+			defer os.Remove(lockPath(oldPath))
+			defer lock.Unlock()
+
+			if err := os.Rename(oldPath, newPath); err != nil {
+				return err
+			}
+			for _, companion := range []func(string) string{journalPath, archivePath, syncStatePath} {
+				if err := os.Rename(companion(oldPath), companion(newPath)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+					return err
+				}
+			}
+
+			if ctx.App.Config.Get("list") == oldName {
+				if err := ctx.App.Config.Set("list", newName); err != nil {
+					return err
+				}
+			}
+			if err := commitGitLists(ctx.App, append([]string{"list-rename"}, args...), newPath, oldPath); err != nil {
+				return err
+			}
+			fmt.Fprintf(ctx.Stdout, "Renamed list %s to %s\n", oldName, newName)
+			return nil
+		},
+	}
+}
+
+func newListDefaultCmd() *Command {
+	return &Command{
+		Name:    "list-default",
+		Args:    "[name]",
+		Summary: "Show or set the list used when -list is not given (the list setting).",
+		Flags:   newFlagSet("list-default"),
+		NoStore: true,
+		Run: func(ctx *Context, args []string) error {
+			switch len(args) {
+			case 0:
+				fmt.Fprintln(ctx.Stdout, ctx.App.Config.Get("list"))
+				return nil
+			case 1:
+				if err := ctx.App.Config.Set("list", args[0]); err != nil {
+					return err
+				}
+				fmt.Fprintf(ctx.Stdout, "Default list is now %s\n", args[0])
+				return nil
+			default:
+				return usageErrorf("expected at most one list name")
+			}
+		},
+	}
+}
+
+func newMoveCmd() *Command {
+	flags := newFlagSet("move")
+	to := flags.String("to", "", "name of the list to move the task to")
+
+	return &Command{
+		Name:    "move",
+		Args:    "<id>",
+		Summary: "Move a task to another list. It gets a new ID there.",
+		Flags:   flags,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) != 1 {
+				return usageErrorf("expected exactly one task id")
+			}
+			if *to == "" {
+				return usageErrorf("-to is required")
+			}
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			index, err := ctx.List.IndexOf(id)
+			if err != nil {
+				return err
+			}
+
+			srcPath, err := ctx.App.StorePath()
+			if err != nil {
+				return err
+			}
+			dstPath, exists, err := listExists(ctx.App.StoreBackend(), *to)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return fmt.Errorf("list %q does not exist, create it with list-create", *to)
+			}
+			if dstPath == srcPath {
+				return fmt.Errorf("task %d is already in list %s", id, *to)
+			}
+
+			backend := ctx.App.StoreBackend()
+			command := commandLine(append([]string{"move"}, args...))
+			moved, err := moveTodo(backend, dstPath, ctx.List.Todos[index], command)
+			if err != nil {
+				return err
+			}
+			ctx.rollbacks = append(ctx.rollbacks, func() error {
+				return replayMove(backend, moved, true, command+" (rolled back)")
+			})
+			ctx.wroteList(srcPath, dstPath)
+			ctx.List.delete(id)
+			if ctx.moved == nil {
+				ctx.moved = map[int]movedTodo{}
+			}
+			ctx.moved[id] = moved
+			fmt.Fprintf(ctx.Stdout, "Moved task %d to list %s as task %d\n", id, *to, moved.Todo.ID)
+			return nil
+		},
+	}
+}
+
+// moveTodo adds t to the list stored at path and returns the copy added
+// there under its new ID. Links to tasks of the old list are dropped.
+func moveTodo(backend, path string, t Todo, command string) (movedTodo, error) {
+	lock, err := LockStore(path, true)
+	if err != nil {
+		return movedTodo{}, err
+	}
+	defer lock.Unlock()
+
+	store, _ := OpenStore(backend, path)
+	list := TodoList{}
+	if err := store.Load(&list); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return movedTodo{}, err
+	}
+	journal, err := OpenJournal(journalPath(path))
+	if err != nil {
+		return movedTodo{}, err
+	}
+	before := cloneTodos(list.Todos)
+
+	moved := list.add(t.Description)
+	id := moved.ID
+	*moved = cloneTodo(t)
+	moved.ID = id
+	moved.ParentID = 0
+	moved.BlockedBy = nil
+	copied := movedTodo{Path: path, Todo: cloneTodo(*moved)}
+
+	if err := store.Save(list); err != nil {
+		return movedTodo{}, err
+	}
+	journal.Record(command, before, list.Todos, nil, time.Now())
+	return copied, journal.Save()
+}
+
+// replayMove removes the copy m from the list it was moved to, or adds it
+// back under its ID, recording the change in the journal of that list. It
+// fails like undo if the copy was changed there since.
+func replayMove(backend string, m movedTodo, remove bool, command string) error {
+	lock, err := LockStore(m.Path, true)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	store, _ := OpenStore(backend, m.Path)
+	list := TodoList{}
+	if err := store.Load(&list); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	journal, err := OpenJournal(journalPath(m.Path))
+	if err != nil {
+		return err
+	}
+	before := cloneTodos(list.Todos)
+
+	change := Change{ID: m.Todo.ID, After: &m.Todo}
+	if remove {
+		change = Change{ID: m.Todo.ID, Before: &m.Todo}
+	}
+	if err := list.applyChanges([]Change{change}, false); err != nil {
+		return fmt.Errorf("moved copy in %s: %w", m.Path, err)
+	}
+	if err := store.Save(list); err != nil {
+		return err
+	}
+	journal.Record(command, before, list.Todos, nil, time.Now())
+	return journal.Save()
+}
+
+// syncMoves removes the copies of the tasks moved by entry from the lists
+// they were moved to when it is undone, and adds them back when it is
+// redone. A failure to save the list afterwards reverts this again.
+func (ctx *Context) syncMoves(entry JournalEntry, undo bool) error {
+	srcPath, err := ctx.App.StorePath()
+	if err != nil {
+		return err
+	}
+	backend := ctx.App.StoreBackend()
+	verb, back := "redo", "undo"
+	if undo {
+		verb, back = back, verb
+	}
+	for _, c := range entry.Changes {
+		if c.MovedTo == nil {
+			continue
+		}
+		m := *c.MovedTo
+		if err := replayMove(backend, m, undo, verb+" "+entry.Command); err != nil {
+			return err
+		}
+		ctx.rollbacks = append(ctx.rollbacks, func() error {
+			return replayMove(backend, m, !undo, back+" "+entry.Command+" (rolled back)")
+		})
+		ctx.wroteList(srcPath, m.Path)
+	}
+	return nil
+}
+
+// wroteList adds the list at path, written besides the one at srcPath, to
+// the git commit when both share the repository.
+func (ctx *Context) wroteList(srcPath, path string) {
+	if filepath.Dir(path) == filepath.Dir(srcPath) {
+		ctx.gitFiles = append(ctx.gitFiles, filepath.Base(path))
+	}
+}
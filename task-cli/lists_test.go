@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Helper to create an app working on named lists in a temporary data directory
+func newListsApp(t *testing.T) (*App, string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	return NewApp(&bytes.Buffer{}, &bytes.Buffer{}), filepath.Join(dir, "task", "lists")
+}
+
+func TestCheckListName(t *testing.T) {
+	for _, name := range []string{"work", "home-2", "team.ops", "side_project"} {
+		if err := checkListName(name); err != nil {
+			t.Errorf("%s: expected no error, got %v", name, err)
+		}
+	}
+	for _, name := range []string{"", ".hidden", "-x", "a/b", "with space"} {
+		if err := checkListName(name); err == nil {
+			t.Errorf("Expected error for %q, got nil", name)
+		}
+	}
+}
+
+func TestAppNamedLists(t *testing.T) {
+	app, dir := newListsApp(t)
+
+	runCmd(t, app, "add", "Personal task")
+	if _, err := os.Stat(filepath.Join(dir, "default.json")); err != nil {
+		t.Fatalf("Expected the default list to be created, got %v", err)
+	}
+
+	if code, _, stderr := runCmd(t, app, "list-create", "work"); code != ExitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", ExitOK, code, stderr)
+	}
+	if code, _, _ := runCmd(t, app, "list-create", "work"); code != ExitError {
+		t.Errorf("Expected exit code %d for an existing list, got %d", ExitError, code)
+	}
+	if code, _, _ := runCmd(t, app, "list-create", "../evil"); code != ExitUsage {
+		t.Errorf("Expected exit code %d for an invalid name, got %d", ExitUsage, code)
+	}
+
+	runCmd(t, app, "--list", "work", "add", "Team task")
+	app.List = ""
+
+	_, stdout, _ := runCmd(t, app, "list", "--output", "csv", "--fields", "id,description")
+	if stdout != "id,description\n1,Personal task\n" {
+		t.Errorf("Expected only the personal task, got %q", stdout)
+	}
+
+	// Changing the default list changes where commands without -list go
+	runCmd(t, app, "list-default", "work")
+	_, stdout, _ = runCmd(t, app, "list", "--output", "csv", "--fields", "id,description")
+	if stdout != "id,description\n1,Team task\n" {
+		t.Errorf("Expected the work list, got %q", stdout)
+	}
+
+	_, stdout, _ = runCmd(t, app, "lists")
+	lines := strings.Split(strings.TrimRight(stdout, "\n"), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "  default") || !strings.HasPrefix(lines[1], "* work") ||
+		!strings.Contains(lines[1], "1 task, 1 open") {
+		t.Errorf("Unexpected lists output: %q", stdout)
+	}
+
+	if code, _, stderr := runCmd(t, app, "list-rename", "work", "team"); code != ExitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", ExitOK, code, stderr)
+	}
+	if _, stdout, _ = runCmd(t, app, "list-default"); stdout != "team\n" {
+		t.Errorf("Expected the default to follow the rename, got %q", stdout)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "team.json.journal")); err != nil {
+		t.Errorf("Expected the journal to be renamed, got %v", err)
+	}
+
+	app.File = filepath.Join(t.TempDir(), "todos.json")
+	app.List = "team"
+	if code, _, _ := runCmd(t, app, "list"); code != ExitUsage {
+		t.Errorf("Expected exit code %d combining -file and -list, got %d", ExitUsage, code)
+	}
+}
+
+func TestAppRenameDefaultList(t *testing.T) {
+	app, _ := newListsApp(t)
+	runCmd(t, app, "add", "Personal task")
+
+	// The implicit default list is renamed along with the setting
+	if code, _, stderr := runCmd(t, app, "list-rename", "default", "home"); code != ExitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", ExitOK, code, stderr)
+	}
+	if _, stdout, _ := runCmd(t, app, "list-default"); stdout != "home\n" {
+		t.Errorf("Expected the default to follow the rename, got %q", stdout)
+	}
+	if _, stdout, _ := runCmd(t, app, "list", "--output", "csv", "--fields", "id,description"); stdout != "id,description\n1,Personal task\n" {
+		t.Errorf("Expected the renamed list used by default, got %q", stdout)
+	}
+}
+
+func TestAppMove(t *testing.T) {
+	app, _ := newListsApp(t)
+	runCmd(t, app, "list-create", "home")
+	runCmd(t, app, "--list", "home", "add", "Existing")
+	app.List = ""
+
+	runCmd(t, app, "add", "--tag", "errand", "Buy milk")
+	runCmd(t, app, "add", "--parent", "1", "Check fridge")
+	runCmd(t, app, "add", "--blocked-by", "2", "Cook")
+
+	if code, _, _ := runCmd(t, app, "move", "1", "--to", "nowhere"); code != ExitError {
+		t.Errorf("Expected exit code %d for a missing list, got %d", ExitError, code)
+	}
+	if code, _, _ := runCmd(t, app, "move", "1"); code != ExitUsage {
+		t.Errorf("Expected exit code %d without -to, got %d", ExitUsage, code)
+	}
+
+	code, stdout, stderr := runCmd(t, app, "move", "2", "--to", "home")
+	if code != ExitOK || stdout != "Moved task 2 to list home as task 2\n" {
+		t.Fatalf("Unexpected move: %d %q %s", code, stdout, stderr)
+	}
+
+	todos := loadTodos(t, app)
+	if !equalIDs(ids(todos), []int{1, 3}) || len(todos[1].BlockedBy) != 0 {
+		t.Errorf("Expected task 2 gone and unlinked, got %+v", todos)
+	}
+
+	app.List = "home"
+	moved := loadTodos(t, app)
+	if len(moved) != 2 || moved[1].Description != "Check fridge" || moved[1].ParentID != 0 {
+		t.Errorf("Unexpected destination list: %+v", moved)
+	}
+
+	// The move can be undone in the destination list
+	runCmd(t, app, "undo")
+	if moved = loadTodos(t, app); len(moved) != 1 {
+		t.Errorf("Expected the move undone in home, got %+v", moved)
+	}
+}
+
+func TestAppMoveUndoInSource(t *testing.T) {
+	app, _ := newListsApp(t)
+	runCmd(t, app, "list-create", "home")
+	runCmd(t, app, "add", "Buy milk")
+	runCmd(t, app, "move", "1", "--to", "home")
+
+	// Undoing the move in the source list takes the copy out of home
+	if code, _, stderr := runCmd(t, app, "undo"); code != ExitOK {
+		t.Fatalf("Expected the move undone, got %d %s", code, stderr)
+	}
+	if todos := loadTodos(t, app); !equalIDs(ids(todos), []int{1}) {
+		t.Errorf("Expected the task back in the source list, got %+v", todos)
+	}
+	app.List = "home"
+	if todos := loadTodos(t, app); len(todos) != 0 {
+		t.Errorf("Expected the copy removed from home, got %+v", todos)
+	}
+
+	// Redo moves it again under the same ID
+	app.List = ""
+	runCmd(t, app, "redo")
+	if todos := loadTodos(t, app); len(todos) != 0 {
+		t.Errorf("Expected the task moved again, got %+v", todos)
+	}
+	app.List = "home"
+	if todos := loadTodos(t, app); !equalIDs(ids(todos), []int{1}) || todos[0].Description != "Buy milk" {
+		t.Errorf("Expected the copy back in home, got %+v", todos)
+	}
+
+	// A copy changed since is left alone, and so is the source list
+	runCmd(t, app, "mark", "1", "done")
+	app.List = ""
+	code, _, stderr := runCmd(t, app, "undo")
+	if code != ExitError || !strings.Contains(stderr, "was changed since") {
+		t.Errorf("Expected undo refused, got %d %q", code, stderr)
+	}
+	if todos := loadTodos(t, app); len(todos) != 0 {
+		t.Errorf("Expected the source list untouched, got %+v", todos)
+	}
+}
+
+func TestMoveRollBack(t *testing.T) {
+	app, _ := newListsApp(t)
+	runCmd(t, app, "list-create", "home")
+	app.List = "home"
+	path, _ := app.StorePath()
+
+	moved, err := moveTodo("json", path, Todo{Description: "Buy milk", Status: StatusTodo}, "move 1 --to home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := &Context{App: app, rollbacks: []func() error{func() error {
+		return replayMove("json", moved, true, "move 1 --to home (rolled back)")
+	}}}
+
+	// A source list that cannot be saved takes the copy out again
+	if err := ctx.rollBack(os.ErrPermission); !errors.Is(err, os.ErrPermission) {
+		t.Errorf("Expected the save error kept, got %v", err)
+	}
+	if todos := loadTodos(t, app); len(todos) != 0 {
+		t.Errorf("Expected the copy removed, got %+v", todos)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrLocked is returned when another process holds the store lock.
+var ErrLocked = errors.New("store is locked by another process")
+
+// FileLock is an advisory lock on a file next to the store. Exclusive locks
+// are taken by commands that write; readers share the lock.
+type FileLock struct {
+	file *os.File
+}
+
+// lockPath returns the lock file guarding the store at storePath.
+func lockPath(storePath string) string {
+	return storePath + ".lock"
+}
+
+// LockStore locks the store at storePath without blocking.
+func LockStore(storePath string, exclusive bool) (*FileLock, error) {
+	file, err := os.OpenFile(lockPath(storePath), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lockFile(file, exclusive); err != nil {
+		file.Close()
+		if errors.Is(err, ErrLocked) {
+			return nil, fmt.Errorf("%w: %s is in use by another task command, try again once it has finished", ErrLocked, storePath)
+		}
+		return nil, err
+	}
+
+	return &FileLock{file: file}, nil
+}
+
+func (l *FileLock) Unlock() error {
+	if err := unlockFile(l.file); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
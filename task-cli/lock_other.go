@@ -0,0 +1,18 @@
+//go:build !(darwin || dragonfly || freebsd || illumos || linux || netbsd || openbsd || windows)
+
+package main
+
+import "os"
+
+// Platforms without flock or LockFileEx run unlocked.
+func lockFile(file *os.File, exclusive bool) error {
+	return nil
+}
+
+func unlockFile(file *os.File) error {
+	return nil
+}
+
+func syncDir(dir string) error {
+	return nil
+}
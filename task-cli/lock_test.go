@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func skipWithoutLocking(t *testing.T) {
+	switch runtime.GOOS {
+	case "darwin", "dragonfly", "freebsd", "illumos", "linux", "netbsd", "openbsd", "windows":
+	default:
+		t.Skip("file locking is not supported on " + runtime.GOOS)
+	}
+}
+
+func TestLockStoreExclusive(t *testing.T) {
+	skipWithoutLocking(t)
+	storePath := filepath.Join(t.TempDir(), "todos.json")
+
+	lock, err := LockStore(storePath, true)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// A second exclusive or shared lock must fail while the first is held
+	if _, err := LockStore(storePath, true); !errors.Is(err, ErrLocked) {
+		t.Errorf("Expected ErrLocked for second exclusive lock, got %v", err)
+	}
+	if _, err := LockStore(storePath, false); !errors.Is(err, ErrLocked) {
+		t.Errorf("Expected ErrLocked for shared lock, got %v", err)
+	}
+
+	if err := lock.Unlock(); err != nil {
+		t.Fatalf("Expected no error unlocking, got %v", err)
+	}
+
+	// Once released the lock can be taken again
+	lock, err = LockStore(storePath, true)
+	if err != nil {
+		t.Fatalf("Expected no error after unlock, got %v", err)
+	}
+	lock.Unlock()
+}
+
+func TestLockStoreShared(t *testing.T) {
+	skipWithoutLocking(t)
+	storePath := filepath.Join(t.TempDir(), "todos.json")
+
+	first, err := LockStore(storePath, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer first.Unlock()
+
+	// Readers share the lock
+	second, err := LockStore(storePath, false)
+	if err != nil {
+		t.Fatalf("Expected second shared lock to succeed, got %v", err)
+	}
+	defer second.Unlock()
+
+	// A writer has to wait for the readers
+	if _, err := LockStore(storePath, true); !errors.Is(err, ErrLocked) {
+		t.Errorf("Expected ErrLocked for exclusive lock, got %v", err)
+	}
+}
+
+func TestAppFailsWhileLocked(t *testing.T) {
+	skipWithoutLocking(t)
+	app := newTestApp(t)
+	runCmd(t, app, "add", "Task 1")
+
+	lock, err := LockStore(app.File, true)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	code, _, stderr := runCmd(t, app, "add", "Task 2")
+	if code != ExitError {
+		t.Errorf("Expected exit code %d, got %d", ExitError, code)
+	}
+	if !strings.Contains(stderr, "in use by another task command") {
+		t.Errorf("Expected lock error on stderr, got '%s'", stderr)
+	}
+
+	lock.Unlock()
+
+	// The blocked add left the store untouched and works once unlocked
+	if todos := loadTodos(t, app); len(todos) != 1 {
+		t.Errorf("Expected 1 todo, got %d", len(todos))
+	}
+	if code, _, _ := runCmd(t, app, "add", "Task 2"); code != ExitOK {
+		t.Errorf("Expected exit code %d after unlock, got %d", ExitOK, code)
+	}
+}
@@ -1,12 +1,8 @@
 package main
 
-func main() {
-	todos := Todos{}
-	Storage := NewStorage[Todos]("first-todos.json")
-	Storage.Load(&todos)
-	cmdFlags := NewCmdFlags()
-	cmdFlags.Execute(&todos)
+import "os"
 
-	todos.Print()
-	Storage.Save(todos)
+func main() {
+	app := NewApp(os.Stdout, os.Stderr)
+	os.Exit(app.Run(os.Args[1:]))
 }
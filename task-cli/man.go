@@ -0,0 +1,113 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func newManCmd() *Command {
+	return &Command{
+		Name:    "man",
+		Summary: "Print the manual page in roff, e.g. task man | man -l -.",
+		Flags:   newFlagSet("man"),
+		NoStore: true,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) > 0 {
+				return usageErrorf("expected no arguments")
+			}
+			ctx.App.writeMan(ctx.Stdout)
+			return nil
+		},
+	}
+}
+
+// writeMan writes the manual page of task to w, generated from the commands
+// and settings like the usage.
+func (app *App) writeMan(w io.Writer) {
+	fmt.Fprintln(w, `.TH TASK 1 "" "task" "User Commands"`)
+	fmt.Fprintln(w, ".SH NAME")
+	fmt.Fprintln(w, `task \- manage tasks from the command line`)
+	fmt.Fprintln(w, ".SH SYNOPSIS")
+	fmt.Fprintln(w, `\fBtask\fR [\fIglobal flags\fR] \fIcommand\fR [\fIflags\fR] [\fIargs\fR]`)
+	fmt.Fprintln(w, ".SH DESCRIPTION")
+	fmt.Fprintln(w, "task keeps lists of tasks with statuses, priorities, due dates, tags, projects, dependencies and tracked time.")
+	fmt.Fprintln(w, "Flags and arguments of a command can be given in any order.")
+	fmt.Fprintln(w, "Task IDs can be given as lists and ranges such as 1,4-6.")
+
+	fmt.Fprintln(w, ".SH GLOBAL FLAGS")
+	writeManFlags(w, app.globals)
+
+	fmt.Fprintln(w, ".SH COMMANDS")
+	for _, cmd := range app.Commands {
+		if cmd.Hidden {
+			continue
+		}
+		synopsis := `\fB` + cmd.Name + `\fR`
+		if hasFlags(cmd.Flags) {
+			synopsis += " [flags]"
+		}
+		if cmd.Args != "" {
+			synopsis += " " + roffEscape(cmd.Args)
+		}
+		fmt.Fprintf(w, ".SS %s\n%s\n", synopsis, roffEscape(cmd.Summary))
+		if hasFlags(cmd.Flags) {
+			fmt.Fprintln(w, ".RS")
+			writeManFlags(w, cmd.Flags)
+			fmt.Fprintln(w, ".RE")
+		}
+	}
+
+	fmt.Fprintln(w, ".SH ENVIRONMENT")
+	for _, s := range settings {
+		fmt.Fprintf(w, ".TP\n.B %s\n%s", envName(s.name), roffEscape(s.summary))
+		if s.def != "" {
+			fmt.Fprintf(w, " (default %s)", roffEscape(s.def))
+		}
+		fmt.Fprintln(w, ".")
+	}
+	fmt.Fprintln(w, ".TP\n.B VISUAL\\fR, \\fBEDITOR\nEditor used by edit and note.")
+	fmt.Fprintln(w, ".TP\n.B XDG_DATA_HOME\\fR, \\fBXDG_CONFIG_HOME\nWhere the lists and the config file are kept.")
+	fmt.Fprintln(w, ".TP\n.B NO_COLOR\nDisables colours when set.")
+
+	fmt.Fprintln(w, ".SH FILES")
+	fmt.Fprintln(w, `.TP
+.I $XDG_CONFIG_HOME/task/config.yaml
+Settings written by config set (default ~/.config/task/config.yaml).
+The environment and global flags take precedence.
+.TP
+.I $XDG_DATA_HOME/task/lists/
+The named lists (default ~/.local/share/task/lists/), each with its .journal and .archive.`)
+
+	fmt.Fprintln(w, ".SH EXIT STATUS")
+	fmt.Fprintf(w, ".TP\n%d\nSuccess.\n", ExitOK)
+	fmt.Fprintf(w, ".TP\n%d\nThe command failed.\n", ExitError)
+	fmt.Fprintf(w, ".TP\n%d\nThe command was invoked with bad flags or arguments.\n", ExitUsage)
+}
+
+// writeManFlags writes the flags of fs as a tagged paragraph each.
+func writeManFlags(w io.Writer, fs *flag.FlagSet) {
+	fs.VisitAll(func(f *flag.Flag) {
+		tag := `\fB\-\-` + roffEscape(f.Name) + `\fR`
+		if name, _ := flag.UnquoteUsage(f); name != "" {
+			tag += ` \fI` + roffEscape(name) + `\fR`
+		}
+		usage := roffEscape(f.Usage)
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" {
+			usage += ` (default ` + roffEscape(f.DefValue) + `)`
+		}
+		fmt.Fprintf(w, ".TP\n%s\n%s\n", tag, usage)
+	})
+}
+
+// roffEscape escapes text for roff: backslashes, minus signs and control
+// characters at the start of a line.
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAppMan(t *testing.T) {
+	app := newTestApp(t)
+	code, stdout, _ := runCmd(t, app, "man")
+	if code != 0 || !strings.HasPrefix(stdout, ".TH TASK 1") {
+		t.Fatalf("Expected a man page, got %d %q", code, stdout)
+	}
+	for _, cmd := range app.Commands {
+		if strings.Contains(stdout, ".SS \\fB"+cmd.Name+"\\fR") == cmd.Hidden {
+			t.Errorf("Expected command %s in the man page unless hidden", cmd.Name)
+		}
+	}
+	for _, want := range []string{`\fB\-\-blocked\-by\fR`, ".B TASK_DATE_FORMAT", ".SH EXIT STATUS"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected %q in the man page", want)
+		}
+	}
+}
+
+func TestRoffEscape(t *testing.T) {
+	tests := map[string]string{
+		"in-progress": `in\-progress`,
+		`C:\tasks`:    `C:\etasks`,
+		".hidden":     `\&.hidden`,
+	}
+	for in, want := range tests {
+		if got := roffEscape(in); got != want {
+			t.Errorf("roffEscape(%q): expected %q, got %q", in, want, got)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// mergeLists merges the changes ours and theirs made to base, task by task.
+// Tasks added on both sides under the same ID keep it on their side and
+// get a new ID on ours; renumbered maps the old IDs to the new. The notes
+// describe renumbered tasks and conflicts.
+func mergeLists(base, ours, theirs TodoList) (merged TodoList, renumbered map[int]int, notes []string) {
+	ours = TodoList{NextID: ours.NextID, Todos: cloneTodos(ours.Todos)}
+	merged = TodoList{NextID: max(base.NextID, ours.NextID, theirs.NextID)}
+
+	renumbered = map[int]int{}
+	for _, t := range ours.Todos {
+		if _, err := base.IndexOf(t.ID); err == nil {
+			continue
+		}
+		if i, err := theirs.IndexOf(t.ID); err == nil && !sameTodo(theirs.Todos[i], t) {
+			renumbered[t.ID] = merged.NextID
+			notes = append(notes, fmt.Sprintf("Renumbered task %d to %d, the remote added a task %d too", t.ID, merged.NextID, t.ID))
+			merged.NextID++
+		}
+	}
+	if len(renumbered) > 0 {
+		ours.renumber(renumbered)
+	}
+
+	var ids []int
+	for _, todos := range []Todos{base.Todos, ours.Todos, theirs.Todos} {
+		for _, t := range todos {
+			ids = append(ids, t.ID)
+		}
+	}
+	slices.Sort(ids)
+	for _, id := range slices.Compact(ids) {
+		b, o, t := base.lookup(id), ours.lookup(id), theirs.lookup(id)
+		switch {
+		case o == nil && t == nil:
+		case o == nil && b == nil:
+			merged.Todos = append(merged.Todos, *t)
+		case t == nil && b == nil:
+			merged.Todos = append(merged.Todos, *o)
+		case o == nil:
+			if !sameTodo(*t, *b) {
+				merged.Todos = append(merged.Todos, *t)
+				notes = append(notes, fmt.Sprintf("Conflict: task %d was deleted here but changed on the remote, kept it", id))
+			}
+		case t == nil:
+			if !sameTodo(*o, *b) {
+				merged.Todos = append(merged.Todos, *o)
+				notes = append(notes, fmt.Sprintf("Conflict: task %d was deleted on the remote but changed here, kept it", id))
+			}
+		case b == nil:
+			// Added on both sides alike
+			merged.Todos = append(merged.Todos, *o)
+		default:
+			todo, conflicts := mergeTodo(*b, *o, *t)
+			merged.Todos = append(merged.Todos, todo)
+			if len(conflicts) > 0 {
+				side := "local"
+				if lastChange(*t).After(lastChange(*o)) {
+					side = "remote"
+				}
+				notes = append(notes, fmt.Sprintf("Conflict: task %d changed on both sides, kept the %s %s", id, side, strings.Join(conflicts, ", ")))
+			}
+		}
+	}
+
+	merged.dropDanglingLinks()
+	return merged, renumbered, notes
+}
+
+func (l *TodoList) lookup(id int) *Todo {
+	i, err := l.IndexOf(id)
+	if err != nil {
+		return nil
+	}
+	return &l.Todos[i]
+}
+
+// renumber moves tasks to new IDs, along with the links pointing at them.
+func (l *TodoList) renumber(ids map[int]int) {
+	for i := range l.Todos {
+		l.Todos[i].renumber(ids)
+	}
+	slices.SortStableFunc(l.Todos, func(a, b Todo) int { return a.ID - b.ID })
+}
+
+// renumber moves t and its links to the new IDs in ids.
+func (t *Todo) renumber(ids map[int]int) {
+	moved := func(id int) int {
+		if to, ok := ids[id]; ok {
+			return to
+		}
+		return id
+	}
+	t.ID, t.ParentID = moved(t.ID), moved(t.ParentID)
+	for j, b := range t.BlockedBy {
+		t.BlockedBy[j] = moved(b)
+	}
+}
+
+// renumber moves the changes recorded for the tasks in ids to their new
+// IDs, so undo keeps working on the tasks a merge renumbered.
+func (j *Journal) renumber(ids map[int]int) {
+	for i := range j.Entries {
+		for k := range j.Entries[i].Changes {
+			c := &j.Entries[i].Changes[k]
+			if to, ok := ids[c.ID]; ok {
+				c.ID = to
+			}
+			for _, t := range []*Todo{c.Before, c.After} {
+				if t != nil {
+					t.renumber(ids)
+				}
+			}
+		}
+	}
+	j.dirty = true
+}
+
+// renumber moves the links of the tasks in ids to their new IDs, so the
+// server sync keeps pairing them with their todos. It reports whether any
+// link moved.
+func (s *SyncState) renumber(ids map[int]int) bool {
+	moved := false
+	for i, link := range s.Links {
+		if to, ok := ids[link.ID]; ok {
+			s.Links[i].ID = to
+			moved = true
+			s.dirty = true
+		}
+	}
+	return moved
+}
+
+// dropDanglingLinks removes parents and blockers that are not in the list,
+// e.g. because one side deleted a task the other side linked to.
+func (l *TodoList) dropDanglingLinks() {
+	for i := range l.Todos {
+		t := &l.Todos[i]
+		if l.lookup(t.ParentID) == nil {
+			t.ParentID = 0
+		}
+		t.BlockedBy = slices.DeleteFunc(t.BlockedBy, func(b int) bool { return l.lookup(b) == nil })
+		if len(t.BlockedBy) == 0 {
+			t.BlockedBy = nil
+		}
+	}
+}
+
+// mergeTodo merges the changes to a single task field by field. Fields
+// changed on both sides come from the side changed last; their names are
+// returned as conflicts.
+func mergeTodo(base, ours, theirs Todo) (Todo, []string) {
+	switch {
+	case sameTodo(ours, base):
+		return theirs, nil
+	case sameTodo(theirs, base), sameTodo(ours, theirs):
+		return ours, nil
+	}
+
+	b, o, t := todoFields(base), todoFields(ours), todoFields(theirs)
+	theirsWins := lastChange(theirs).After(lastChange(ours))
+	merged := map[string]json.RawMessage{}
+	var conflicts []string
+	for _, key := range sortedKeys(mergeKeys(b, o, t)) {
+		value := o[key]
+		switch {
+		case bytes.Equal(o[key], b[key]):
+			value = t[key]
+		case bytes.Equal(t[key], b[key]), bytes.Equal(o[key], t[key]):
+		case key == "updatedAt":
+			// Always changed by both, see below
+		default:
+			conflicts = append(conflicts, key)
+			if theirsWins {
+				value = t[key]
+			}
+		}
+		if value != nil {
+			merged[key] = value
+		}
+	}
+
+	var todo Todo
+	data, _ := json.Marshal(merged)
+	if err := json.Unmarshal(data, &todo); err != nil {
+		return ours, []string{"all fields"}
+	}
+	if theirsWins {
+		todo.UpdatedAt = theirs.UpdatedAt
+	} else {
+		todo.UpdatedAt = ours.UpdatedAt
+	}
+	return todo, conflicts
+}
+
+func todoFields(t Todo) map[string]json.RawMessage {
+	fields := map[string]json.RawMessage{}
+	data, _ := json.Marshal(t)
+	json.Unmarshal(data, &fields)
+	return fields
+}
+
+func mergeKeys(fields ...map[string]json.RawMessage) map[string]bool {
+	keys := map[string]bool{}
+	for _, f := range fields {
+		for k := range f {
+			keys[k] = true
+		}
+	}
+	return keys
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Helper to build a list from descriptions, IDs counting from 1
+func listOf(descriptions ...string) TodoList {
+	created := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	list := TodoList{NextID: 1}
+	for _, d := range descriptions {
+		t := list.add(d)
+		t.CreatedAt = created
+	}
+	return list
+}
+
+func TestMergeListsByTask(t *testing.T) {
+	base := listOf("Write docs", "Fix bug", "Review")
+	ours := listOf("Write docs", "Fix bug", "Review")
+	theirs := listOf("Write docs", "Fix bug", "Review")
+
+	ours.Todos[0].Description = "Write the docs"
+	ours.Todos.delete(3)
+	theirs.Todos[1].Status = StatusDone
+	theirs.Todos[1].BlockedBy = []int{3}
+
+	merged, _, notes := mergeLists(base, ours, theirs)
+	if len(notes) != 0 {
+		t.Errorf("Expected no conflicts, got %q", notes)
+	}
+	if len(merged.Todos) != 2 || merged.Todos[0].Description != "Write the docs" || merged.Todos[1].Status != StatusDone {
+		t.Fatalf("Unexpected merge: %+v", merged.Todos)
+	}
+	if merged.Todos[1].BlockedBy != nil {
+		t.Errorf("Expected the link to the deleted task dropped, got %v", merged.Todos[1].BlockedBy)
+	}
+}
+
+func TestMergeListsRenumbersAddedTasks(t *testing.T) {
+	base := listOf("Write docs")
+	ours := listOf("Write docs", "Ours")
+	ours.Todos[0].BlockedBy = []int{2}
+	theirs := listOf("Write docs", "Theirs")
+
+	merged, renumbered, notes := mergeLists(base, ours, theirs)
+	if len(renumbered) != 1 || renumbered[2] != 3 {
+		t.Errorf("Expected task 2 renumbered to 3, got %v", renumbered)
+	}
+	if len(merged.Todos) != 3 || merged.Todos[1].Description != "Theirs" || merged.Todos[2].Description != "Ours" {
+		t.Fatalf("Expected the local task moved after the remote one, got %+v", merged.Todos)
+	}
+	if merged.Todos[0].BlockedBy[0] != 3 || merged.NextID != 4 {
+		t.Errorf("Expected links and NextID to follow, got %v and %d", merged.Todos[0].BlockedBy, merged.NextID)
+	}
+	if len(notes) != 1 || !strings.HasPrefix(notes[0], "Renumbered task 2 to 3") {
+		t.Errorf("Unexpected notes: %q", notes)
+	}
+}
+
+func TestMergeListsConflicts(t *testing.T) {
+	base := listOf("Write docs", "Fix bug")
+	ours := listOf("Write docs", "Fix bug")
+	theirs := listOf("Write docs", "Fix bug")
+	earlier, later := time.Now(), time.Now().Add(time.Minute)
+
+	// Different fields merge, the same field comes from the later change
+	ours.Todos[0].Description, ours.Todos[0].Priority, ours.Todos[0].UpdatedAt = "Mine", PriorityHigh, &earlier
+	theirs.Todos[0].Description, theirs.Todos[0].Project, theirs.Todos[0].UpdatedAt = "Theirs", "work", &later
+	// Deleted on one side, changed on the other
+	ours.Todos.delete(2)
+	theirs.Todos[1].Status = StatusInProgress
+
+	merged, _, notes := mergeLists(base, ours, theirs)
+	first := merged.Todos[0]
+	if first.Description != "Theirs" || first.Priority != PriorityHigh || first.Project != "work" || !first.UpdatedAt.Equal(later) {
+		t.Errorf("Unexpected merged task: %+v", first)
+	}
+	if len(merged.Todos) != 2 || merged.Todos[1].Status != StatusInProgress {
+		t.Errorf("Expected the changed task kept, got %+v", merged.Todos)
+	}
+	want := []string{
+		"Conflict: task 1 changed on both sides, kept the remote description",
+		"Conflict: task 2 was deleted here but changed on the remote, kept it",
+	}
+	if strings.Join(notes, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected notes %q, got %q", want, notes)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// noteLayout is how note times are shown and edited.
+const noteLayout = "2006-01-02 15:04"
+
+// Note is a timestamped annotation of a task, e.g. "called vendor".
+type Note struct {
+	At   time.Time `json:"at"`
+	Text string    `json:"text"`
+}
+
+func newNoteCmd() *Command {
+	flags := newFlagSet("note")
+	remove := flags.Int("remove", 0, "remove the note with this number instead of adding one")
+
+	return &Command{
+		Name:    "note",
+		Args:    "<id> [text]",
+		Summary: "Add a timestamped note to a task, or remove one with -remove.",
+		Flags:   flags,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) == 0 || (len(args) == 1) == (*remove == 0) {
+				return usageErrorf("expected a task id and either a note or -remove")
+			}
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			index, err := ctx.List.IndexOf(id)
+			if err != nil {
+				return err
+			}
+			t := &ctx.List.Todos[index]
+			now := time.Now()
+
+			if *remove != 0 {
+				if *remove < 0 || *remove > len(t.Notes) {
+					return fmt.Errorf("task %d has no note %d", id, *remove)
+				}
+				t.Notes = slices.Delete(t.Notes, *remove-1, *remove)
+				if len(t.Notes) == 0 {
+					t.Notes = nil
+				}
+				t.UpdatedAt = &now
+				fmt.Fprintf(ctx.Stdout, "Removed note %d from task %d\n", *remove, id)
+				return nil
+			}
+
+			text := strings.TrimSpace(strings.Join(args[1:], " "))
+			if text == "" {
+				return usageErrorf("missing note text")
+			}
+			t.Notes = append(t.Notes, Note{At: now, Text: text})
+			t.UpdatedAt = &now
+			fmt.Fprintf(ctx.Stdout, "Added note %d to task %d\n", len(t.Notes), id)
+			return nil
+		},
+	}
+}
+
+func newShowCmd() *Command {
+	return &Command{
+		Name:     "show",
+		Args:     "<id>",
+		Summary:  "Show everything about a task: its fields, details, notes and history.",
+		Flags:    newFlagSet("show"),
+		ReadOnly: true,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) != 1 {
+				return usageErrorf("expected exactly one task id")
+			}
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			index, err := ctx.List.IndexOf(id)
+			if err != nil {
+				return err
+			}
+
+			path, err := ctx.App.StorePath()
+			if err != nil {
+				return err
+			}
+			j, err := OpenJournal(journalPath(path))
+			if err != nil {
+				return err
+			}
+			showTodo(ctx.Stdout, ctx.List.Todos, ctx.List.Todos[index], j, time.Now())
+			return nil
+		},
+	}
+}
+
+// showTodo writes the detail view of t. Related tasks are looked up in
+// todos, the commands that changed t in the journal.
+func showTodo(w io.Writer, todos Todos, t Todo, j *Journal, now time.Time) {
+	fmt.Fprintf(w, "Task %d: %s\n\n", t.ID, t.Description)
+	field := func(label string, value any) {
+		if text := formatValue(value, humanLayout); text != "" {
+			fmt.Fprintf(w, "%-12s %s\n", label+":", text)
+		}
+	}
+	describe := func(ids []int) string {
+		parts := make([]string, len(ids))
+		for i, id := range ids {
+			parts[i] = strconv.Itoa(id)
+			if index, err := todos.IndexOf(id); err == nil {
+				parts[i] += " " + todos[index].Description
+			}
+		}
+		return strings.Join(parts, ", ")
+	}
+
+	field("Status", t.Status)
+	field("Priority", t.Priority)
+	field("Project", t.Project)
+	field("Tags", strings.Join(t.Tags, ", "))
+	field("Due", t.DueAt)
+	if t.Recur != nil {
+		field("Repeats", t.Recur.String())
+	}
+	if t.ParentID != 0 {
+		field("Parent", describe([]int{t.ParentID}))
+	}
+	field("Blocked by", describe(t.BlockedBy))
+	var children []int
+	for _, c := range todos {
+		if c.ParentID == t.ID {
+			children = append(children, c.ID)
+		}
+	}
+	field("Subtasks", describe(children))
+	if t.Progress != nil {
+		field("Progress", fmt.Sprintf("%d%%", *t.Progress))
+	}
+	field("Created", t.CreatedAt)
+	field("Updated", t.UpdatedAt)
+	field("Started", t.StartedAt)
+	field("Completed", t.CompletedAt)
+	if tracked := t.Tracked(time.Time{}, time.Time{}, now); tracked > 0 {
+		running := ""
+		if t.Running() {
+			running = " (running)"
+		}
+		field("Tracked", formatDuration(tracked)+running)
+	}
+
+	if t.Details != "" {
+		fmt.Fprintln(w, "\nDetails:")
+		for _, line := range strings.Split(t.Details, "\n") {
+			fmt.Fprintln(w, strings.TrimRight("  "+line, " "))
+		}
+	}
+	if len(t.Notes) > 0 {
+		fmt.Fprintln(w, "\nNotes:")
+		for i, n := range t.Notes {
+			fmt.Fprintf(w, "  %d. %s  %s\n", i+1, n.At.Format(noteLayout), n.Text)
+		}
+	}
+	if len(t.Transitions) > 0 {
+		fmt.Fprintln(w, "\nStatus changes:")
+		for _, tr := range t.Transitions {
+			fmt.Fprintf(w, "  %s  %s → %s\n", tr.At.Format(noteLayout), tr.From, tr.To)
+		}
+	}
+
+	var history []JournalEntry
+	for _, e := range j.Entries[:j.Position] {
+		if slices.ContainsFunc(e.Changes, func(c Change) bool { return c.ID == t.ID }) {
+			history = append(history, e)
+		}
+	}
+	if len(history) > 0 {
+		fmt.Fprintln(w, "\nHistory:")
+		for _, e := range history {
+			fmt.Fprintf(w, "  %s  #%d %s\n", e.At.Format(noteLayout), e.Seq, e.Command)
+		}
+	}
+}
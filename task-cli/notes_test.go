@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppNotes(t *testing.T) {
+	app := newTestApp(t)
+	runCmd(t, app, "add", "Order parts")
+
+	code, stdout, _ := runCmd(t, app, "note", "1", "called", "vendor")
+	if code != 0 || !strings.Contains(stdout, "Added note 1 to task 1") {
+		t.Fatalf("Expected note added, got %d %q", code, stdout)
+	}
+	runCmd(t, app, "note", "1", "parts ship Friday")
+	todos := loadTodos(t, app)
+	if len(todos[0].Notes) != 2 || todos[0].Notes[0].Text != "called vendor" || todos[0].UpdatedAt == nil {
+		t.Fatalf("Expected two notes, got %+v", todos[0])
+	}
+
+	if code, _, _ := runCmd(t, app, "note", "1"); code != 2 {
+		t.Errorf("Expected usage error without text, got %d", code)
+	}
+	if code, _, _ := runCmd(t, app, "note", "-remove", "3", "1"); code != 1 {
+		t.Errorf("Expected error for a missing note, got %d", code)
+	}
+	runCmd(t, app, "note", "-remove", "1", "1")
+	todos = loadTodos(t, app)
+	if len(todos[0].Notes) != 1 || todos[0].Notes[0].Text != "parts ship Friday" {
+		t.Errorf("Expected the first note removed, got %+v", todos[0].Notes)
+	}
+
+	// Notes can be undone like any change
+	runCmd(t, app, "undo")
+	if todos := loadTodos(t, app); len(todos[0].Notes) != 2 {
+		t.Errorf("Expected the note back after undo, got %+v", todos[0].Notes)
+	}
+}
+
+func TestShowTodo(t *testing.T) {
+	now := time.Date(2026, 10, 12, 9, 30, 0, 0, time.Local)
+	todos := Todos{
+		{ID: 1, Description: "Release", Status: StatusTodo, CreatedAt: now},
+		{ID: 2, Description: "Order parts", Status: StatusInProgress, CreatedAt: now, ParentID: 1, Tags: []string{"shop", "urgent"},
+			Details: "Two boxes of screws\n\nAsk for a discount",
+			Notes:   []Note{{At: now, Text: "called vendor"}}},
+	}
+	j := &Journal{Entries: []JournalEntry{
+		{Seq: 1, At: now, Command: "add Release", Changes: []Change{{ID: 1}}},
+		{Seq: 2, At: now, Command: `add "Order parts"`, Changes: []Change{{ID: 2}}},
+	}, Position: 2}
+
+	var buf bytes.Buffer
+	showTodo(&buf, todos, todos[1], j, now)
+	want := []string{
+		"Task 2: Order parts",
+		"Status:      in-progress",
+		"Tags:        shop, urgent",
+		"Parent:      1 Release",
+		"Details:\n  Two boxes of screws\n\n  Ask for a discount",
+		"Notes:\n  1. 2026-10-12 09:30  called vendor",
+		"History:\n  2026-10-12 09:30  #2 add \"Order parts\"",
+	}
+	for _, w := range want {
+		if !strings.Contains(buf.String(), w) {
+			t.Errorf("Expected %q in the output, got:\n%s", w, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "#1 add Release") {
+		t.Errorf("Expected only the history of task 2, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	showTodo(&buf, todos, todos[0], j, now)
+	if !strings.Contains(buf.String(), "Subtasks:    2 Order parts") {
+		t.Errorf("Expected the subtask listed, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,452 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aquasecurity/table"
+)
+
+// Field is a column that can be selected with -fields. Name is the stable
+// key used by the machine-readable formats, Header the table heading.
+type Field struct {
+	Name   string
+	Header string
+	Value  func(t Todo) any
+}
+
+var Fields = []Field{
+	{"id", "id", func(t Todo) any { return t.ID }},
+	{"description", "Description", func(t Todo) any { return t.Description }},
+	{"status", "Status", func(t Todo) any { return t.Status }},
+	{"created", "Created At", func(t Todo) any { return t.CreatedAt }},
+	{"updated", "Updated At", func(t Todo) any { return t.UpdatedAt }},
+	{"started", "Started At", func(t Todo) any { return t.StartedAt }},
+	{"completed", "Completed At", func(t Todo) any { return t.CompletedAt }},
+	{"archived", "Archived At", func(t Todo) any { return t.ArchivedAt }},
+	{"due", "Due", func(t Todo) any { return t.DueAt }},
+	{"priority", "Priority", func(t Todo) any { return t.Priority }},
+	{"project", "Project", func(t Todo) any { return t.Project }},
+	{"tags", "Tags", func(t Todo) any {
+		if t.Tags == nil {
+			return []string{}
+		}
+		return t.Tags
+	}},
+	{"parent", "Parent", func(t Todo) any {
+		if t.ParentID == 0 {
+			return nil
+		}
+		return t.ParentID
+	}},
+	{"blocked-by", "Blocked By", func(t Todo) any {
+		if t.BlockedBy == nil {
+			return []int{}
+		}
+		return t.BlockedBy
+	}},
+	{"recur", "Repeats", func(t Todo) any {
+		if t.Recur == nil {
+			return nil
+		}
+		return t.Recur.String()
+	}},
+	{"progress", "Progress %", func(t Todo) any {
+		if t.Progress == nil {
+			return nil
+		}
+		return *t.Progress
+	}},
+}
+
+const defaultFields = "id,description,status,priority,project,tags,due,created,updated"
+
+// lookupFields resolves a comma separated list of field names.
+func lookupFields(spec string) ([]Field, error) {
+	var selected []Field
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, f := range Fields {
+			if f.Name == name {
+				selected = append(selected, f)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown field %q (valid: %s)", name, fieldNames())
+		}
+	}
+	return selected, nil
+}
+
+func fieldNames() string {
+	names := make([]string, len(Fields))
+	for i, f := range Fields {
+		names[i] = f.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// humanLayout asks formatValue for times in the configured date format.
+const humanLayout = "human"
+
+// formatValue renders a field value as text, times in layout. The human
+// readable layout drops the time of day from all-day dates.
+func formatValue(v any, layout string) string {
+	switch v := v.(type) {
+	case time.Time:
+		return formatTime(v, layout)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return formatTime(*v, layout)
+	case []string:
+		return strings.Join(v, ",")
+	case []int:
+		return (*idList)(&v).String()
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func formatTime(t time.Time, layout string) string {
+	if layout == humanLayout {
+		layout = humanDates.time
+		if isAllDay(t) {
+			layout = humanDates.day
+		}
+	}
+	return t.Format(layout)
+}
+
+type outputFormat func(w io.Writer, todos Todos, fields []Field) error
+
+var outputFormats = map[string]outputFormat{
+	"table":    renderTable,
+	"json":     renderJSON,
+	"jsonl":    renderJSONL,
+	"csv":      renderCSV,
+	"tsv":      renderTSV,
+	"markdown": renderMarkdown,
+}
+
+func outputFormatNames() string {
+	names := make([]string, 0, len(outputFormats))
+	for name := range outputFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// newTable returns a table in the configured style.
+func newTable(w io.Writer) *table.Table {
+	t := table.New(w)
+	t.SetRowLines(false)
+	t.SetDividers(tableStyle)
+	return t
+}
+
+func renderTable(w io.Writer, todos Todos, fields []Field) error {
+	table := newTable(w)
+
+	headers := make([]string, len(fields))
+	for i, f := range fields {
+		headers[i] = f.Header
+	}
+	table.SetHeaders(headers...)
+
+	color := colorOutput(w)
+	now := time.Now()
+	for _, t := range todos {
+		row := make([]string, len(fields))
+		for i, f := range fields {
+			row[i] = formatValue(f.Value(t), humanLayout)
+			if color && t.Overdue(now) {
+				row[i] = ansiRed + row[i] + ansiReset
+			}
+		}
+		table.AddRow(row...)
+	}
+
+	table.Render()
+	return nil
+}
+
+// jsonObject marshals the selected fields of t, keeping the field order.
+func jsonObject(t Todo, fields []Field) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(f.Name)
+		value, err := json.Marshal(f.Value(t))
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func renderJSON(w io.Writer, todos Todos, fields []Field) error {
+	objects := make([]json.RawMessage, len(todos))
+	for i, t := range todos {
+		obj, err := jsonObject(t, fields)
+		if err != nil {
+			return err
+		}
+		objects[i] = obj
+	}
+
+	data, err := json.MarshalIndent(objects, "", "   ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+func renderJSONL(w io.Writer, todos Todos, fields []Field) error {
+	for _, t := range todos {
+		obj, err := jsonObject(t, fields)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s\n", obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renderCSV(w io.Writer, todos Todos, fields []Field) error {
+	cw := csv.NewWriter(w)
+
+	header := make([]string, len(fields))
+	for i, f := range fields {
+		header[i] = f.Name
+	}
+	cw.Write(header)
+
+	for _, t := range todos {
+		row := make([]string, len(fields))
+		for i, f := range fields {
+			row[i] = formatValue(f.Value(t), time.RFC3339)
+		}
+		cw.Write(row)
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// tsvEscaper keeps every record on one line with tab separated columns.
+var tsvEscaper = strings.NewReplacer("\\", "\\\\", "\t", "\\t", "\n", "\\n", "\r", "\\r")
+
+func renderTSV(w io.Writer, todos Todos, fields []Field) error {
+	header := make([]string, len(fields))
+	for i, f := range fields {
+		header[i] = f.Name
+	}
+	if _, err := fmt.Fprintln(w, strings.Join(header, "\t")); err != nil {
+		return err
+	}
+
+	for _, t := range todos {
+		row := make([]string, len(fields))
+		for i, f := range fields {
+			row[i] = tsvEscaper.Replace(formatValue(f.Value(t), time.RFC3339))
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(row, "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var markdownEscaper = strings.NewReplacer("|", "\\|", "\n", " ", "\r", "")
+
+func renderMarkdown(w io.Writer, todos Todos, fields []Field) error {
+	header := make([]string, len(fields))
+	rule := make([]string, len(fields))
+	for i, f := range fields {
+		header[i] = f.Header
+		rule[i] = "---"
+	}
+	fmt.Fprintf(w, "| %s |\n", strings.Join(header, " | "))
+	fmt.Fprintf(w, "| %s |\n", strings.Join(rule, " | "))
+
+	for _, t := range todos {
+		row := make([]string, len(fields))
+		for i, f := range fields {
+			row[i] = markdownEscaper.Replace(formatValue(f.Value(t), humanLayout))
+		}
+		if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(row, " | ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// grouping places a task in named groups for -group-by. rank orders the
+// groups, nil meaning alphabetical.
+type grouping struct {
+	names func(t Todo) []string
+	rank  func(name string) int
+}
+
+// noGroup holds the tasks without a value for the grouped attribute.
+const noGroup = "(none)"
+
+var groupings = map[string]grouping{
+	"status": {
+		names: func(t Todo) []string { return []string{string(t.Status)} },
+		rank:  func(name string) int { return statusRank(Status(name)) },
+	},
+	"priority": {
+		names: func(t Todo) []string { return []string{string(t.Priority)} },
+		rank:  func(name string) int { return Priority(name).Rank() },
+	},
+	"project": {
+		names: func(t Todo) []string { return []string{t.Project} },
+	},
+	"tag": {
+		names: func(t Todo) []string { return t.Tags },
+	},
+}
+
+func groupingNames() string {
+	names := make([]string, 0, len(groupings))
+	for name := range groupings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+type todoGroup struct {
+	name  string
+	todos Todos
+}
+
+// group splits todos by g keeping their order within each group. A task
+// with several tags appears under each of them.
+func (g grouping) group(todos Todos) []todoGroup {
+	index := map[string]int{}
+	var groups []todoGroup
+	for _, t := range todos {
+		names := g.names(t)
+		if len(names) == 0 {
+			names = []string{""}
+		}
+		for _, name := range names {
+			if name == "" {
+				name = noGroup
+			}
+			i, ok := index[name]
+			if !ok {
+				i = len(groups)
+				index[name] = i
+				groups = append(groups, todoGroup{name: name})
+			}
+			groups[i].todos = append(groups[i].todos, t)
+		}
+	}
+
+	slices.SortFunc(groups, func(a, b todoGroup) int {
+		switch {
+		case a.name == noGroup:
+			return 1
+		case b.name == noGroup:
+			return -1
+		}
+		if g.rank != nil {
+			if c := cmp.Compare(g.rank(a.name), g.rank(b.name)); c != 0 {
+				return c
+			}
+		}
+		return strings.Compare(a.name, b.name)
+	})
+	return groups
+}
+
+// outputFlags are the -output, -fields and -group-by flags shared by
+// listing commands.
+type outputFlags struct {
+	format  *string
+	fields  *string
+	groupBy *string
+}
+
+func addOutputFlags(fs *flag.FlagSet, fields string) *outputFlags {
+	return &outputFlags{
+		format:  fs.String("output", "table", "output format: "+outputFormatNames()),
+		fields:  fs.String("fields", fields, "comma separated fields to show: "+fieldNames()),
+		groupBy: fs.String("group-by", "", "split table or markdown output into sections by: "+groupingNames()),
+	}
+}
+
+// human reports whether the selected format is meant to be read by people.
+func (o *outputFlags) human() bool {
+	return *o.format == "table" || *o.format == "markdown"
+}
+
+// render writes todos in the selected format. Bad flag values are reported
+// as usage errors.
+func (o *outputFlags) render(w io.Writer, todos Todos) error {
+	format, ok := outputFormats[*o.format]
+	if !ok {
+		return usageErrorf("unknown output format %q (valid: %s)", *o.format, outputFormatNames())
+	}
+	fields, err := lookupFields(*o.fields)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+	if *o.groupBy == "" {
+		return format(w, todos, fields)
+	}
+
+	g, ok := groupings[*o.groupBy]
+	if !ok {
+		return usageErrorf("unknown -group-by %q (valid: %s)", *o.groupBy, groupingNames())
+	}
+	if !o.human() {
+		return usageErrorf("-group-by needs table or markdown output")
+	}
+
+	for i, grp := range g.group(todos) {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		heading := fmt.Sprintf("%s: %s (%d)", *o.groupBy, grp.name, len(grp.todos))
+		if *o.format == "markdown" {
+			fmt.Fprintf(w, "### %s\n\n", heading)
+		} else {
+			fmt.Fprintln(w, heading)
+		}
+		if err := format(w, grp.todos, fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func outputTodos() Todos {
+	created := time.Date(2026, 10, 1, 9, 30, 0, 0, time.UTC)
+	updated := time.Date(2026, 10, 2, 18, 0, 0, 0, time.UTC)
+	return Todos{
+		{ID: 1, Description: "Plain task", Status: "todo", CreatedAt: created},
+		{ID: 7, Description: "Tricky, \"quoted\"\tand | piped", Status: "done", CreatedAt: created, UpdatedAt: &updated},
+	}
+}
+
+func TestLookupFields(t *testing.T) {
+	fields, err := lookupFields("status, id")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(fields) != 2 || fields[0].Name != "status" || fields[1].Name != "id" {
+		t.Errorf("Expected [status id] in order, got %v", fields)
+	}
+
+	if _, err := lookupFields("id,colour"); err == nil {
+		t.Error("Expected error for unknown field, got nil")
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	fields, _ := lookupFields(defaultFields)
+	var buf bytes.Buffer
+	if err := renderJSON(&buf, outputTodos(), fields); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var decoded []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Expected valid JSON, got %v: %s", err, buf.String())
+	}
+	if len(decoded) != 2 {
+		t.Fatalf("Expected 2 objects, got %d", len(decoded))
+	}
+
+	// Stable schema: every selected key is present, missing times are null
+	first := decoded[0]
+	for _, key := range []string{"id", "description", "status", "created", "updated"} {
+		if _, ok := first[key]; !ok {
+			t.Errorf("Expected key %q in %v", key, first)
+		}
+	}
+	if first["updated"] != nil {
+		t.Errorf("Expected null updated, got %v", first["updated"])
+	}
+	if first["created"] != "2026-10-01T09:30:00Z" {
+		t.Errorf("Expected RFC 3339 created, got %v", first["created"])
+	}
+	if decoded[1]["id"] != float64(7) {
+		t.Errorf("Expected numeric id 7, got %v", decoded[1]["id"])
+	}
+
+	// Keys keep the order of the field list
+	if strings.Index(buf.String(), `"id"`) > strings.Index(buf.String(), `"description"`) {
+		t.Errorf("Expected keys in field order, got %s", buf.String())
+	}
+}
+
+func TestRenderJSONL(t *testing.T) {
+	fields, _ := lookupFields("id,status")
+	var buf bytes.Buffer
+	renderJSONL(&buf, outputTodos(), fields)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(lines))
+	}
+	if lines[1] != `{"id":7,"status":"done"}` {
+		t.Errorf("Unexpected record: %s", lines[1])
+	}
+}
+
+func TestRenderCSV(t *testing.T) {
+	fields, _ := lookupFields("id,description,updated")
+	var buf bytes.Buffer
+	renderCSV(&buf, outputTodos(), fields)
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected valid CSV, got %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected header and 2 rows, got %d", len(records))
+	}
+	if strings.Join(records[0], ",") != "id,description,updated" {
+		t.Errorf("Unexpected header: %v", records[0])
+	}
+	if records[2][1] != "Tricky, \"quoted\"\tand | piped" {
+		t.Errorf("Expected description to round-trip, got %q", records[2][1])
+	}
+	if records[2][2] != "2026-10-02T18:00:00Z" || records[1][2] != "" {
+		t.Errorf("Unexpected updated values: %q and %q", records[1][2], records[2][2])
+	}
+}
+
+func TestRenderTSV(t *testing.T) {
+	fields, _ := lookupFields("id,description")
+	var buf bytes.Buffer
+	renderTSV(&buf, outputTodos(), fields)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if lines[0] != "id\tdescription" {
+		t.Errorf("Unexpected header: %q", lines[0])
+	}
+	// Tabs inside values are escaped so columns stay aligned
+	if cols := strings.Split(lines[2], "\t"); len(cols) != 2 {
+		t.Errorf("Expected 2 columns, got %d in %q", len(cols), lines[2])
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	fields, _ := lookupFields("id,description")
+	var buf bytes.Buffer
+	renderMarkdown(&buf, outputTodos(), fields)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 lines, got %d", len(lines))
+	}
+	if lines[0] != "| id | Description |" || lines[1] != "| --- | --- |" {
+		t.Errorf("Unexpected header: %q / %q", lines[0], lines[1])
+	}
+	if !strings.Contains(lines[3], `and \| piped`) {
+		t.Errorf("Expected pipe to be escaped, got %q", lines[3])
+	}
+}
+
+func TestAppListOutput(t *testing.T) {
+	app := newTestApp(t)
+	runCmd(t, app, "add", "Task 1")
+	runCmd(t, app, "add", "Task 2")
+
+	code, stdout, stderr := runCmd(t, app, "list", "--output", "jsonl", "--fields", "id,description")
+	if code != ExitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", ExitOK, code, stderr)
+	}
+	expected := "{\"id\":1,\"description\":\"Task 1\"}\n{\"id\":2,\"description\":\"Task 2\"}\n"
+	if stdout != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout)
+	}
+
+	// The table stays the default
+	_, stdout, _ = runCmd(t, app, "list")
+	if !strings.Contains(stdout, "Description") || !strings.Contains(stdout, "│") {
+		t.Errorf("Expected table output, got '%s'", stdout)
+	}
+
+	code, _, _ = runCmd(t, app, "list", "--output", "xml")
+	if code != ExitUsage {
+		t.Errorf("Expected exit code %d for unknown format, got %d", ExitUsage, code)
+	}
+	code, _, _ = runCmd(t, app, "list", "--fields", "id,colour")
+	if code != ExitUsage {
+		t.Errorf("Expected exit code %d for unknown field, got %d", ExitUsage, code)
+	}
+}
+
+func TestGrouping(t *testing.T) {
+	todos := Todos{
+		{ID: 1, Status: StatusDone, Tags: []string{"home", "work"}},
+		{ID: 2, Status: StatusTodo},
+		{ID: 3, Status: StatusDone, Tags: []string{"work"}},
+	}
+
+	groups := groupings["status"].group(todos)
+	if len(groups) != 2 || groups[0].name != "todo" || groups[1].name != "done" {
+		t.Fatalf("Expected groups in workflow order, got %+v", groups)
+	}
+	if !equalIDs(ids(groups[1].todos), []int{1, 3}) {
+		t.Errorf("Expected done group [1 3], got %v", ids(groups[1].todos))
+	}
+
+	// Tasks appear under each of their tags, untagged ones last
+	groups = groupings["tag"].group(todos)
+	names := []string{}
+	for _, g := range groups {
+		names = append(names, g.name)
+	}
+	if strings.Join(names, ",") != "home,work,(none)" {
+		t.Errorf("Expected home,work,(none), got %v", names)
+	}
+	if !equalIDs(ids(groups[1].todos), []int{1, 3}) {
+		t.Errorf("Expected work group [1 3], got %v", ids(groups[1].todos))
+	}
+}
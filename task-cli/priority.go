@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Priority is how important a task is. The empty priority means none was
+// set.
+type Priority string
+
+const (
+	PriorityLow    Priority = "low"
+	PriorityMedium Priority = "medium"
+	PriorityHigh   Priority = "high"
+	PriorityUrgent Priority = "urgent"
+)
+
+// Priorities lists every valid priority, most important first.
+var Priorities = []Priority{PriorityUrgent, PriorityHigh, PriorityMedium, PriorityLow}
+
+func priorityNames() string {
+	names := make([]string, len(Priorities))
+	for i, p := range Priorities {
+		names[i] = string(p)
+	}
+	return strings.Join(names, ", ")
+}
+
+// ParsePriority validates a priority typed by the user. "none" clears it.
+func ParsePriority(s string) (Priority, error) {
+	p := Priority(strings.ToLower(strings.TrimSpace(s)))
+	if p == "none" {
+		return "", nil
+	}
+	if !p.Valid() {
+		return "", fmt.Errorf("unknown priority %q (valid: %s, none)", s, priorityNames())
+	}
+	return p, nil
+}
+
+func (p Priority) Valid() bool {
+	return slices.Contains(Priorities, p)
+}
+
+// Rank orders priorities most important first, tasks without one last.
+func (p Priority) Rank() int {
+	if i := slices.Index(Priorities, p); i >= 0 {
+		return i
+	}
+	return len(Priorities)
+}
+
+// priorityList collects -priority filter flags, each a comma separated list.
+type priorityList []Priority
+
+func (l *priorityList) String() string {
+	names := make([]string, len(*l))
+	for i, p := range *l {
+		names[i] = string(p)
+	}
+	return strings.Join(names, ",")
+}
+
+func (l *priorityList) Set(value string) error {
+	if value == "" {
+		*l = nil
+		return nil
+	}
+	for _, part := range strings.Split(value, ",") {
+		p, err := ParsePriority(part)
+		if err != nil {
+			return err
+		}
+		*l = append(*l, p)
+	}
+	return nil
+}
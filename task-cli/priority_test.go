@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestParsePriority(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  Priority
+	}{
+		{"low", PriorityLow},
+		{" High ", PriorityHigh},
+		{"URGENT", PriorityUrgent},
+		{"none", ""},
+	}
+	for _, tc := range testCases {
+		got, err := ParsePriority(tc.input)
+		if err != nil || got != tc.want {
+			t.Errorf("%q: expected %q, got %q (%v)", tc.input, tc.want, got, err)
+		}
+	}
+
+	if _, err := ParsePriority("critical"); err == nil {
+		t.Error("Expected error for unknown priority, got nil")
+	}
+}
+
+func TestPriorityRank(t *testing.T) {
+	order := []Priority{PriorityUrgent, PriorityHigh, PriorityMedium, PriorityLow, ""}
+	for i := 1; i < len(order); i++ {
+		if order[i-1].Rank() >= order[i].Rank() {
+			t.Errorf("Expected %q to rank before %q", order[i-1], order[i])
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence kinds. "after" schedules the next occurrence a number of days
+// after the task was completed, the others follow the calendar.
+const (
+	RecurDaily   = "daily"
+	RecurWeekly  = "weekly"
+	RecurMonthly = "monthly"
+	RecurAfter   = "after"
+)
+
+// Recurrence is the schedule of a recurring task, written as
+//
+//	daily
+//	weekly            weekly:mon,thu
+//	monthly           monthly:15
+//	after:3d
+//
+// Weekly and monthly rules without days are pinned to the weekday or day
+// of month of the due date when they are set.
+type Recurrence struct {
+	Kind     string
+	Weekdays []time.Weekday
+	Day      int
+	Days     int
+}
+
+// recurFields are the default fields of list -recurring.
+const recurFields = "id,description,status,recur,due"
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ParseRecurrence parses a rule as written by String.
+func ParseRecurrence(s string) (*Recurrence, error) {
+	kind, arg, hasArg := strings.Cut(strings.ToLower(strings.TrimSpace(s)), ":")
+	r := &Recurrence{Kind: kind}
+
+	switch {
+	case kind == RecurDaily && !hasArg:
+		return r, nil
+	case kind == RecurWeekly:
+		if !hasArg {
+			return r, nil
+		}
+		for _, name := range strings.Split(arg, ",") {
+			wd, ok := weekdays[strings.TrimSpace(name)]
+			if !ok {
+				return nil, fmt.Errorf("invalid weekday %q in recurrence %q", name, s)
+			}
+			if !slices.Contains(r.Weekdays, wd) {
+				r.Weekdays = append(r.Weekdays, wd)
+			}
+		}
+		slices.Sort(r.Weekdays)
+		return r, nil
+	case kind == RecurMonthly:
+		if !hasArg {
+			return r, nil
+		}
+		day, err := strconv.Atoi(arg)
+		if err != nil || day < 1 || day > 31 {
+			return nil, fmt.Errorf("invalid day of month %q in recurrence %q", arg, s)
+		}
+		r.Day = day
+		return r, nil
+	case kind == RecurAfter && hasArg:
+		days, err := strconv.Atoi(strings.TrimSuffix(arg, "d"))
+		if err != nil || days < 1 {
+			return nil, fmt.Errorf("invalid interval %q in recurrence %q, use e.g. after:3d", arg, s)
+		}
+		r.Days = days
+		return r, nil
+	}
+	return nil, fmt.Errorf("invalid recurrence %q, use daily, weekly[:mon,thu], monthly[:15] or after:3d", s)
+}
+
+func (r Recurrence) String() string {
+	switch {
+	case r.Kind == RecurWeekly && len(r.Weekdays) > 0:
+		names := make([]string, len(r.Weekdays))
+		for i, wd := range r.Weekdays {
+			names[i] = weekdayNames[wd]
+		}
+		return r.Kind + ":" + strings.Join(names, ",")
+	case r.Kind == RecurMonthly && r.Day > 0:
+		return r.Kind + ":" + strconv.Itoa(r.Day)
+	case r.Kind == RecurAfter:
+		return r.Kind + ":" + strconv.Itoa(r.Days) + "d"
+	}
+	return r.Kind
+}
+
+// MarshalText stores the rule in its written form.
+func (r Recurrence) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *Recurrence) UnmarshalText(text []byte) error {
+	parsed, err := ParseRecurrence(string(text))
+	if err != nil {
+		return err
+	}
+	*r = *parsed
+	return nil
+}
+
+// pin fills in the days of a weekly or monthly rule given without any from
+// the due date.
+func (r *Recurrence) pin(due time.Time) {
+	switch {
+	case r.Kind == RecurWeekly && len(r.Weekdays) == 0:
+		r.Weekdays = []time.Weekday{due.Weekday()}
+	case r.Kind == RecurMonthly && r.Day == 0:
+		r.Day = due.Day()
+	}
+}
+
+// matches reports whether day is an occurrence of a pinned calendar rule.
+func (r Recurrence) matches(day time.Time) bool {
+	switch r.Kind {
+	case RecurWeekly:
+		return slices.Contains(r.Weekdays, day.Weekday())
+	case RecurMonthly:
+		// Days past the end of a short month fall on its last day
+		lastDay := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+		return day.Day() == min(r.Day, lastDay)
+	}
+	return true
+}
+
+// First returns the first occurrence on or after the day of now, used as
+// the due date of a new recurring task without one. Unpinned rules start
+// today.
+func (r Recurrence) First(now time.Time) time.Time {
+	day := startOfDay(now)
+	if r.Kind == RecurAfter || (r.Kind == RecurWeekly && len(r.Weekdays) == 0) || (r.Kind == RecurMonthly && r.Day == 0) {
+		return day
+	}
+	for !r.matches(day) {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// Next returns the due date of the occurrence after a task due at due was
+// completed at done. Calendar rules skip occurrences missed while the task
+// was overdue; the time of day of due is kept.
+func (r Recurrence) Next(due *time.Time, done time.Time) time.Time {
+	from := startOfDay(done)
+	clock := time.Duration(0)
+	if due != nil {
+		clock = due.Sub(startOfDay(*due))
+		if d := startOfDay(*due); d.After(from) {
+			from = d
+		}
+	}
+
+	if r.Kind == RecurAfter {
+		return startOfDay(done).AddDate(0, 0, r.Days).Add(clock)
+	}
+
+	day := from.AddDate(0, 0, 1)
+	for !r.matches(day) {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day.Add(clock)
+}
+
+// spawnNext adds the next occurrence of the recurring task id, which was
+// just completed at now. The schedule moves to the new task so reopening
+// the old one does not fork the series.
+func (l *TodoList) spawnNext(id int, now time.Time) (*Todo, error) {
+	index, err := l.IndexOf(id)
+	if err != nil {
+		return nil, err
+	}
+	done := l.Todos[index]
+	if done.Recur == nil {
+		return nil, nil
+	}
+
+	due := done.Recur.Next(done.DueAt, now)
+	l.Todos[index].Recur = nil
+
+	next := l.add(done.Description)
+	next.Priority = done.Priority
+	next.Project = done.Project
+	next.Tags = slices.Clone(done.Tags)
+	next.ParentID = done.ParentID
+	next.DueAt = &due
+	next.Recur = done.Recur
+	return next, nil
+}
+
+// recurFlag is a -recur value, "none" removing the schedule.
+type recurFlag struct {
+	rule *Recurrence
+	set  bool
+}
+
+func (f *recurFlag) String() string {
+	if f.rule == nil {
+		return ""
+	}
+	return f.rule.String()
+}
+
+func (f *recurFlag) Set(value string) error {
+	f.rule, f.set = nil, value != ""
+	if value == "" || strings.EqualFold(value, "none") {
+		return nil
+	}
+	rule, err := ParseRecurrence(value)
+	if err != nil {
+		return err
+	}
+	f.rule = rule
+	return nil
+}
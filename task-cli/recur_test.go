@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	for input, want := range map[string]string{
+		"daily":              "daily",
+		"Weekly":             "weekly",
+		"weekly:thu,mon,thu": "weekly:mon,thu",
+		"monthly:15":         "monthly:15",
+		"after:3d":           "after:3d",
+		"after:10":           "after:10d",
+	} {
+		r, err := ParseRecurrence(input)
+		if err != nil {
+			t.Errorf("%s: expected no error, got %v", input, err)
+			continue
+		}
+		if r.String() != want {
+			t.Errorf("%s: expected %q, got %q", input, want, r.String())
+		}
+	}
+
+	for _, input := range []string{"", "hourly", "daily:2", "weekly:funday", "monthly:32", "after", "after:0d"} {
+		if _, err := ParseRecurrence(input); err == nil {
+			t.Errorf("Expected error for %q, got nil", input)
+		}
+	}
+}
+
+func TestRecurrenceJSON(t *testing.T) {
+	r, _ := ParseRecurrence("weekly:mon,fri")
+	data, err := json.Marshal(Todo{ID: 1, Recur: r})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(string(data), `"recur":"weekly:mon,fri"`) {
+		t.Errorf("Expected rule stored as text, got %s", data)
+	}
+
+	var todo Todo
+	if err := json.Unmarshal(data, &todo); err != nil || todo.Recur == nil || todo.Recur.String() != "weekly:mon,fri" {
+		t.Errorf("Expected rule to round-trip, got %v (%v)", todo.Recur, err)
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	day := func(m time.Month, d int) time.Time {
+		return time.Date(2026, m, d, 0, 0, 0, 0, time.Local)
+	}
+	at := func(t time.Time) *time.Time { return &t }
+	rule := func(s string) Recurrence {
+		r, err := ParseRecurrence(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *r
+	}
+
+	// 2026-10-16 is a Friday
+	testCases := []struct {
+		name string
+		rule Recurrence
+		due  *time.Time
+		done time.Time
+		want time.Time
+	}{
+		{"Daily on time", rule("daily"), at(day(10, 16)), day(10, 16).Add(9 * time.Hour), day(10, 17)},
+		{"Daily late skips missed days", rule("daily"), at(day(10, 10)), day(10, 16), day(10, 17)},
+		{"Daily keeps time of day", rule("daily"), at(day(10, 16).Add(8 * time.Hour)), day(10, 16), day(10, 17).Add(8 * time.Hour)},
+		{"Weekly on weekdays", rule("weekly:mon,thu"), at(day(10, 19)), day(10, 19), day(10, 22)},
+		{"Weekly done early", rule("weekly:fri"), at(day(10, 16)), day(10, 14), day(10, 23)},
+		{"Monthly", rule("monthly:15"), at(day(10, 15)), day(10, 15), day(11, 15)},
+		{"Monthly short month", rule("monthly:31"), at(day(1, 31)), day(1, 31), day(2, 28)},
+		{"After completion", rule("after:3d"), at(day(10, 1)), day(10, 16).Add(20 * time.Hour), day(10, 19)},
+	}
+
+	for _, tc := range testCases {
+		if got := tc.rule.Next(tc.due, tc.done); !got.Equal(tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+
+	// The first occurrence of a pinned rule is on or after today
+	if got := rule("weekly:mon").First(day(10, 16).Add(time.Hour)); !got.Equal(day(10, 19)) {
+		t.Errorf("Expected first Monday 2026-10-19, got %v", got)
+	}
+}
+
+func TestAppRecurring(t *testing.T) {
+	app := newTestApp(t)
+	runCmd(t, app, "add", "--recur", "daily", "--tag", "home", "Water plants")
+	runCmd(t, app, "add", "--recur", "weekly", "--due", "2026-10-16", "Take out bins")
+	runCmd(t, app, "add", "One-off")
+
+	todos := loadTodos(t, app)
+	if todos[0].DueAt == nil || !todos[0].DueAt.Equal(startOfDay(time.Now())) {
+		t.Errorf("Expected daily task due today, got %v", todos[0].DueAt)
+	}
+	if todos[1].Recur.String() != "weekly:fri" {
+		t.Errorf("Expected weekly rule pinned to Friday, got %s", todos[1].Recur)
+	}
+
+	code, stdout, stderr := runCmd(t, app, "mark", "1", "done")
+	if code != ExitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", ExitOK, code, stderr)
+	}
+	if !strings.Contains(stdout, "Next occurrence is task 4") {
+		t.Errorf("Expected next occurrence message, got %q", stdout)
+	}
+
+	todos = loadTodos(t, app)
+	next := todos[3]
+	if next.Description != "Water plants" || next.Status != StatusTodo || next.Tags[0] != "home" || next.Recur == nil {
+		t.Errorf("Unexpected next occurrence: %+v", next)
+	}
+	if want := startOfDay(time.Now()).AddDate(0, 0, 1); !next.DueAt.Equal(want) {
+		t.Errorf("Expected next occurrence due %v, got %v", want, next.DueAt)
+	}
+	if todos[0].Recur != nil {
+		t.Error("Expected the schedule to move to the next occurrence")
+	}
+
+	_, stdout, _ = runCmd(t, app, "list", "--recurring", "--output", "csv")
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 3 || lines[0] != "id,description,status,recur,due" || !strings.HasPrefix(lines[1], "2,Take out bins,todo,weekly:fri,") {
+		t.Errorf("Unexpected recurring list: %q", stdout)
+	}
+
+	runCmd(t, app, "update", "--recur", "none", "2")
+	if todos = loadTodos(t, app); todos[1].Recur != nil {
+		t.Errorf("Expected schedule removed, got %s", todos[1].Recur)
+	}
+	if code, _, _ := runCmd(t, app, "add", "--recur", "hourly", "Bad"); code != ExitUsage {
+		t.Errorf("Expected exit code %d for bad rule, got %d", ExitUsage, code)
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// remoteTodo is a todo as the todo-list-api server stores it.
+type remoteTodo struct {
+	ID          int64     `json:"id,omitempty"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Completed   bool      `json:"completed"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// remoteBody is what is sent to create or update a todo.
+type remoteBody struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Completed   bool   `json:"completed"`
+}
+
+// errNotLoggedIn is returned for requests the server rejects as
+// unauthenticated.
+var errNotLoggedIn = errors.New("not logged in or the session expired, run task remote login")
+
+// remoteClient talks to a todo-list-api server. The server authenticates
+// with a JWT in the Authorization cookie, set by POST /login.
+type remoteClient struct {
+	url   string
+	token string
+	http  *http.Client
+}
+
+func newRemoteClient(url, token string) *remoteClient {
+	return &remoteClient{url: strings.TrimRight(url, "/"), token: token, http: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// do sends body as JSON and decodes the response into out. A body is
+// always sent since the server binds one even for DELETE.
+func (c *remoteClient) do(method, path string, body, out any) (*http.Response, error) {
+	if body == nil {
+		body = struct{}{}
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, c.url+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.AddCookie(&http.Cookie{Name: "Authorization", Value: c.token})
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return resp, errNotLoggedIn
+	}
+	if resp.StatusCode >= 300 {
+		var failure struct{ Error, Message string }
+		json.NewDecoder(resp.Body).Decode(&failure)
+		msg := failure.Error + failure.Message
+		if msg == "" {
+			msg = resp.Status
+		}
+		return resp, fmt.Errorf("%s %s: %s", method, path, msg)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp, fmt.Errorf("%s %s: reading response: %w", method, path, err)
+		}
+	}
+	return resp, nil
+}
+
+// login exchanges the credentials for a token.
+func (c *remoteClient) login(email, password string) error {
+	body := map[string]string{"email": email, "password": password}
+	resp, err := c.do(http.MethodPost, "/login", body, nil)
+	if err != nil {
+		return err
+	}
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == "Authorization" && cookie.Value != "" {
+			c.token = cookie.Value
+			return nil
+		}
+	}
+	return errors.New("the server sent no Authorization cookie")
+}
+
+func (c *remoteClient) todos() ([]remoteTodo, error) {
+	var out struct {
+		Todos []remoteTodo `json:"todos"`
+	}
+	_, err := c.do(http.MethodGet, "/todos", nil, &out)
+	return out.Todos, err
+}
+
+func (c *remoteClient) create(body remoteBody) (remoteTodo, error) {
+	var out struct {
+		Todo remoteTodo `json:"todo"`
+	}
+	_, err := c.do(http.MethodPost, "/todos", body, &out)
+	return out.Todo, err
+}
+
+// update changes the todo from its current state to body. The server
+// skips zero values on PUT, so clearing completed takes a toggle.
+func (c *remoteClient) update(current remoteTodo, body remoteBody) error {
+	path := "/todos/" + strconv.FormatInt(current.ID, 10)
+	if _, err := c.do(http.MethodPut, path, body, nil); err != nil {
+		return err
+	}
+	if current.Completed && !body.Completed {
+		_, err := c.do(http.MethodPatch, "/todos/toggle", map[string]int64{"Id": current.ID}, nil)
+		return err
+	}
+	return nil
+}
+
+func (c *remoteClient) delete(id int64) error {
+	_, err := c.do(http.MethodDelete, "/todos/"+strconv.FormatInt(id, 10), nil, nil)
+	return err
+}
+
+// remoteLogin is the server the user logged in to, kept in remote.json in
+// the config directory.
+type remoteLogin struct {
+	URL   string `json:"url"`
+	Email string `json:"email"`
+	Token string `json:"token"`
+}
+
+func remoteLoginPath() (string, error) {
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "remote.json"), nil
+}
+
+// loadRemoteLogin returns the saved login, or nil if there is none.
+func loadRemoteLogin() (*remoteLogin, error) {
+	path, err := remoteLoginPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var login remoteLogin
+	if err := json.Unmarshal(data, &login); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &login, nil
+}
+
+// save writes the login readable only by the user, as it holds the token.
+func (l *remoteLogin) save() error {
+	path, err := remoteLoginPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0o600)
+}
+
+// readPassword reads the password without echo from a terminal, or as the
+// first line of standard input otherwise.
+func readPassword(ctx *Context) (string, error) {
+	if f, ok := ctx.App.Stdin.(*os.File); ok && interactive(f) {
+		fmt.Fprint(ctx.Stderr, "Password: ")
+		password, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(ctx.Stderr)
+		return string(password), err
+	}
+	line, err := bufio.NewReader(ctx.App.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func newRemoteCmd() *Command {
+	flags := newFlagSet("remote")
+	email := flags.String("email", "", "account to log in with; the password is read from the terminal or standard input")
+
+	return &Command{
+		Name:    "remote",
+		Args:    "login <url> | logout | status",
+		Summary: "Log in to a todo-list-api server to push, pull and sync with.",
+		Flags:   flags,
+		NoStore: true,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) == 0 {
+				return usageErrorf("expected login, logout or status")
+			}
+			action, args := args[0], args[1:]
+			switch action {
+			case "login":
+				if len(args) != 1 || *email == "" {
+					return usageErrorf("expected a server URL and -email")
+				}
+				password, err := readPassword(ctx)
+				if err != nil {
+					return err
+				}
+				client := newRemoteClient(args[0], "")
+				if err := client.login(*email, password); err != nil {
+					return err
+				}
+				login := &remoteLogin{URL: client.url, Email: *email, Token: client.token}
+				if err := login.save(); err != nil {
+					return err
+				}
+				fmt.Fprintf(ctx.Stdout, "Logged in to %s as %s\n", login.URL, login.Email)
+			case "logout":
+				if len(args) > 0 {
+					return usageErrorf("unexpected argument %q", args[0])
+				}
+				path, err := remoteLoginPath()
+				if err != nil {
+					return err
+				}
+				if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+					return err
+				}
+				fmt.Fprintln(ctx.Stdout, "Logged out")
+			case "status":
+				if len(args) > 0 {
+					return usageErrorf("unexpected argument %q", args[0])
+				}
+				login, err := loadRemoteLogin()
+				if err != nil {
+					return err
+				}
+				if login == nil {
+					fmt.Fprintln(ctx.Stdout, "Not logged in")
+					return nil
+				}
+				fmt.Fprintf(ctx.Stdout, "Logged in to %s as %s\n", login.URL, login.Email)
+				path, err := ctx.App.StorePath()
+				if err != nil {
+					return err
+				}
+				state, err := OpenSyncState(syncStatePath(path))
+				if err != nil {
+					return err
+				}
+				if state.URL == login.URL {
+					fmt.Fprintf(ctx.Stdout, "List %s: %d %s linked\n", ctx.App.ListName(), len(state.Links), plural(len(state.Links), "task"))
+				} else {
+					fmt.Fprintf(ctx.Stdout, "List %s: not synced yet\n", ctx.App.ListName())
+				}
+			default:
+				return usageErrorf("unknown action %q, expected login, logout or status", action)
+			}
+			return nil
+		},
+	}
+}
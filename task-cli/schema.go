@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// envelope wraps a stored document with the name and version of its
+// schema, so files written by older versions can be recognised and
+// upgraded. Documents written before the envelope are version 1.
+type envelope struct {
+	Schema  string          `json:"schema"`
+	Version int             `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// versioned is implemented by the types Storage keeps in an envelope.
+type versioned interface {
+	schema() string
+}
+
+func (TodoList) schema() string  { return "tasks" }
+func (Journal) schema() string   { return "journal" }
+func (Archive) schema() string   { return "archive" }
+func (SyncState) schema() string { return "sync-state" }
+
+// migration upgrades a document decoded into generic JSON values from the
+// version before to version.
+type migration struct {
+	version int
+	summary string
+	apply   func(doc any) (any, error)
+}
+
+// migrations are the upgrades of each schema, oldest first. The version of
+// the last one is the version written.
+var migrations = map[string][]migration{
+	"tasks": {
+		{2, "tasks are keyed id instead of ID; the bare task array of the first versions becomes a list", func(doc any) (any, error) {
+			if todos, ok := doc.([]any); ok {
+				doc = map[string]any{"todos": todos}
+			}
+			list, ok := doc.(map[string]any)
+			if !ok {
+				return nil, errors.New("expected a list of tasks")
+			}
+			renameTaskKeys(list["todos"])
+			return list, nil
+		}},
+	},
+	"journal": {
+		{2, "tasks are keyed id instead of ID", func(doc any) (any, error) {
+			journal, ok := doc.(map[string]any)
+			if !ok {
+				return nil, errors.New("expected a journal")
+			}
+			entries, _ := journal["entries"].([]any)
+			for _, e := range entries {
+				entry, _ := e.(map[string]any)
+				changes, _ := entry["changes"].([]any)
+				for _, c := range changes {
+					change, _ := c.(map[string]any)
+					renameTaskKeys([]any{change["before"], change["after"]})
+				}
+			}
+			return journal, nil
+		}},
+	},
+	"archive": {
+		{2, "tasks are keyed id instead of ID", func(doc any) (any, error) {
+			archive, ok := doc.(map[string]any)
+			if !ok {
+				return nil, errors.New("expected an archive")
+			}
+			renameTaskKeys(archive["todos"])
+			return archive, nil
+		}},
+	},
+}
+
+// renameTaskKeys moves the ID of each task in todos, a JSON array, to the
+// id key.
+func renameTaskKeys(todos any) {
+	list, _ := todos.([]any)
+	for _, t := range list {
+		task, ok := t.(map[string]any)
+		if id, found := task["ID"]; ok && found {
+			delete(task, "ID")
+			task["id"] = id
+		}
+	}
+}
+
+// schemaVersion returns the version of schema this build writes.
+func schemaVersion(schema string) int {
+	if m := migrations[schema]; len(m) > 0 {
+		return m[len(m)-1].version
+	}
+	return 1
+}
+
+// decodeStored unwraps a stored document of schema and upgrades it to the
+// current version. It returns the data along with the version it was
+// stored in.
+func decodeStored(data []byte, schema string) (json.RawMessage, int, error) {
+	version, raw := 1, json.RawMessage(data)
+	var env envelope
+	if json.Unmarshal(data, &env) == nil && env.Schema != "" && env.Data != nil {
+		if env.Schema != schema {
+			return nil, 0, fmt.Errorf("holds %s, not %s", env.Schema, schema)
+		}
+		version, raw = env.Version, env.Data
+	}
+	current := schemaVersion(schema)
+	switch {
+	case version < 1:
+		return nil, version, fmt.Errorf("invalid %s version %d", schema, version)
+	case version > current:
+		return nil, version, fmt.Errorf("%s version %d was written by a newer task, this one reads up to version %d", schema, version, current)
+	case version == current:
+		return raw, version, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, version, err
+	}
+	for _, m := range migrations[schema] {
+		if m.version <= version {
+			continue
+		}
+		var err error
+		if doc, err = m.apply(doc); err != nil {
+			return nil, version, fmt.Errorf("upgrading %s to version %d: %w", schema, m.version, err)
+		}
+	}
+	raw, err := json.Marshal(doc)
+	return raw, version, err
+}
+
+// encodeStored wraps data in the envelope of the current version of schema.
+func encodeStored(schema string, data any) ([]byte, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(envelope{Schema: schema, Version: schemaVersion(schema), Data: raw}, "", "   ")
+}
+
+// backupPath returns where the file at path is kept before it is upgraded
+// from version.
+func backupPath(path string, version int) string {
+	return fmt.Sprintf("%s.v%d.bak", path, version)
+}
+
+// backupFile keeps data, the file at path in version, before it is
+// upgraded. An earlier backup of the same version is left alone.
+func backupFile(path string, version int, data []byte) error {
+	backup := backupPath(path, version)
+	if _, err := os.Stat(backup); !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return writeFileAtomic(backup, data, 0o644)
+}
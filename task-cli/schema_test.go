@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStorageEnvelope(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.json")
+	storage := NewStorage[TodoList](path)
+	list := TodoList{NextID: 2, Todos: Todos{{ID: 1, Description: "Task", Status: StatusTodo}}}
+	if err := storage.Save(list); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil || env.Schema != "tasks" || env.Version != schemaVersion("tasks") {
+		t.Fatalf("Expected a tasks envelope of the current version, got %+v, %v", env, err)
+	}
+	if !strings.Contains(string(env.Data), `"id": 1`) {
+		t.Errorf("Expected tasks keyed id, got %s", env.Data)
+	}
+
+	loaded := TodoList{}
+	if err := storage.Load(&loaded); err != nil || loaded.NextID != 2 || len(loaded.Todos) != 1 {
+		t.Errorf("Expected the list back, got %+v, %v", loaded, err)
+	}
+	if _, err := os.Stat(backupPath(path, 1)); !os.IsNotExist(err) {
+		t.Errorf("Expected no backup of a current file, got %v", err)
+	}
+}
+
+func TestStorageMigratesOldFiles(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name, content string
+		check         func(path string) (upgrade func() error, err error)
+	}{
+		{"bare array", `[{"ID": 4, "description": "Old", "status": "todo"}]`, func(path string) (func() error, error) {
+			list, storage := TodoList{}, NewStorage[TodoList](path)
+			if err := storage.Load(&list); err != nil {
+				return nil, err
+			}
+			if len(list.Todos) != 1 || list.Todos[0].ID != 4 || list.NextID != 5 {
+				t.Errorf("Unexpected list: %+v", list)
+			}
+			return func() error { return storage.Save(list) }, nil
+		}},
+		{"list", `{"nextId": 9, "todos": [{"ID": 4, "description": "Old", "status": "todo"}]}`, func(path string) (func() error, error) {
+			list, storage := TodoList{}, NewStorage[TodoList](path)
+			if err := storage.Load(&list); err != nil {
+				return nil, err
+			}
+			if list.NextID != 9 || list.Todos[0].ID != 4 {
+				t.Errorf("Unexpected list: %+v", list)
+			}
+			return func() error { return storage.Save(list) }, nil
+		}},
+		{"journal", `{"entries": [{"seq": 1, "command": "add", "changes": [{"id": 4, "after": {"ID": 4, "description": "Old"}}]}], "position": 1}`, func(path string) (func() error, error) {
+			j, storage := Journal{}, NewStorage[Journal](path)
+			if err := storage.Load(&j); err != nil {
+				return nil, err
+			}
+			if after := j.Entries[0].Changes[0].After; after == nil || after.ID != 4 {
+				t.Errorf("Unexpected journal: %+v", j)
+			}
+			return func() error { return storage.Save(j) }, nil
+		}},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, strings.ReplaceAll(tt.name, " ", "-")+".json")
+		os.WriteFile(path, []byte(tt.content), 0o644)
+		upgrade, err := tt.check(path)
+		if err != nil {
+			t.Errorf("%s: expected no error, got %v", tt.name, err)
+			continue
+		}
+
+		// Loading only upgrades in memory
+		if data, _ := os.ReadFile(path); string(data) != tt.content {
+			t.Errorf("%s: expected the file untouched by a load, got %s", tt.name, data)
+		}
+		if _, err := os.Stat(backupPath(path, 1)); err == nil {
+			t.Errorf("%s: expected no backup before saving", tt.name)
+		}
+
+		// Saving keeps the old file and writes the current version
+		if err := upgrade(); err != nil {
+			t.Errorf("%s: expected no error saving, got %v", tt.name, err)
+			continue
+		}
+		if backup, err := os.ReadFile(backupPath(path, 1)); err != nil || string(backup) != tt.content {
+			t.Errorf("%s: expected the old file backed up, got %q, %v", tt.name, backup, err)
+		}
+		data, _ := os.ReadFile(path)
+		if !strings.Contains(string(data), `"version": 2`) || strings.Contains(string(data), `"ID"`) {
+			t.Errorf("%s: expected the file upgraded, got %s", tt.name, data)
+		}
+	}
+}
+
+func TestStorageRejectsUnknownVersions(t *testing.T) {
+	dir := t.TempDir()
+	newer := filepath.Join(dir, "newer.json")
+	os.WriteFile(newer, []byte(`{"schema": "tasks", "version": 99, "data": {}}`), 0o644)
+	if err := NewStorage[TodoList](newer).Load(&TodoList{}); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("Expected error for a newer version, got %v", err)
+	}
+
+	other := filepath.Join(dir, "journal.json")
+	NewStorage[Journal](other).Save(Journal{})
+	if err := NewStorage[TodoList](other).Load(&TodoList{}); err == nil || !strings.Contains(err.Error(), "holds journal") {
+		t.Errorf("Expected error for another schema, got %v", err)
+	}
+}
+
+func TestMigrationsAreOrdered(t *testing.T) {
+	for schema, list := range migrations {
+		for i, m := range list {
+			if m.version != i+2 || m.summary == "" {
+				t.Errorf("Expected %s migration %d to version %d with a summary, got %d %q", schema, i, i+2, m.version, m.summary)
+			}
+		}
+	}
+}
+
+func TestAppUpgradesList(t *testing.T) {
+	app := newTestApp(t)
+	now := time.Now().UTC().Format(time.RFC3339)
+	os.WriteFile(app.File, []byte(`[{"ID": 1, "description": "Old", "status": "todo", "createdAt": "`+now+`"}]`), 0o644)
+
+	code, stdout, stderr := runCmd(t, app, "list", "--output", "json")
+	if code != 0 || !strings.Contains(stdout, `"Old"`) {
+		t.Fatalf("Expected the old list read, got %d %q %q", code, stdout, stderr)
+	}
+
+	// Read-only commands hold a shared lock and leave the file as it was
+	if data, _ := os.ReadFile(app.File); strings.Contains(string(data), `"version"`) {
+		t.Errorf("Expected the list untouched by list, got %s", data)
+	}
+	if _, err := os.Stat(backupPath(app.File, 1)); err == nil {
+		t.Errorf("Expected no backup from list")
+	}
+
+	runCmd(t, app, "add", "New")
+	if _, err := os.Stat(backupPath(app.File, 1)); err != nil {
+		t.Errorf("Expected a backup of the old list, got %v", err)
+	}
+	if todos := loadTodos(t, app); len(todos) != 2 || todos[1].ID != 2 {
+		t.Errorf("Expected the new task after the old one, got %+v", todos)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// maxRange caps the size of a single a-b range of task IDs.
+const maxRange = 10000
+
+// parseIDs parses a list of task IDs and ranges such as 3,5,8-12. The IDs
+// are returned sorted and without duplicates.
+func parseIDs(spec string) ([]int, error) {
+	var ids []int
+	for _, part := range strings.Split(spec, ",") {
+		from, to, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		first, err := strconv.Atoi(from)
+		if err != nil || first < 1 {
+			return nil, usageErrorf("invalid task id %q in %q", part, spec)
+		}
+		last := first
+		if isRange {
+			last, err = strconv.Atoi(to)
+			if err != nil || last < first {
+				return nil, usageErrorf("invalid range %q in %q", part, spec)
+			}
+			if last-first >= maxRange {
+				return nil, usageErrorf("range %q is too large", part)
+			}
+		}
+		for id := first; id <= last; id++ {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids), nil
+}
+
+// selector holds the flags bulk commands use to pick tasks besides a list
+// of IDs, and to preview or confirm the change.
+type selector struct {
+	statuses   statusList
+	priorities priorityList
+	project    projectFlag
+	tags       tagList
+	grep       string
+	olderThan  string
+	dryRun     bool
+	yes        bool
+}
+
+// addSelectorFlags defines the selector flags on fs. Flags the command
+// already defines to set values, such as update -priority, are left out.
+func addSelectorFlags(fs *flag.FlagSet) *selector {
+	s := &selector{}
+	values := []struct {
+		name, usage string
+		value       flag.Value
+	}{
+		{"status", "only tasks with these statuses (comma separated, repeatable)", &s.statuses},
+		{"priority", "only tasks with these priorities (comma separated, repeatable)", &s.priorities},
+		{"project", "only tasks in this project or its sub-projects", &s.project},
+		{"tag", "only tasks with all of these tags (comma separated, repeatable)", &s.tags},
+	}
+	for _, v := range values {
+		if fs.Lookup(v.name) == nil {
+			fs.Var(v.value, v.name, v.usage)
+		}
+	}
+	fs.StringVar(&s.grep, "grep", "", "only tasks whose description matches this regular expression")
+	fs.StringVar(&s.olderThan, "older-than", "", "only tasks not changed for this long, e.g. 30d or 2w")
+	fs.BoolVar(&s.dryRun, "dry-run", false, "show the tasks that would change without changing them")
+	fs.BoolVar(&s.yes, "yes", false, "change several tasks without asking for confirmation")
+	return s
+}
+
+func (s *selector) filtering() bool {
+	return len(s.statuses) > 0 || len(s.priorities) > 0 || s.project.name != "" || len(s.tags) > 0 ||
+		s.grep != "" || s.olderThan != ""
+}
+
+// match returns the matcher built from the selector flags.
+func (s *selector) match(now time.Time) (func(Todo) bool, error) {
+	filter := TodoFilter{Statuses: s.statuses, Priorities: s.priorities, Project: s.project.name, Tags: s.tags}
+	if s.grep != "" {
+		re, err := regexp.Compile("(?i)" + s.grep)
+		if err != nil {
+			return nil, usageErrorf("invalid -grep pattern: %v", err)
+		}
+		filter.Grep = re
+	}
+	var cutoff time.Time
+	if s.olderThan != "" {
+		age, err := parseDuration(s.olderThan)
+		if err != nil {
+			return nil, usageErrorf("invalid -older-than: %v", err)
+		}
+		cutoff = now.Add(-age)
+	}
+	return func(t Todo) bool {
+		return filter.Match(t) && (cutoff.IsZero() || lastChange(t).Before(cutoff))
+	}, nil
+}
+
+// selectIDs returns the tasks picked by spec, a list of IDs and ranges, and
+// the selector flags. Either may be empty, but not both. Every ID given
+// explicitly has to exist.
+func (s *selector) selectIDs(todos Todos, spec string, now time.Time) ([]int, error) {
+	if spec == "" && !s.filtering() {
+		return nil, usageErrorf("expected task ids or selector flags")
+	}
+	match, err := s.match(now)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int
+	if spec == "" {
+		for _, t := range todos {
+			if match(t) {
+				ids = append(ids, t.ID)
+			}
+		}
+		return ids, nil
+	}
+
+	explicit, err := parseIDs(spec)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range explicit {
+		index, err := todos.IndexOf(id)
+		if err != nil {
+			return nil, err
+		}
+		if match(todos[index]) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// interactive reports whether r is a terminal a confirmation can be read
+// from.
+var interactive = func(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// confirm decides whether a bulk command goes ahead with changing the
+// tasks ids: a dry run only lists them, and changing more than one task
+// needs -yes or a confirmation typed on the terminal. The change is
+// described as verb, the number of tasks and detail.
+func (s *selector) confirm(ctx *Context, verb, detail string, ids []int) (bool, error) {
+	if len(ids) == 0 {
+		fmt.Fprintln(ctx.Stdout, "No matching tasks")
+		return false, nil
+	}
+	if len(ids) == 1 && !s.dryRun || s.yes && !s.dryRun {
+		return true, nil
+	}
+
+	selected := Todos{}
+	for _, t := range ctx.List.Todos {
+		if slices.Contains(ids, t.ID) {
+			selected = append(selected, t)
+		}
+	}
+	count := fmt.Sprintf("%d %s%s", len(ids), plural(len(ids), "task"), detail)
+	fields, _ := lookupFields("id,description,status,updated")
+
+	if s.dryRun {
+		fmt.Fprintf(ctx.Stdout, "Would %s %s:\n", verb, count)
+		return false, renderTable(ctx.Stdout, selected, fields)
+	}
+	if !interactive(ctx.App.Stdin) {
+		return false, fmt.Errorf("refusing to %s %s without confirmation, pass -yes (or -dry-run to preview)", verb, count)
+	}
+
+	renderTable(ctx.Stdout, selected, fields)
+	fmt.Fprintf(ctx.Stdout, "%s %s? [y/N] ", strings.ToUpper(verb[:1])+verb[1:], count)
+	answer, _ := bufio.NewReader(ctx.App.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	fmt.Fprintln(ctx.Stdout, "Nothing changed")
+	return false, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseIDs(t *testing.T) {
+	ids, err := parseIDs("8-10,3, 5,9")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !equalIDs(ids, []int{3, 5, 8, 9, 10}) {
+		t.Errorf("Expected sorted unique IDs, got %v", ids)
+	}
+
+	for _, spec := range []string{"", "a", "0", "3,", "5-3", "1-x", "1-100000"} {
+		if _, err := parseIDs(spec); err == nil {
+			t.Errorf("Expected error for %q, got nil", spec)
+		}
+	}
+}
+
+func TestSelectorSelectIDs(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.Local)
+	old := now.AddDate(0, -2, 0)
+	todos := Todos{
+		{ID: 1, Description: "Old done", Status: StatusDone, CreatedAt: old, UpdatedAt: &old},
+		{ID: 2, Description: "Recent done", Status: StatusDone, CreatedAt: now},
+		{ID: 3, Description: "Old todo", Status: StatusTodo, CreatedAt: old},
+	}
+
+	s := &selector{statuses: statusList{StatusDone}, olderThan: "30d"}
+	if ids, err := s.selectIDs(todos, "", now); err != nil || !equalIDs(ids, []int{1}) {
+		t.Errorf("Expected old done task, got %v (%v)", ids, err)
+	}
+
+	// Explicit IDs are narrowed down by the selector flags
+	s = &selector{statuses: statusList{StatusDone}}
+	if ids, err := s.selectIDs(todos, "1-3", now); err != nil || !equalIDs(ids, []int{1, 2}) {
+		t.Errorf("Expected done tasks among 1-3, got %v (%v)", ids, err)
+	}
+	if _, err := s.selectIDs(todos, "2,7", now); err == nil {
+		t.Error("Expected error for a missing explicit ID, got nil")
+	}
+	if _, err := (&selector{}).selectIDs(todos, "", now); err == nil {
+		t.Error("Expected error without IDs or selectors, got nil")
+	}
+}
+
+func TestAppBulk(t *testing.T) {
+	app := newTestApp(t)
+	for _, text := range []string{"One", "Two", "Three", "Four #home", "Five #home"} {
+		runCmd(t, app, "add", text)
+	}
+
+	code, stdout, _ := runCmd(t, app, "mark", "1-3", "done", "--yes")
+	if code != ExitOK || stdout != "Marked task 1 as done\nMarked task 2 as done\nMarked task 3 as done\n" {
+		t.Errorf("Unexpected bulk mark: %d %q", code, stdout)
+	}
+
+	// Without a terminal more than one task needs -yes
+	code, _, stderr := runCmd(t, app, "delete", "--status", "done")
+	if code != ExitError || !strings.Contains(stderr, "refusing to delete 3 tasks") {
+		t.Errorf("Expected confirmation error, got %d %q", code, stderr)
+	}
+
+	code, stdout, _ = runCmd(t, app, "delete", "--status", "done", "--dry-run")
+	if code != ExitOK || !strings.HasPrefix(stdout, "Would delete 3 tasks:") || len(loadTodos(t, app)) != 5 {
+		t.Errorf("Expected a preview only, got %d %q", code, stdout)
+	}
+
+	runCmd(t, app, "update", "--tag", "chores", "--status", "done", "--yes")
+	if todos := loadTodos(t, app); len(todos[0].Tags) != 1 || len(todos[3].Tags) != 1 {
+		t.Errorf("Expected chores tag on done tasks only, got %+v", todos)
+	}
+	if code, _, _ = runCmd(t, app, "update", "4,5", "Renamed"); code != ExitUsage {
+		t.Errorf("Expected exit code %d for a description on two tasks, got %d", ExitUsage, code)
+	}
+
+	// A failure on one task leaves all of them unchanged
+	runCmd(t, app, "update", "4", "--blocked-by", "5")
+	if code, _, _ = runCmd(t, app, "mark", "4,5", "done", "--yes"); code != ExitError {
+		t.Errorf("Expected exit code %d, got %d", ExitError, code)
+	}
+	if todos := loadTodos(t, app); todos[3].Status != StatusTodo {
+		t.Errorf("Expected task 4 unchanged, got %s", todos[3].Status)
+	}
+
+	// On a terminal the change is confirmed by typing y
+	defer func(orig func(io.Reader) bool) { interactive = orig }(interactive)
+	interactive = func(io.Reader) bool { return true }
+
+	app.Stdin = strings.NewReader("n\n")
+	_, stdout, _ = runCmd(t, app, "delete", "--tag", "home")
+	if !strings.HasSuffix(stdout, "Delete 2 tasks? [y/N] Nothing changed\n") || len(loadTodos(t, app)) != 5 {
+		t.Errorf("Expected the delete declined, got %q", stdout)
+	}
+	app.Stdin = strings.NewReader("y\n")
+	runCmd(t, app, "delete", "--tag", "home")
+	if todos := loadTodos(t, app); len(todos) != 3 {
+		t.Errorf("Expected the home tasks deleted, got %+v", todos)
+	}
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// statusChange is a task entering a status. The empty status is the task
+// leaving the list unfinished, i.e. deleted into the archive.
+type statusChange struct {
+	at     time.Time
+	status Status
+}
+
+// journalTransitions collects the status changes recorded by the applied
+// entries of j, per task ID.
+func journalTransitions(j *Journal) map[int][]statusChange {
+	changes := map[int][]statusChange{}
+	for _, e := range j.Entries[:j.Position] {
+		for _, c := range e.Changes {
+			if c.Archived || c.After == nil {
+				continue
+			}
+			if c.Before == nil || c.Before.Status != c.After.Status {
+				changes[c.ID] = append(changes[c.ID], statusChange{e.At, c.After.Status})
+			}
+		}
+	}
+	return changes
+}
+
+// statusHistory returns the status changes of t, oldest first. The
+// timestamps of t give when it was created, started and finished; recorded
+// adds the transitions in between that the journal still holds.
+func statusHistory(t Todo, recorded []statusChange) []statusChange {
+	history := []statusChange{{t.CreatedAt, StatusTodo}}
+	if t.StartedAt != nil {
+		history = append(history, statusChange{*t.StartedAt, StatusInProgress})
+	}
+	if t.CompletedAt != nil && t.Status.Finished() {
+		history = append(history, statusChange{*t.CompletedAt, t.Status})
+	}
+	history = append(history, recorded...)
+	slices.SortStableFunc(history, func(a, b statusChange) int { return a.at.Compare(b.at) })
+
+	// The journal no longer knows when the task got its status
+	if last := history[len(history)-1]; last.status != t.Status {
+		at := last.at
+		if t.UpdatedAt != nil && t.UpdatedAt.After(at) {
+			at = *t.UpdatedAt
+		}
+		history = append(history, statusChange{at, t.Status})
+	}
+	if t.ArchivedAt != nil && !t.Status.Finished() {
+		history = append(history, statusChange{*t.ArchivedAt, ""})
+	}
+	return history
+}
+
+// statusAt returns the status a task with history had just before at, or
+// the empty status when it did not exist.
+func statusAt(history []statusChange, at time.Time) Status {
+	status := Status("")
+	for _, c := range history {
+		if !c.at.Before(at) {
+			break
+		}
+		status = c.status
+	}
+	return status
+}
+
+// weekStats counts the tasks created and done in the week from start.
+type weekStats struct {
+	start         time.Time
+	created, done int
+}
+
+// stats describes the tasks of a list and its archive between from and to.
+type stats struct {
+	from, to  time.Time
+	inList    map[Status]int
+	archived  map[Status]int
+	weeks     []weekStats
+	leadTime  time.Duration
+	completed int
+	oldest    Todos
+	samples   []time.Time
+	flow      []map[Status]int
+}
+
+// maxChartWidth bounds the columns of the chart; longer ranges put several
+// days in a column.
+const maxChartWidth = 60
+
+func newStats(todos, archived Todos, journal *Journal, from, to, now time.Time, oldest int) stats {
+	s := stats{from: from, to: to, inList: map[Status]int{}, archived: map[Status]int{}}
+	for week := startOfWeek(from); week.Before(to); week = week.AddDate(0, 0, 7) {
+		s.weeks = append(s.weeks, weekStats{start: week})
+	}
+	week := func(t time.Time) *weekStats {
+		if t.Before(from) || !t.Before(to) {
+			return nil
+		}
+		i := slices.IndexFunc(s.weeks, func(w weekStats) bool { return t.Before(w.start.AddDate(0, 0, 7)) })
+		return &s.weeks[i]
+	}
+
+	end := to
+	if now.Before(end) {
+		end = now
+	}
+	days := max(int(end.Sub(from).Hours()/24+0.5), 1)
+	step := (days + maxChartWidth - 1) / maxChartWidth
+	for at := from.AddDate(0, 0, step); ; at = at.AddDate(0, 0, step) {
+		if !at.Before(end) {
+			s.samples = append(s.samples, end)
+			break
+		}
+		s.samples = append(s.samples, at)
+	}
+	s.flow = make([]map[Status]int, len(s.samples))
+	for i := range s.flow {
+		s.flow[i] = map[Status]int{}
+	}
+
+	transitions := map[int][]statusChange{}
+	if journal != nil {
+		transitions = journalTransitions(journal)
+	}
+	var lead time.Duration
+	all := append(slices.Clip(todos), archived...)
+	for i, t := range all {
+		if i < len(todos) {
+			s.inList[t.Status]++
+			if !t.Status.Finished() {
+				s.oldest = append(s.oldest, t)
+			}
+		} else {
+			s.archived[t.Status]++
+		}
+
+		if w := week(t.CreatedAt); w != nil {
+			w.created++
+		}
+		if t.Status == StatusDone && t.CompletedAt != nil {
+			if w := week(*t.CompletedAt); w != nil {
+				w.done++
+				lead += t.CompletedAt.Sub(t.CreatedAt)
+				s.completed++
+			}
+		}
+
+		history := statusHistory(t, transitions[t.ID])
+		for j, at := range s.samples {
+			if status := statusAt(history, at); status != "" {
+				s.flow[j][status]++
+			}
+		}
+	}
+	if s.completed > 0 {
+		s.leadTime = lead / time.Duration(s.completed)
+	}
+
+	slices.SortStableFunc(s.oldest, func(a, b Todo) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	if len(s.oldest) > oldest {
+		s.oldest = s.oldest[:oldest]
+	}
+	return s
+}
+
+// formatAge formats a span of days the way formatDuration does hours.
+func formatAge(d time.Duration) string {
+	if d < 24*time.Hour {
+		return formatDuration(d)
+	}
+	return fmt.Sprintf("%dd%02dh", int(d.Hours())/24, int(d.Hours())%24)
+}
+
+func (s stats) render(w io.Writer, chart string, now time.Time) {
+	statuses := newTable(w)
+	statuses.SetHeaders("Status", "In list", "Archived")
+	listTotal, archivedTotal := 0, 0
+	for _, status := range Statuses {
+		statuses.AddRow(string(status), strconv.Itoa(s.inList[status]), strconv.Itoa(s.archived[status]))
+		listTotal += s.inList[status]
+		archivedTotal += s.archived[status]
+	}
+	statuses.SetFooters("Total", strconv.Itoa(listTotal), strconv.Itoa(archivedTotal))
+	statuses.Render()
+
+	fmt.Fprintln(w)
+	weeks := newTable(w)
+	weeks.SetHeaders("Week", "Created", "Done")
+	created, done := 0, 0
+	for _, week := range s.weeks {
+		weeks.AddRow(week.start.Format("Mon 2006-01-02"), strconv.Itoa(week.created), strconv.Itoa(week.done))
+		created += week.created
+		done += week.done
+	}
+	weeks.SetFooters("Total", strconv.Itoa(created), strconv.Itoa(done))
+	weeks.Render()
+
+	if s.completed > 0 {
+		fmt.Fprintf(w, "\nAverage lead time: %s from creation to done, over %d %s\n", formatAge(s.leadTime), s.completed, plural(s.completed, "task"))
+	} else {
+		fmt.Fprintln(w, "\nAverage lead time: no tasks done in this range")
+	}
+
+	if len(s.oldest) > 0 {
+		fmt.Fprintln(w, "\nOldest open tasks:")
+		oldest := newTable(w)
+		oldest.SetHeaders("id", "Task", "Status", "Age")
+		for _, t := range s.oldest {
+			oldest.AddRow(strconv.Itoa(t.ID), t.Description, string(t.Status), formatAge(now.Sub(t.CreatedAt)))
+		}
+		oldest.Render()
+	}
+
+	fmt.Fprintln(w)
+	s.renderChart(w, chart)
+}
+
+// chartSeries is a band of the chart, drawn with mark.
+type chartSeries struct {
+	name     string
+	mark     byte
+	statuses []Status
+}
+
+// charts are the bands of each chart, bottom first.
+var charts = map[string][]chartSeries{
+	"flow": {
+		{"done", '#', []Status{StatusDone}},
+		{"cancelled", '/', []Status{StatusCancelled}},
+		{"in-progress", '=', []Status{StatusInProgress}},
+		{"blocked", 'x', []Status{StatusBlocked}},
+		{"todo", '.', []Status{StatusTodo}},
+	},
+	"burndown": {
+		{"open", '#', []Status{StatusTodo, StatusInProgress, StatusBlocked}},
+	},
+}
+
+var chartTitles = map[string]string{
+	"flow":     "Cumulative flow",
+	"burndown": "Open tasks",
+}
+
+const chartHeight = 10
+
+// renderChart draws the tasks at each sample as stacked columns, one
+// character per sample, scaled to chartHeight rows.
+func (s stats) renderChart(w io.Writer, chart string) {
+	series := charts[chart]
+	heights := make([][]int, len(s.samples))
+	highest := 0
+	for i, counts := range s.flow {
+		total := 0
+		for _, band := range series {
+			for _, status := range band.statuses {
+				total += counts[status]
+			}
+			heights[i] = append(heights[i], total)
+		}
+		highest = max(highest, total)
+	}
+
+	last := s.samples[len(s.samples)-1]
+	fmt.Fprintf(w, "%s, %s to %s:\n\n", chartTitles[chart], s.from.Format("Mon 2006-01-02"), last.Add(-time.Nanosecond).Format("Mon 2006-01-02"))
+	if highest == 0 {
+		fmt.Fprintln(w, "No tasks in this range")
+		return
+	}
+
+	label := len(strconv.Itoa(highest))
+	for row := chartHeight; row > 0; row-- {
+		var line strings.Builder
+		for _, column := range heights {
+			mark := byte(' ')
+			for b, top := range column {
+				// Round so that a single task still shows on a tall chart
+				if (top*chartHeight+highest-1)/highest >= row {
+					mark = series[b].mark
+					break
+				}
+			}
+			line.WriteByte(mark)
+		}
+		y := ""
+		if row == chartHeight {
+			y = strconv.Itoa(highest)
+		}
+		fmt.Fprintf(w, "%*s |%s\n", label, y, strings.TrimRight(line.String(), " "))
+	}
+	fmt.Fprintf(w, "%*s +%s\n", label, "0", strings.Repeat("-", len(heights)))
+
+	first, end := s.from.Format("01-02"), last.Add(-time.Nanosecond).Format("01-02")
+	axis := first
+	if gap := len(heights) - len(first) - len(end); gap > 0 {
+		axis += strings.Repeat(" ", gap) + end
+	}
+	fmt.Fprintf(w, "%*s  %s\n\n", label, "", axis)
+
+	var legend []string
+	for b := len(series) - 1; b >= 0; b-- {
+		legend = append(legend, fmt.Sprintf("%c %s", series[b].mark, series[b].name))
+	}
+	fmt.Fprintln(w, strings.Join(legend, "  "))
+}
+
+func newStatsCmd() *Command {
+	flags := newFlagSet("stats")
+	since := &timeFlag{}
+	until := &timeFlag{endOfDay: true}
+	flags.Var(since, "since", "start of the range (default: four weeks ago)")
+	flags.Var(until, "until", "end of the range, included (default: today)")
+	oldest := flags.Int("oldest", 5, "show this many of the oldest open tasks")
+	chart := flags.String("chart", "flow", "chart to draw: "+strings.Join(sortedKeys(charts), ", "))
+
+	return &Command{
+		Name:     "stats",
+		Summary:  "Show tasks by status, weekly throughput, lead time, the oldest open tasks and a chart of their flow.",
+		Flags:    flags,
+		ReadOnly: true,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) > 0 {
+				return usageErrorf("unexpected argument %q", args[0])
+			}
+			if _, ok := charts[*chart]; !ok {
+				return usageErrorf("unknown chart %q (available: %s)", *chart, strings.Join(sortedKeys(charts), ", "))
+			}
+			if *oldest < 0 {
+				return usageErrorf("-oldest must not be negative")
+			}
+
+			now := time.Now()
+			from, to := since.Time, until.Time
+			if to.IsZero() {
+				to = startOfDay(now).AddDate(0, 0, 1)
+			}
+			if from.IsZero() {
+				from = startOfDay(to.Add(-time.Nanosecond)).AddDate(0, 0, -27)
+			}
+			if !to.After(from) {
+				return usageErrorf("-until must be after -since")
+			}
+			if from.After(now) {
+				return usageErrorf("-since must not be in the future")
+			}
+
+			archive, err := ctx.Archive()
+			if err != nil {
+				return err
+			}
+			path, err := ctx.App.StorePath()
+			if err != nil {
+				return err
+			}
+			journal, err := OpenJournal(journalPath(path))
+			if err != nil {
+				return err
+			}
+
+			newStats(ctx.List.Todos, archive.Todos, journal, from, to, now, *oldest).render(ctx.Stdout, *chart, now)
+			return nil
+		},
+	}
+}
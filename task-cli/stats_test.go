@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatusHistory(t *testing.T) {
+	monday := time.Date(2026, 10, 12, 9, 0, 0, 0, time.Local)
+	day := func(n int) time.Time { return monday.AddDate(0, 0, n) }
+	ptr := func(t time.Time) *time.Time { return &t }
+
+	todo := Todo{ID: 1, Status: StatusDone, CreatedAt: day(0), StartedAt: ptr(day(1)), CompletedAt: ptr(day(4))}
+	blocked := Todo{ID: 1, Status: StatusInProgress}
+	started := blocked
+	blocked.Status = StatusBlocked
+	j := &Journal{Entries: []JournalEntry{
+		{At: day(2), Changes: []Change{{ID: 1, Before: &started, After: &blocked}}},
+		{At: day(3), Changes: []Change{{ID: 1, Before: &blocked, After: &started}}},
+		{At: day(5), Changes: []Change{{ID: 1, Before: &started, After: &blocked}}}, // undone
+	}, Position: 2}
+
+	history := statusHistory(todo, journalTransitions(j)[1])
+	want := map[int]Status{-1: "", 0: StatusTodo, 1: StatusInProgress, 2: StatusBlocked, 3: StatusInProgress, 4: StatusDone, 6: StatusDone}
+	for n, status := range want {
+		if got := statusAt(history, day(n).Add(time.Hour)); got != status {
+			t.Errorf("Day %d: expected %q, got %q", n, status, got)
+		}
+	}
+
+	// Without the journal the current status counts from the last update,
+	// and a deleted task leaves the list
+	deleted := Todo{ID: 2, Status: StatusBlocked, CreatedAt: day(0), UpdatedAt: ptr(day(2)), ArchivedAt: ptr(day(3))}
+	history = statusHistory(deleted, nil)
+	if statusAt(history, day(1)) != StatusTodo || statusAt(history, day(2).Add(time.Hour)) != StatusBlocked || statusAt(history, day(4)) != "" {
+		t.Errorf("Unexpected history of a deleted task: %+v", history)
+	}
+}
+
+func TestNewStats(t *testing.T) {
+	monday := time.Date(2026, 10, 5, 9, 0, 0, 0, time.Local)
+	day := func(n int) time.Time { return monday.AddDate(0, 0, n) }
+	ptr := func(t time.Time) *time.Time { return &t }
+
+	todos := Todos{
+		{ID: 1, Description: "Plan", Status: StatusDone, CreatedAt: day(0), CompletedAt: ptr(day(2))},
+		{ID: 2, Description: "Build", Status: StatusInProgress, CreatedAt: day(1), StartedAt: ptr(day(3))},
+		{ID: 3, Description: "Write", Status: StatusTodo, CreatedAt: day(8)},
+		{ID: 4, Description: "Old", Status: StatusTodo, CreatedAt: day(-30)},
+	}
+	archived := Todos{
+		{ID: 5, Description: "Ship", Status: StatusDone, CreatedAt: day(2), CompletedAt: ptr(day(9)), ArchivedAt: ptr(day(10))},
+	}
+	from, to, now := startOfDay(day(0)), startOfDay(day(14)), day(11)
+	s := newStats(todos, archived, nil, from, to, now, 2)
+
+	if s.inList[StatusTodo] != 2 || s.inList[StatusDone] != 1 || s.archived[StatusDone] != 1 {
+		t.Errorf("Unexpected counts: %v, %v", s.inList, s.archived)
+	}
+	if len(s.weeks) != 2 || s.weeks[0].created != 3 || s.weeks[0].done != 1 || s.weeks[1].created != 1 || s.weeks[1].done != 1 {
+		t.Errorf("Unexpected weeks: %+v", s.weeks)
+	}
+	if s.completed != 2 || s.leadTime != 4*24*time.Hour+12*time.Hour {
+		t.Errorf("Expected a lead time of 4.5 days over 2 tasks, got %v over %d", s.leadTime, s.completed)
+	}
+	if len(s.oldest) != 2 || s.oldest[0].ID != 4 || s.oldest[1].ID != 2 {
+		t.Errorf("Expected the two oldest open tasks, got %+v", s.oldest)
+	}
+
+	// The chart stops at now and counts the archived task as done
+	if len(s.samples) != 12 || !s.samples[11].Equal(now) {
+		t.Fatalf("Expected a sample per day up to now, got %v", s.samples)
+	}
+	if last := s.flow[11]; last[StatusDone] != 2 || last[StatusInProgress] != 1 || last[StatusTodo] != 2 {
+		t.Errorf("Unexpected flow at the end: %v", last)
+	}
+	if first := s.flow[0]; first[StatusTodo] != 2 || first[StatusDone] != 0 {
+		t.Errorf("Unexpected flow after the first day: %v", first)
+	}
+}
+
+func TestRenderChart(t *testing.T) {
+	from := time.Date(2026, 10, 12, 0, 0, 0, 0, time.Local)
+	s := stats{from: from, samples: []time.Time{from.AddDate(0, 0, 1), from.AddDate(0, 0, 2), from.AddDate(0, 0, 3)}, flow: []map[Status]int{
+		{StatusTodo: 2},
+		{StatusTodo: 1, StatusDone: 1},
+		{StatusDone: 4},
+	}}
+	var buf bytes.Buffer
+	s.renderChart(&buf, "flow")
+	lines := strings.Split(buf.String(), "\n")
+	want := []string{
+		"Cumulative flow, Mon 2026-10-12 to Wed 2026-10-14:",
+		"",
+		"4 |  #",
+		"  |  #",
+		"  |  #",
+		"  |  #",
+		"  |  #",
+		"  |..#",
+		"  |..#",
+		"  |.##",
+		"  |.##",
+		"  |.##",
+		"0 +---",
+		"   10-12",
+	}
+	for i, w := range want {
+		if i >= len(lines) || lines[i] != w {
+			t.Fatalf("Expected the chart\n%s\ngot\n%s", strings.Join(want, "\n"), buf.String())
+		}
+	}
+	if !strings.Contains(buf.String(), ". todo  x blocked  = in-progress  / cancelled  # done") {
+		t.Errorf("Expected the legend, got %q", buf.String())
+	}
+}
+
+func TestAppStats(t *testing.T) {
+	app := newTestApp(t)
+	runCmd(t, app, "add", "Plan")
+	runCmd(t, app, "add", "Build")
+	runCmd(t, app, "mark", "1", "done")
+	runCmd(t, app, "mark", "2", "blocked")
+
+	code, stdout, stderr := runCmd(t, app, "stats", "-chart", "burndown")
+	if code != 0 {
+		t.Fatalf("Expected stats, got %d %q", code, stderr)
+	}
+	for _, want := range []string{"│ blocked     │ 1", "over 1 task", "│ 2  │ Build", "Open tasks,", "# open"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected %q in the stats, got:\n%s", want, stdout)
+		}
+	}
+
+	if code, _, _ := runCmd(t, app, "stats", "-chart", "pie"); code != 2 {
+		t.Errorf("Expected usage error for an unknown chart, got %d", code)
+	}
+	if code, _, _ := runCmd(t, app, "stats", "-since", "today", "-until", "yesterday"); code != 2 {
+		t.Errorf("Expected usage error for an empty range, got %d", code)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusTodo       Status = "todo"
+	StatusInProgress Status = "in-progress"
+	StatusBlocked    Status = "blocked"
+	StatusDone       Status = "done"
+	StatusCancelled  Status = "cancelled"
+)
+
+// Statuses lists every valid status in workflow order.
+var Statuses = []Status{StatusTodo, StatusInProgress, StatusBlocked, StatusDone, StatusCancelled}
+
+// statusTransitions maps a status to the statuses a task may move to next.
+// Finished tasks have to be reopened before they can be worked on again.
+var statusTransitions = map[Status][]Status{
+	StatusTodo:       {StatusInProgress, StatusBlocked, StatusDone, StatusCancelled},
+	StatusInProgress: {StatusTodo, StatusBlocked, StatusDone, StatusCancelled},
+	StatusBlocked:    {StatusTodo, StatusInProgress, StatusCancelled},
+	StatusDone:       {StatusTodo},
+	StatusCancelled:  {StatusTodo},
+}
+
+// Transition records a single status change of a task.
+type Transition struct {
+	From Status    `json:"from"`
+	To   Status    `json:"to"`
+	At   time.Time `json:"at"`
+}
+
+func statusNames() string {
+	names := make([]string, len(Statuses))
+	for i, s := range Statuses {
+		names[i] = string(s)
+	}
+	return strings.Join(names, ", ")
+}
+
+func allowedNames(s Status) string {
+	names := []string{}
+	for _, next := range statusTransitions[s] {
+		names = append(names, string(next))
+	}
+	return strings.Join(names, ", ")
+}
+
+// ParseStatus validates a status typed by the user.
+func ParseStatus(s string) (Status, error) {
+	status := Status(strings.ToLower(strings.TrimSpace(s)))
+	if status.Valid() {
+		return status, nil
+	}
+
+	if suggestion, ok := closestStatus(string(status)); ok {
+		return "", fmt.Errorf("unknown status %q, did you mean %q? (valid: %s)", s, suggestion, statusNames())
+	}
+	return "", fmt.Errorf("unknown status %q (valid: %s)", s, statusNames())
+}
+
+func (s Status) Valid() bool {
+	_, ok := statusTransitions[s]
+	return ok
+}
+
+// Finished reports whether no more work is expected on a task.
+func (s Status) Finished() bool {
+	return s == StatusDone || s == StatusCancelled
+}
+
+// CanTransitionTo reports whether a task may move from s to next. Tasks
+// with a status from before statuses were validated may move anywhere.
+func (s Status) CanTransitionTo(next Status) bool {
+	allowed, ok := statusTransitions[s]
+	if !ok {
+		return next.Valid()
+	}
+	for _, a := range allowed {
+		if a == next {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeStatus maps a stored status onto a valid one where the intent
+// is clear, e.g. the "in-progres" typo written by older versions.
+func normalizeStatus(s Status) Status {
+	if s == "" {
+		return StatusTodo
+	}
+	lower := Status(strings.ToLower(string(s)))
+	if lower.Valid() {
+		return lower
+	}
+	if suggestion, ok := closestStatus(string(lower)); ok {
+		return suggestion
+	}
+	return s
+}
+
+// closestStatus returns the valid status within two edits of s.
+func closestStatus(s string) (Status, bool) {
+	best, bestDist := Status(""), 3
+	for _, status := range Statuses {
+		if d := editDistance(s, string(status)); d < bestDist {
+			best, bestDist = status, d
+		}
+	}
+	return best, best != ""
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
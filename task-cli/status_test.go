@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseStatus(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  Status
+	}{
+		{"todo", StatusTodo},
+		{"in-progress", StatusInProgress},
+		{"Blocked", StatusBlocked},
+		{" done ", StatusDone},
+		{"cancelled", StatusCancelled},
+	}
+
+	for _, tc := range testCases {
+		got, err := ParseStatus(tc.input)
+		if err != nil {
+			t.Errorf("%q: expected no error, got %v", tc.input, err)
+		}
+		if got != tc.want {
+			t.Errorf("%q: expected %s, got %s", tc.input, tc.want, got)
+		}
+	}
+}
+
+func TestParseStatusUnknown(t *testing.T) {
+	// Close typos get a suggestion
+	_, err := ParseStatus("in-progres")
+	if err == nil || !strings.Contains(err.Error(), `did you mean "in-progress"`) {
+		t.Errorf("Expected suggestion for typo, got %v", err)
+	}
+
+	// Everything else lists the valid values
+	_, err = ParseStatus("someday")
+	if err == nil || !strings.Contains(err.Error(), "valid: todo, in-progress, blocked, done, cancelled") {
+		t.Errorf("Expected list of valid statuses, got %v", err)
+	}
+	if strings.Contains(err.Error(), "did you mean") {
+		t.Errorf("Expected no suggestion for unrelated value, got %v", err)
+	}
+}
+
+func TestStatusTransitions(t *testing.T) {
+	testCases := []struct {
+		from, to Status
+		allowed  bool
+	}{
+		{StatusTodo, StatusInProgress, true},
+		{StatusTodo, StatusDone, true},
+		{StatusInProgress, StatusBlocked, true},
+		{StatusBlocked, StatusInProgress, true},
+		{StatusBlocked, StatusDone, false},
+		{StatusDone, StatusTodo, true},
+		{StatusDone, StatusInProgress, false},
+		{StatusCancelled, StatusDone, false},
+		{Status("legacy"), StatusDone, true},
+		{StatusTodo, Status("bogus"), false},
+	}
+
+	for _, tc := range testCases {
+		if got := tc.from.CanTransitionTo(tc.to); got != tc.allowed {
+			t.Errorf("%s -> %s: expected %v, got %v", tc.from, tc.to, tc.allowed, got)
+		}
+	}
+}
+
+func TestAppMarkValidatesStatus(t *testing.T) {
+	app := newTestApp(t)
+	runCmd(t, app, "add", "Task 1")
+
+	code, _, stderr := runCmd(t, app, "mark", "1", "in-progres")
+	if code != ExitUsage {
+		t.Errorf("Expected exit code %d, got %d", ExitUsage, code)
+	}
+	if !strings.Contains(stderr, "did you mean") {
+		t.Errorf("Expected suggestion on stderr, got '%s'", stderr)
+	}
+
+	runCmd(t, app, "mark", "1", "done")
+	code, _, stderr = runCmd(t, app, "mark", "1", "blocked")
+	if code != ExitError {
+		t.Errorf("Expected exit code %d, got %d", ExitError, code)
+	}
+	if !strings.Contains(stderr, "cannot change task 1 from done to blocked") {
+		t.Errorf("Expected transition error on stderr, got '%s'", stderr)
+	}
+
+	todos := loadTodos(t, app)
+	if todos[0].Status != StatusDone || todos[0].CompletedAt == nil {
+		t.Errorf("Expected task to stay done with CompletedAt, got %+v", todos[0])
+	}
+}
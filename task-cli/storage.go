@@ -2,7 +2,11 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
 )
 
 type Storage[T any] struct {
@@ -13,16 +17,30 @@ func NewStorage[T any](fileName string) *Storage[T] {
 	return &Storage[T]{FileName: fileName}
 }
 
+// Save writes data to the file, versioned types in their envelope. A file
+// written in an older version is kept in a backup next to it first.
 func (s *Storage[T]) Save(data T) error {
-	fileData, err := json.MarshalIndent(data, "", "   ")
+	var fileData []byte
+	var err error
+	if v, ok := any(data).(versioned); ok {
+		if err := s.backupOlder(v.schema()); err != nil {
+			return fmt.Errorf("upgrading %s: %w", s.FileName, err)
+		}
+		fileData, err = encodeStored(v.schema(), data)
+	} else {
+		fileData, err = json.MarshalIndent(data, "", "   ")
+	}
 
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(s.FileName, fileData, 0644)
+	return writeFileAtomic(s.FileName, fileData, 0644)
 }
 
+// Load reads the file into data. A versioned file written in an older
+// version is upgraded in memory only: readers hold a shared lock, so the
+// upgrade is written by the next Save.
 func (s *Storage[T]) Load(data *T) error {
 	fileData, err := os.ReadFile(s.FileName)
 
@@ -30,5 +48,63 @@ func (s *Storage[T]) Load(data *T) error {
 		return err
 	}
 
-	return json.Unmarshal(fileData, data)
+	v, ok := any(data).(versioned)
+	if !ok {
+		return json.Unmarshal(fileData, data)
+	}
+	raw, _, err := decodeStored(fileData, v.schema())
+	if err != nil {
+		return fmt.Errorf("%s: %w", s.FileName, err)
+	}
+	return json.Unmarshal(raw, data)
+}
+
+// backupOlder keeps the file in a backup when it holds schema in an older
+// version. Damaged files are left to doctor.
+func (s *Storage[T]) backupOlder(schema string) error {
+	data, err := os.ReadFile(s.FileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, version, err := decodeStored(data, schema)
+	if err != nil || version == schemaVersion(schema) {
+		return nil
+	}
+	return backupFile(s.FileName, version, data)
+}
+
+// writeFileAtomic writes data to a temporary file next to fileName, syncs it
+// and renames it over fileName, so readers and crashes only ever see the old
+// or the new content.
+func writeFileAtomic(fileName string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(fileName)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(fileName)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, fileName); err != nil {
+		return err
+	}
+
+	return syncDir(dir)
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/json"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -325,3 +326,58 @@ func TestStorageWithDifferentTypes(t *testing.T) {
 		t.Errorf("Expected %d ints, got %d", len(testInts), len(loadedInts))
 	}
 }
+
+func TestStorageSaveIsAtomic(t *testing.T) {
+	dir := t.TempDir()
+	testFile := filepath.Join(dir, "todos.json")
+	storage := NewStorage[Todos](testFile)
+
+	original := Todos{{ID: 1, Description: "Task 1", Status: "todo", CreatedAt: time.Now()}}
+	if err := storage.Save(original); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+
+	// Overwrite with new content
+	updated := Todos{
+		{ID: 1, Description: "Task 1", Status: "done", CreatedAt: time.Now()},
+		{ID: 2, Description: "Task 2", Status: "todo", CreatedAt: time.Now()},
+	}
+	if err := storage.Save(updated); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+
+	// No temporary files are left behind next to the store
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		names := []string{}
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("Expected only the store file, got %v", names)
+	}
+
+	loaded := Todos{}
+	if err := storage.Load(&loaded); err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	if len(loaded) != 2 || loaded[0].Status != "done" {
+		t.Errorf("Expected updated content, got %v", loaded)
+	}
+}
+
+func TestStorageSaveKeepsPermissions(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "todos.json")
+	storage := NewStorage[[]int](testFile)
+
+	if err := storage.Save([]int{1}); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+
+	info, err := os.Stat(testFile)
+	if err != nil {
+		t.Fatalf("Failed to stat: %v", err)
+	}
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("Expected mode 0644, got %v", info.Mode().Perm())
+	}
+}
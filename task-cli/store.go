@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Store persists a TodoList. Load returns an error wrapping fs.ErrNotExist
+// when nothing has been saved yet.
+type Store interface {
+	Load(list *TodoList) error
+	Save(list TodoList) error
+}
+
+type storeBackend struct {
+	ext  string
+	open func(path string) Store
+}
+
+var storeBackends = map[string]storeBackend{
+	"json": {
+		ext:  ".json",
+		open: func(path string) Store { return NewStorage[TodoList](path) },
+	},
+	"jsonl": {
+		ext:  ".jsonl",
+		open: func(path string) Store { return NewJSONLStore(path) },
+	},
+	"db": {
+		ext:  ".db",
+		open: func(path string) Store { return NewDBStore(path) },
+	},
+}
+
+// StoreBackends returns the names of the available backends.
+func StoreBackends() []string {
+	names := make([]string, 0, len(storeBackends))
+	for name := range storeBackends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StorePath returns the file used by the named backend. An empty path
+// selects the named list in the data directory, and an empty list the
+// default list.
+func StorePath(backend, path, list string) (string, error) {
+	b, ok := storeBackends[backend]
+	if !ok {
+		return "", fmt.Errorf("unknown store %q (available: %s)", backend, strings.Join(StoreBackends(), ", "))
+	}
+	if path != "" {
+		return path, nil
+	}
+
+	if list == "" {
+		name, err := DefaultList()
+		if err != nil {
+			return "", err
+		}
+		list = name
+	}
+	if err := checkListName(list); err != nil {
+		return "", err
+	}
+	dir, err := listsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, list+b.ext), nil
+}
+
+// OpenStore returns the store for the named backend, see StorePath. An
+// empty path opens the default list.
+func OpenStore(backend, path string) (Store, error) {
+	path, err := StorePath(backend, path, "")
+	if err != nil {
+		return nil, err
+	}
+	return storeBackends[backend].open(path), nil
+}
+
+func newMigrateStoreCmd() *Command {
+	flags := newFlagSet("migrate-store")
+	from := flags.String("from", "", "source backend: "+strings.Join(StoreBackends(), ", "))
+	to := flags.String("to", "", "destination backend: "+strings.Join(StoreBackends(), ", "))
+	fromFile := flags.String("from-file", "", "source file (default: the current list)")
+	toFile := flags.String("to-file", "", "destination file (default: the current list)")
+	force := flags.Bool("force", false, "overwrite a destination that already holds tasks")
+
+	return &Command{
+		Name:    "migrate-store",
+		Summary: "Copy all tasks from one storage backend to another.",
+		Flags:   flags,
+		NoStore: true,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) > 0 {
+				return usageErrorf("unexpected argument %q", args[0])
+			}
+			if *from == "" || *to == "" {
+				return usageErrorf("both -from and -to are required")
+			}
+			for _, backend := range []string{*from, *to} {
+				if _, ok := storeBackends[backend]; !ok {
+					return usageErrorf("unknown store %q (available: %s)", backend, strings.Join(StoreBackends(), ", "))
+				}
+			}
+
+			if *from == *to && *fromFile == *toFile {
+				return usageErrorf("source and destination are the same store")
+			}
+
+			srcPath, err := StorePath(*from, *fromFile, ctx.App.ListName())
+			if err != nil {
+				return err
+			}
+			dstPath, err := StorePath(*to, *toFile, ctx.App.ListName())
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(dstPath), 0o755); err != nil {
+				return err
+			}
+			src, _ := OpenStore(*from, srcPath)
+			dst, _ := OpenStore(*to, dstPath)
+
+			srcLock, err := LockStore(srcPath, false)
+			if err != nil {
+				return err
+			}
+			defer srcLock.Unlock()
+			dstLock, err := LockStore(dstPath, true)
+			if err != nil {
+				return err
+			}
+			defer dstLock.Unlock()
+
+			list := TodoList{}
+			if err := src.Load(&list); err != nil {
+				return fmt.Errorf("reading %s store: %w", *from, err)
+			}
+
+			existing := TodoList{}
+			err = dst.Load(&existing)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("reading %s store: %w", *to, err)
+			}
+			if len(existing.Todos) > 0 && !*force {
+				return fmt.Errorf("%s store already holds %d tasks; use -force to overwrite it", *to, len(existing.Todos))
+			}
+
+			if err := dst.Save(list); err != nil {
+				return fmt.Errorf("writing %s store: %w", *to, err)
+			}
+			fmt.Fprintf(ctx.Stdout, "Migrated %d tasks from %s to %s\n", len(list.Todos), *from, *to)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// DBStore keeps tasks in a single-file embedded database, one record per
+// task keyed by ID, plus a metadata record for the list itself.
+type DBStore struct {
+	FileName string
+}
+
+var (
+	dbMetaBucket  = []byte("meta")
+	dbTodosBucket = []byte("todos")
+	dbListKey     = []byte("list")
+)
+
+func NewDBStore(fileName string) *DBStore {
+	return &DBStore{FileName: fileName}
+}
+
+func (s *DBStore) open() (*bolt.DB, error) {
+	return bolt.Open(s.FileName, 0644, &bolt.Options{Timeout: time.Second})
+}
+
+func (s *DBStore) Load(list *TodoList) error {
+	// bolt.Open would create the file, so check for it first.
+	if _, err := os.Stat(s.FileName); err != nil {
+		return err
+	}
+
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	loaded := TodoList{}
+	err = db.View(func(tx *bolt.Tx) error {
+		if meta := tx.Bucket(dbMetaBucket); meta != nil {
+			if data := meta.Get(dbListKey); data != nil {
+				if err := json.Unmarshal(data, &loaded); err != nil {
+					return fmt.Errorf("%s: list metadata: %w", s.FileName, err)
+				}
+			}
+		}
+
+		todos := tx.Bucket(dbTodosBucket)
+		if todos == nil {
+			return nil
+		}
+		return todos.ForEach(func(k, v []byte) error {
+			var t Todo
+			if err := json.Unmarshal(v, &t); err != nil {
+				return fmt.Errorf("%s: task %d: %w", s.FileName, binary.BigEndian.Uint64(k), err)
+			}
+			loaded.Todos = append(loaded.Todos, t)
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	*list = loaded
+	return nil
+}
+
+func (s *DBStore) Save(list TodoList) error {
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	meta := list
+	meta.Todos = nil
+	metaData, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	return db.Update(func(tx *bolt.Tx) error {
+		metaBucket, err := tx.CreateBucketIfNotExists(dbMetaBucket)
+		if err != nil {
+			return err
+		}
+		if err := metaBucket.Put(dbListKey, metaData); err != nil {
+			return err
+		}
+
+		if tx.Bucket(dbTodosBucket) != nil {
+			if err := tx.DeleteBucket(dbTodosBucket); err != nil {
+				return err
+			}
+		}
+		todos, err := tx.CreateBucket(dbTodosBucket)
+		if err != nil {
+			return err
+		}
+		for _, t := range list.Todos {
+			data, err := json.Marshal(t)
+			if err != nil {
+				return err
+			}
+			if err := todos.Put(dbKey(t.ID), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func dbKey(id int) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(id))
+	return key
+}
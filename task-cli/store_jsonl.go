@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+)
+
+// JSONLStore keeps an append-only log of changes, one JSON record per line.
+// Load replays the log; Save appends only what differs from the replayed
+// state.
+type JSONLStore struct {
+	FileName string
+}
+
+type jsonlRecord struct {
+	Op   string    `json:"op"`
+	ID   int       `json:"id,omitempty"`
+	Todo *Todo     `json:"todo,omitempty"`
+	Meta *TodoList `json:"meta,omitempty"`
+}
+
+func NewJSONLStore(fileName string) *JSONLStore {
+	return &JSONLStore{FileName: fileName}
+}
+
+func (s *JSONLStore) Load(list *TodoList) error {
+	loaded, _, err := s.replay()
+	if err != nil {
+		return err
+	}
+
+	*list = loaded
+	return nil
+}
+
+// replay rebuilds the list from the log. It also returns the length of the
+// log up to the last complete record: a final line without a newline is
+// what a crash during Save leaves behind and is ignored.
+func (s *JSONLStore) replay() (TodoList, int64, error) {
+	data, err := os.ReadFile(s.FileName)
+	if err != nil {
+		return TodoList{}, 0, err
+	}
+
+	loaded := TodoList{}
+	var valid int64
+	for line := 1; len(data) > 0; line++ {
+		end := bytes.IndexByte(data, '\n')
+		if end < 0 {
+			break
+		}
+		record := bytes.TrimSpace(data[:end])
+		data = data[end+1:]
+		valid += int64(end + 1)
+
+		if len(record) == 0 {
+			continue
+		}
+
+		var rec jsonlRecord
+		if err := json.Unmarshal(record, &rec); err != nil {
+			return TodoList{}, 0, fmt.Errorf("%s:%d: %w", s.FileName, line, err)
+		}
+		if err := loaded.apply(rec); err != nil {
+			return TodoList{}, 0, fmt.Errorf("%s:%d: %w", s.FileName, line, err)
+		}
+	}
+
+	return loaded, valid, nil
+}
+
+func (s *JSONLStore) Save(list TodoList) error {
+	previous, valid, err := s.replay()
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	records := jsonlDiff(previous, list)
+	if len(records) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, rec := range records {
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	file, err := os.OpenFile(s.FileName, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	// Drop a torn record left by an earlier crash before appending.
+	if err := file.Truncate(valid); err != nil {
+		file.Close()
+		return err
+	}
+	if _, err := file.WriteAt(buf.Bytes(), valid); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (l *TodoList) apply(rec jsonlRecord) error {
+	switch rec.Op {
+	case "meta":
+		if rec.Meta == nil {
+			return fmt.Errorf("meta record without data")
+		}
+		todos := l.Todos
+		*l = *rec.Meta
+		l.Todos = todos
+	case "put":
+		if rec.Todo == nil {
+			return fmt.Errorf("put record without todo")
+		}
+		if index, err := l.IndexOf(rec.Todo.ID); err == nil {
+			l.Todos[index] = *rec.Todo
+		} else {
+			l.Todos = append(l.Todos, *rec.Todo)
+		}
+	case "delete":
+		if index, err := l.IndexOf(rec.ID); err == nil {
+			l.Todos = append(l.Todos[:index], l.Todos[index+1:]...)
+		}
+	default:
+		return fmt.Errorf("unknown op %q", rec.Op)
+	}
+	return nil
+}
+
+// jsonlDiff returns the records that turn previous into next.
+func jsonlDiff(previous, next TodoList) []jsonlRecord {
+	var records []jsonlRecord
+
+	prevMeta, nextMeta := previous, next
+	prevMeta.Todos, nextMeta.Todos = nil, nil
+	if !reflect.DeepEqual(prevMeta, nextMeta) {
+		records = append(records, jsonlRecord{Op: "meta", Meta: &nextMeta})
+	}
+
+	for _, t := range previous.Todos {
+		if _, err := next.IndexOf(t.ID); err != nil {
+			records = append(records, jsonlRecord{Op: "delete", ID: t.ID})
+		}
+	}
+
+	for i := range next.Todos {
+		t := next.Todos[i]
+		if index, err := previous.IndexOf(t.ID); err == nil && sameTodo(previous.Todos[index], t) {
+			continue
+		}
+		records = append(records, jsonlRecord{Op: "put", Todo: &t})
+	}
+
+	return records
+}
+
+// sameTodo compares todos by their stored form, so time values that only
+// differ in monotonic clock readings are considered equal.
+func sameTodo(a, b Todo) bool {
+	da, errA := json.Marshal(a)
+	db, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(da, db)
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStoreBackendsRoundTrip(t *testing.T) {
+	for _, backend := range StoreBackends() {
+		t.Run(backend, func(t *testing.T) {
+			store, err := OpenStore(backend, filepath.Join(t.TempDir(), "todos"+storeBackends[backend].ext))
+			if err != nil {
+				t.Fatalf("Failed to open store: %v", err)
+			}
+
+			// Loading before anything is saved reports a missing file
+			var empty TodoList
+			if err := store.Load(&empty); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Expected fs.ErrNotExist, got %v", err)
+			}
+
+			list := TodoList{}
+			list.add("Task 1")
+			list.add("Task 2")
+			list.add("Task 3")
+			list.StatusChange("done", 2)
+
+			if err := store.Save(list); err != nil {
+				t.Fatalf("Failed to save: %v", err)
+			}
+
+			// Save again after a delete and an update
+			list.delete(1)
+			list.update("Task 3 updated", 3)
+			if err := store.Save(list); err != nil {
+				t.Fatalf("Failed to save: %v", err)
+			}
+
+			var loaded TodoList
+			if err := store.Load(&loaded); err != nil {
+				t.Fatalf("Failed to load: %v", err)
+			}
+
+			if loaded.NextID != 4 {
+				t.Errorf("Expected NextID 4, got %d", loaded.NextID)
+			}
+			if len(loaded.Todos) != 2 {
+				t.Fatalf("Expected 2 todos, got %d", len(loaded.Todos))
+			}
+			if loaded.Todos[0].ID != 2 || loaded.Todos[0].Status != "done" {
+				t.Errorf("Expected task 2 to be done, got %+v", loaded.Todos[0])
+			}
+			if loaded.Todos[0].UpdatedAt == nil {
+				t.Error("Expected UpdatedAt to survive the round trip")
+			}
+			if loaded.Todos[1].Description != "Task 3 updated" {
+				t.Errorf("Expected 'Task 3 updated', got '%s'", loaded.Todos[1].Description)
+			}
+		})
+	}
+}
+
+func TestJSONLStoreAppendsOnlyChanges(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "todos.jsonl")
+	store := NewJSONLStore(testFile)
+
+	list := TodoList{}
+	list.add("Task 1")
+	list.add("Task 2")
+	store.Save(list)
+
+	data, _ := os.ReadFile(testFile)
+	before := strings.Count(string(data), "\n")
+
+	// Saving an unchanged list writes nothing
+	store.Save(list)
+	data, _ = os.ReadFile(testFile)
+	if got := strings.Count(string(data), "\n"); got != before {
+		t.Errorf("Expected %d lines after no-op save, got %d", before, got)
+	}
+
+	// A single update appends a single record
+	list.update("Task 2 updated", 2)
+	store.Save(list)
+	data, _ = os.ReadFile(testFile)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != before+1 {
+		t.Fatalf("Expected %d lines, got %d", before+1, len(lines))
+	}
+	if !strings.Contains(lines[len(lines)-1], "Task 2 updated") {
+		t.Errorf("Expected last record to hold the update, got %s", lines[len(lines)-1])
+	}
+}
+
+func TestJSONLStoreLoadInvalidLine(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "todos.jsonl")
+	os.WriteFile(testFile, []byte(`{"op":"put","todo":{"ID":1}}`+"\n"+`{"op":"explode"}`+"\n"), 0644)
+
+	var list TodoList
+	err := NewJSONLStore(testFile).Load(&list)
+	if err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Errorf("Expected error pointing at line 2, got %v", err)
+	}
+}
+
+func TestJSONLStoreIgnoresTornRecord(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "todos.jsonl")
+	store := NewJSONLStore(testFile)
+
+	list := TodoList{}
+	list.add("Task 1")
+	store.Save(list)
+
+	// Simulate a crash in the middle of appending a record
+	file, _ := os.OpenFile(testFile, os.O_WRONLY|os.O_APPEND, 0644)
+	file.WriteString(`{"op":"put","todo":{"ID":2,"desc`)
+	file.Close()
+
+	var loaded TodoList
+	if err := store.Load(&loaded); err != nil {
+		t.Fatalf("Expected torn record to be ignored, got %v", err)
+	}
+	if len(loaded.Todos) != 1 {
+		t.Errorf("Expected 1 todo, got %d", len(loaded.Todos))
+	}
+
+	// The next save replaces the torn record instead of appending after it
+	list.add("Task 2")
+	if err := store.Save(list); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+	if err := store.Load(&loaded); err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	if len(loaded.Todos) != 2 || loaded.Todos[1].Description != "Task 2" {
+		t.Errorf("Expected 2 todos, got %v", loaded.Todos)
+	}
+}
+
+func TestOpenStoreUnknownBackend(t *testing.T) {
+	if _, err := OpenStore("csv", ""); err == nil {
+		t.Error("Expected error for unknown backend, got nil")
+	}
+}
+
+func TestOpenStoreDefaultFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dir)
+	store, _ := OpenStore("jsonl", "")
+	want := filepath.Join(dir, "task", "lists", "default.jsonl")
+	if s, ok := store.(*JSONLStore); !ok || s.FileName != want {
+		t.Errorf("Expected default file %s, got %#v", want, store)
+	}
+}
+
+func TestAppStoreFlag(t *testing.T) {
+	dir := t.TempDir()
+	app := NewApp(&bytes.Buffer{}, &bytes.Buffer{})
+	dbFile := filepath.Join(dir, "tasks.db")
+
+	code, _, stderr := runCmd(t, app, "-store", "db", "-file", dbFile, "add", "Stored in db")
+	if code != ExitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", ExitOK, code, stderr)
+	}
+
+	var list TodoList
+	if err := NewDBStore(dbFile).Load(&list); err != nil {
+		t.Fatalf("Failed to load db store: %v", err)
+	}
+	if len(list.Todos) != 1 || list.Todos[0].Description != "Stored in db" {
+		t.Errorf("Expected task in db store, got %v", list.Todos)
+	}
+
+	code, _, _ = runCmd(t, NewApp(&bytes.Buffer{}, &bytes.Buffer{}), "-store", "yaml", "list")
+	if code != ExitError {
+		t.Errorf("Expected exit code %d for unknown store, got %d", ExitError, code)
+	}
+}
+
+func TestAppMigrateStore(t *testing.T) {
+	dir := t.TempDir()
+	jsonFile := filepath.Join(dir, "todos.json")
+	jsonlFile := filepath.Join(dir, "todos.jsonl")
+
+	list := TodoList{}
+	list.add("Task 1")
+	list.add("Task 2")
+	list.Todos[1].CreatedAt = time.Date(2025, 12, 27, 15, 0, 0, 0, time.UTC)
+	NewStorage[TodoList](jsonFile).Save(list)
+
+	app := NewApp(&bytes.Buffer{}, &bytes.Buffer{})
+	code, stdout, stderr := runCmd(t, app, "migrate-store", "--from", "json", "--to", "jsonl",
+		"--from-file", jsonFile, "--to-file", jsonlFile)
+	if code != ExitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", ExitOK, code, stderr)
+	}
+	if !strings.Contains(stdout, "Migrated 2 tasks") {
+		t.Errorf("Expected migration summary, got '%s'", stdout)
+	}
+
+	var migrated TodoList
+	if err := NewJSONLStore(jsonlFile).Load(&migrated); err != nil {
+		t.Fatalf("Failed to load migrated store: %v", err)
+	}
+	if len(migrated.Todos) != 2 || migrated.NextID != 3 {
+		t.Errorf("Expected 2 todos and NextID 3, got %d and %d", len(migrated.Todos), migrated.NextID)
+	}
+	if !migrated.Todos[1].CreatedAt.Equal(list.Todos[1].CreatedAt) {
+		t.Errorf("Expected CreatedAt to be preserved, got %v", migrated.Todos[1].CreatedAt)
+	}
+
+	// A second migration into a non-empty store needs -force
+	code, _, _ = runCmd(t, app, "migrate-store", "--from", "json", "--to", "jsonl",
+		"--from-file", jsonFile, "--to-file", jsonlFile)
+	if code != ExitError {
+		t.Errorf("Expected exit code %d without -force, got %d", ExitError, code)
+	}
+	code, _, _ = runCmd(t, app, "migrate-store", "--from", "json", "--to", "jsonl",
+		"--from-file", jsonFile, "--to-file", jsonlFile, "--force")
+	if code != ExitOK {
+		t.Errorf("Expected exit code %d with -force, got %d", ExitOK, code)
+	}
+}
+
+func TestAppMigrateStoreInvalidArgs(t *testing.T) {
+	app := newTestApp(t)
+
+	testCases := []struct {
+		name string
+		args []string
+	}{
+		{"Missing to", []string{"migrate-store", "--from", "json"}},
+		{"Unknown backend", []string{"migrate-store", "--from", "json", "--to", "xml"}},
+		{"Same store", []string{"migrate-store", "--from", "json", "--to", "json"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			code, _, _ := runCmd(t, app, tc.args...)
+			if code != ExitUsage {
+				t.Errorf("Expected exit code %d, got %d", ExitUsage, code)
+			}
+		})
+	}
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"time"
+)
+
+// syncLink ties a task to the server's todo. Local and Remote are the two
+// sides as of the last sync, so a change on either side can be told apart
+// from one on the other.
+type syncLink struct {
+	ID       int        `json:"id"`
+	RemoteID int64      `json:"remoteId"`
+	Local    remoteBody `json:"local"`
+	Remote   remoteBody `json:"remote"`
+}
+
+// SyncState holds the links of a list to the todos of the server at URL.
+// It is kept next to the store.
+type SyncState struct {
+	URL   string     `json:"url"`
+	Links []syncLink `json:"links"`
+
+	path  string
+	dirty bool
+}
+
+// syncStatePath returns the sync state of the store at storePath.
+func syncStatePath(storePath string) string {
+	return storePath + ".remote"
+}
+
+// OpenSyncState loads the sync state at path. A missing state is empty.
+func OpenSyncState(path string) (*SyncState, error) {
+	s := &SyncState{}
+	if err := NewStorage[SyncState](path).Load(s); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("reading sync state: %w", err)
+	}
+	s.path = path
+	return s, nil
+}
+
+// Save writes the sync state back if it changed.
+func (s *SyncState) Save() error {
+	if !s.dirty {
+		return nil
+	}
+	if err := NewStorage[SyncState](s.path).Save(*s); err != nil {
+		return fmt.Errorf("writing sync state: %w", err)
+	}
+	s.dirty = false
+	return nil
+}
+
+// SyncState opens the sync state of the store on first use. App.Run saves
+// it after the list, so its links never point at tasks that were not
+// written.
+func (ctx *Context) SyncState() (*SyncState, error) {
+	if ctx.syncState == nil {
+		path, err := ctx.App.StorePath()
+		if err != nil {
+			return nil, err
+		}
+		s, err := OpenSyncState(syncStatePath(path))
+		if err != nil {
+			return nil, err
+		}
+		ctx.syncState = s
+	}
+	return ctx.syncState, nil
+}
+
+// PartialError reports a command that failed after making changes that
+// have to be kept, such as a sync that already changed the server. App.Run
+// saves the list before reporting the error.
+type PartialError struct {
+	Err error
+}
+
+func (e *PartialError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
+// partial wraps err in a PartialError if changes were made before it.
+func partial(err error, changes int) error {
+	if changes > 0 {
+		return &PartialError{Err: err}
+	}
+	return err
+}
+
+// syncDirection is what a sync may change: the server, the list or both.
+type syncDirection int
+
+const (
+	syncPush syncDirection = 1 << iota
+	syncPull
+	syncBoth = syncPush | syncPull
+)
+
+// toRemote maps a task to the server's fields: the description is the
+// title, the status goes into the description and finished tasks are
+// completed.
+func toRemote(t Todo) remoteBody {
+	return remoteBody{Title: t.Description, Description: string(t.Status), Completed: t.Status.Finished()}
+}
+
+func (r remoteTodo) body() remoteBody {
+	return remoteBody{Title: r.Title, Description: r.Description, Completed: r.Completed}
+}
+
+// remoteStatus is the status a task takes from the server's todo. A status
+// in the description is kept where it agrees with completed; todos edited
+// elsewhere only change between open and finished.
+func remoteStatus(r remoteBody, current Status) Status {
+	status := Status(r.Description)
+	switch {
+	case r.Completed && status == StatusCancelled:
+		return StatusCancelled
+	case r.Completed:
+		return StatusDone
+	case status.Valid() && !status.Finished():
+		return status
+	case current.Finished():
+		return StatusTodo
+	}
+	return current
+}
+
+// applyRemote updates t to the server's todo, going through todo where
+// the workflow allows no direct transition.
+func applyRemote(t *Todo, r remoteBody, now time.Time) {
+	if t.Description != r.Title {
+		t.Description = r.Title
+		t.UpdatedAt = &now
+	}
+	status := remoteStatus(r, t.Status)
+	if err := t.setStatus(status, now); err != nil {
+		t.setStatus(StatusTodo, now)
+		t.setStatus(status, now)
+	}
+}
+
+// syncer reconciles a list with the server.
+type syncer struct {
+	ctx       *Context
+	client    *remoteClient
+	state     *SyncState
+	direction syncDirection
+	now       time.Time
+	changes   int
+	conflicts int
+}
+
+func (s *syncer) printf(format string, a ...any) {
+	fmt.Fprintf(s.ctx.Stdout, format+"\n", a...)
+	s.changes++
+}
+
+func (s *syncer) conflict(format string, a ...any) {
+	fmt.Fprintf(s.ctx.Stdout, "Conflict: "+format+"\n", a...)
+	s.conflicts++
+}
+
+// run reconciles every linked task, then links the new ones on either
+// side. Both sides changing a task is a conflict: sync keeps the side
+// changed last, push and pull leave the task alone.
+func (s *syncer) run() error {
+	todos, err := s.client.todos()
+	if err != nil {
+		return err
+	}
+	remote := map[int64]remoteTodo{}
+	for _, r := range todos {
+		remote[r.ID] = r
+	}
+
+	links := s.state.Links
+	s.state.Links = nil
+	for i, link := range links {
+		keep, err := s.reconcile(&link, remote)
+		if keep {
+			s.state.Links = append(s.state.Links, link)
+		}
+		delete(remote, link.RemoteID)
+		if err != nil {
+			s.state.Links = append(s.state.Links, links[i+1:]...)
+			return err
+		}
+	}
+
+	if s.direction&syncPush != 0 {
+		linked := map[int]bool{}
+		for _, link := range s.state.Links {
+			linked[link.ID] = true
+		}
+		for _, t := range slices.Clone(s.ctx.List.Todos) {
+			if linked[t.ID] {
+				continue
+			}
+			body := toRemote(t)
+			created, err := s.client.create(body)
+			if err != nil {
+				return err
+			}
+			s.state.Links = append(s.state.Links, syncLink{ID: t.ID, RemoteID: created.ID, Local: body, Remote: created.body()})
+			s.printf("Created remote todo %d from task %d", created.ID, t.ID)
+		}
+	}
+	if s.direction&syncPull != 0 {
+		for _, r := range todos {
+			if _, ok := remote[r.ID]; !ok {
+				continue
+			}
+			s.pullNew(r)
+		}
+	}
+	return nil
+}
+
+// pullNew adds the server's todo r as a new task.
+func (s *syncer) pullNew(r remoteTodo) {
+	t := s.ctx.List.add(r.Title)
+	if !r.CreatedAt.IsZero() {
+		t.CreatedAt = r.CreatedAt
+	}
+	applyRemote(t, r.body(), s.now)
+	s.state.Links = append(s.state.Links, syncLink{ID: t.ID, RemoteID: r.ID, Local: toRemote(*t), Remote: r.body()})
+	s.printf("Added task %d from remote todo %d", t.ID, r.ID)
+}
+
+// reconcile brings one linked task and todo together. It reports whether
+// the link is still needed.
+func (s *syncer) reconcile(link *syncLink, remote map[int64]remoteTodo) (bool, error) {
+	push, pull := s.direction&syncPush != 0, s.direction&syncPull != 0
+	index, err := s.ctx.List.IndexOf(link.ID)
+	localOK := err == nil
+	r, remoteOK := remote[link.RemoteID]
+
+	switch {
+	case !localOK && !remoteOK:
+		return false, nil
+
+	case !localOK:
+		if r.body() != link.Remote {
+			if !pull {
+				s.conflict("task %d was deleted, but remote todo %d changed since, pull or sync to get it back", link.ID, r.ID)
+				return true, nil
+			}
+			s.conflict("task %d was deleted, but remote todo %d changed since, adding it again", link.ID, r.ID)
+			s.pullNew(r)
+			return false, nil
+		}
+		if !push {
+			return true, nil
+		}
+		if err := s.client.delete(r.ID); err != nil {
+			return true, err
+		}
+		s.printf("Deleted remote todo %d, task %d was deleted", r.ID, link.ID)
+		return false, nil
+
+	case !remoteOK:
+		t := s.ctx.List.Todos[index]
+		if toRemote(t) != link.Local {
+			if !push {
+				s.conflict("remote todo %d was deleted, but task %d changed since, push or sync to send it again", link.RemoteID, t.ID)
+				return true, nil
+			}
+			s.conflict("remote todo %d was deleted, but task %d changed since, sending it again", link.RemoteID, t.ID)
+			created, err := s.client.create(toRemote(t))
+			if err != nil {
+				return true, err
+			}
+			*link = syncLink{ID: t.ID, RemoteID: created.ID, Local: toRemote(t), Remote: created.body()}
+			return true, nil
+		}
+		if !pull {
+			return true, nil
+		}
+		if err := s.ctx.archiveTodo(t.ID, s.now); err != nil {
+			return true, err
+		}
+		s.printf("Archived task %d, remote todo %d was deleted", t.ID, link.RemoteID)
+		return false, nil
+	}
+
+	t := &s.ctx.List.Todos[index]
+	local := toRemote(*t)
+	localChanged, remoteChanged := local != link.Local, r.body() != link.Remote
+	if localChanged && remoteChanged && local != r.body() {
+		if s.direction != syncBoth {
+			s.conflict("task %d and remote todo %d both changed, sync to keep the newer one", t.ID, r.ID)
+			return true, nil
+		}
+		if r.UpdatedAt.After(lastChange(*t)) {
+			s.conflict("task %d and remote todo %d both changed, kept the remote one", t.ID, r.ID)
+			localChanged = false
+		} else {
+			s.conflict("task %d and remote todo %d both changed, kept the local one", t.ID, r.ID)
+			remoteChanged = false
+		}
+	}
+
+	switch {
+	case localChanged && remoteChanged:
+		// Both sides made the same change
+	case localChanged && push:
+		if err := s.client.update(r, local); err != nil {
+			return true, err
+		}
+		s.printf("Updated remote todo %d from task %d", r.ID, t.ID)
+		r = remoteTodo{ID: r.ID, Title: local.Title, Description: local.Description, Completed: local.Completed}
+	case remoteChanged && pull:
+		applyRemote(t, r.body(), s.now)
+		s.printf("Updated task %d from remote todo %d", t.ID, r.ID)
+	default:
+		return true, nil
+	}
+	*link = syncLink{ID: t.ID, RemoteID: r.ID, Local: toRemote(*t), Remote: r.body()}
+	return true, nil
+}
+
+func newSyncCmd(name string, direction syncDirection, summary string) *Command {
+	return &Command{
+		Name:    name,
+		Summary: summary,
+		Flags:   newFlagSet(name),
+		Run: func(ctx *Context, args []string) error {
+			if len(args) > 0 {
+				return usageErrorf("unexpected argument %q", args[0])
+			}
+			// With git history on, sync also merges the list's repository
+			merged, synced := 0, false
+			if direction == syncBoth && gitEnabled(ctx.App) {
+				n, err := gitSync(ctx)
+				if err != nil {
+					return err
+				}
+				merged, synced = n, true
+			}
+			login, err := loadRemoteLogin()
+			if err != nil {
+				return partial(err, merged)
+			}
+			if login == nil {
+				if synced {
+					return nil
+				}
+				return errors.New("not logged in, run task remote login <url> -email <email> first")
+			}
+			state, err := ctx.SyncState()
+			if err != nil {
+				return partial(err, merged)
+			}
+			if state.URL != login.URL {
+				// Links to another server mean nothing on this one
+				state.URL, state.Links = login.URL, nil
+			}
+
+			s := &syncer{ctx: ctx, client: newRemoteClient(login.URL, login.Token), state: state, direction: direction, now: time.Now()}
+			err = s.run()
+			state.dirty = true
+			if err != nil {
+				return partial(err, merged+s.changes+s.conflicts)
+			}
+			if s.changes == 0 && s.conflicts == 0 {
+				fmt.Fprintln(ctx.Stdout, "Already up to date")
+			}
+			return nil
+		},
+	}
+}
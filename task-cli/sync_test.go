@@ -0,0 +1,330 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAPI mimics the todo-list-api server: a login setting the
+// Authorization cookie, PUT skipping zero values, completion cleared only
+// by a toggle and DELETE requiring a JSON body. hook runs before every
+// request that is let in.
+type fakeAPI struct {
+	mu        sync.Mutex
+	todos     map[int64]*remoteTodo
+	nextID    int64
+	failAfter int
+	hook      func()
+}
+
+// Helper to start a fake todo-list-api server for the duration of a test
+func newFakeAPI(t *testing.T) (*fakeAPI, *httptest.Server) {
+	api := &fakeAPI{todos: map[int64]*remoteTodo{}, nextID: 1, failAfter: -1}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ Email, Password string }
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Email != "me@example.com" || body.Password != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Invalid email or password"})
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "Authorization", Value: "token-1", Domain: "localhost", Path: "/"})
+		json.NewEncoder(w).Encode(map[string]string{"message": "login success"})
+	})
+	auth := func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if c, err := r.Cookie("Authorization"); err != nil || c.Value != "token-1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			api.mu.Lock()
+			defer api.mu.Unlock()
+			if api.hook != nil {
+				api.hook()
+			}
+			h(w, r)
+		}
+	}
+	// Helper to bind the JSON body like gin's ShouldBindJSON
+	bind := func(w http.ResponseWriter, r *http.Request, v any) bool {
+		if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"message": err.Error()})
+			return false
+		}
+		return true
+	}
+
+	mux.HandleFunc("GET /todos", auth(func(w http.ResponseWriter, r *http.Request) {
+		todos := []remoteTodo{}
+		for id := int64(1); id < api.nextID; id++ {
+			if todo, ok := api.todos[id]; ok {
+				todos = append(todos, *todo)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"todos": todos})
+	}))
+	mux.HandleFunc("POST /todos", auth(func(w http.ResponseWriter, r *http.Request) {
+		var body remoteTodo
+		if !bind(w, r, &body) {
+			return
+		}
+		if api.failAfter == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		api.failAfter--
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]any{"todo": api.create(body)})
+	}))
+	mux.HandleFunc("PUT /todos/{id}", auth(func(w http.ResponseWriter, r *http.Request) {
+		var body remoteTodo
+		if !bind(w, r, &body) {
+			return
+		}
+		id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if api.todos[id] == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		api.update(id, body)
+	}))
+	mux.HandleFunc("PATCH /todos/toggle", auth(func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ Id int64 }
+		if bind(w, r, &body) && !api.toggle(body.Id) {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	mux.HandleFunc("DELETE /todos/{id}", auth(func(w http.ResponseWriter, r *http.Request) {
+		var body remoteTodo
+		if !bind(w, r, &body) {
+			return
+		}
+		id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if api.todos[id] == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(api.todos, id)
+	}))
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return api, srv
+}
+
+func (api *fakeAPI) create(body remoteTodo) remoteTodo {
+	now := time.Now()
+	todo := &remoteTodo{ID: api.nextID, Title: body.Title, Description: body.Description, Completed: body.Completed,
+		CreatedAt: now, UpdatedAt: now}
+	api.todos[todo.ID] = todo
+	api.nextID++
+	return *todo
+}
+
+// update applies body like gorm's Updates: zero values are left alone.
+func (api *fakeAPI) update(id int64, body remoteTodo) {
+	todo := api.todos[id]
+	if body.Title != "" {
+		todo.Title = body.Title
+	}
+	if body.Description != "" {
+		todo.Description = body.Description
+	}
+	if body.Completed {
+		todo.Completed = true
+	}
+	todo.UpdatedAt = time.Now()
+	if !body.UpdatedAt.IsZero() {
+		todo.UpdatedAt = body.UpdatedAt
+	}
+}
+
+func (api *fakeAPI) toggle(id int64) bool {
+	todo, ok := api.todos[id]
+	if ok {
+		todo.Completed = !todo.Completed
+		todo.UpdatedAt = time.Now()
+	}
+	return ok
+}
+
+// Helper to look at a remote todo by title
+func (api *fakeAPI) find(title string) *remoteTodo {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	for _, todo := range api.todos {
+		if todo.Title == title {
+			return todo
+		}
+	}
+	return nil
+}
+
+func TestRemoteLogin(t *testing.T) {
+	_, srv := newFakeAPI(t)
+	app := newTestApp(t)
+
+	app.Stdin = strings.NewReader("wrong\n")
+	if code, _, stderr := runCmd(t, app, "remote", "login", srv.URL, "-email", "me@example.com"); code != ExitError ||
+		!strings.Contains(stderr, "Invalid email or password") {
+		t.Errorf("Expected the login rejected, got %d: %s", code, stderr)
+	}
+
+	app.Stdin = strings.NewReader("secret\n")
+	if code, stdout, stderr := runCmd(t, app, "remote", "login", srv.URL+"/", "-email", "me@example.com"); code != ExitOK ||
+		stdout != "Logged in to "+srv.URL+" as me@example.com\n" {
+		t.Fatalf("Expected to be logged in, got %d: %q %s", code, stdout, stderr)
+	}
+	path, _ := remoteLoginPath()
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected the login saved readable by the user only, got %v", info)
+	}
+
+	runCmd(t, app, "remote", "logout")
+	if code, _, stderr := runCmd(t, app, "sync"); code != ExitError || !strings.Contains(stderr, "not logged in") {
+		t.Errorf("Expected sync to need a login, got %d: %s", code, stderr)
+	}
+}
+
+// Helper to log the app in to the fake server
+func loginFakeAPI(t *testing.T, app *App, url string) {
+	t.Helper()
+	app.Stdin = strings.NewReader("secret\n")
+	if code, _, stderr := runCmd(t, app, "remote", "login", url, "-email", "me@example.com"); code != ExitOK {
+		t.Fatalf("Failed to log in: %s", stderr)
+	}
+}
+
+func TestPushPullSync(t *testing.T) {
+	api, srv := newFakeAPI(t)
+	app := newTestApp(t)
+	loginFakeAPI(t, app, srv.URL)
+
+	runCmd(t, app, "add", "Write docs")
+	runCmd(t, app, "add", "Fix bug")
+	runCmd(t, app, "mark", "2", "in-progress")
+	if code, stdout, stderr := runCmd(t, app, "push"); code != ExitOK || strings.Count(stdout, "Created remote todo") != 2 {
+		t.Fatalf("Expected two todos created, got %d: %q %s", code, stdout, stderr)
+	}
+	if todo := api.find("Fix bug"); todo == nil || todo.Description != "in-progress" || todo.Completed {
+		t.Errorf("Unexpected remote todo: %+v", todo)
+	}
+	if _, stdout, _ := runCmd(t, app, "sync"); stdout != "Already up to date\n" {
+		t.Errorf("Expected nothing to sync, got %q", stdout)
+	}
+
+	// Changes made on the server come back with pull
+	api.mu.Lock()
+	api.create(remoteTodo{Title: "From the web"})
+	api.toggle(1)
+	api.mu.Unlock()
+	runCmd(t, app, "pull")
+	todos := loadTodos(t, app)
+	if len(todos) != 3 || todos[0].Status != StatusDone || todos[2].Description != "From the web" {
+		t.Fatalf("Expected the remote changes pulled, got %+v", todos)
+	}
+
+	// Reopening clears completed with a toggle, as PUT cannot
+	runCmd(t, app, "mark", "1", "todo")
+	runCmd(t, app, "push")
+	if todo := api.find("Write docs"); todo.Completed || todo.Description != "todo" {
+		t.Errorf("Expected the todo reopened, got %+v", todo)
+	}
+
+	// Both sides changed: sync keeps the newer one
+	runCmd(t, app, "update", "2", "Fix bug now")
+	api.mu.Lock()
+	api.update(2, remoteTodo{Title: "Fix the bug", UpdatedAt: time.Now().Add(time.Hour)})
+	api.mu.Unlock()
+	_, stdout, _ := runCmd(t, app, "push")
+	if !strings.Contains(stdout, "Conflict: task 2 and remote todo 2 both changed, sync") {
+		t.Errorf("Expected push to leave the conflict alone, got %q", stdout)
+	}
+	_, stdout, _ = runCmd(t, app, "sync")
+	if !strings.Contains(stdout, "kept the remote one") || loadTodos(t, app)[1].Description != "Fix the bug" {
+		t.Errorf("Expected the newer remote change kept, got %q", stdout)
+	}
+
+	// Deletes go both ways; a task deleted by the server is archived
+	runCmd(t, app, "delete", "3")
+	api.mu.Lock()
+	delete(api.todos, 1)
+	api.mu.Unlock()
+	_, stdout, _ = runCmd(t, app, "sync")
+	if !strings.Contains(stdout, "Deleted remote todo 3, task 3 was deleted") ||
+		!strings.Contains(stdout, "Archived task 1, remote todo 1 was deleted") {
+		t.Errorf("Expected deletes synced, got %q", stdout)
+	}
+	if todos := loadTodos(t, app); len(todos) != 1 || len(api.todos) != 1 || len(loadArchive(t, app)) != 2 {
+		t.Errorf("Expected one task left on both sides, got %+v and %d remote", todos, len(api.todos))
+	}
+
+	_, stdout, _ = runCmd(t, app, "remote", "status")
+	if !strings.Contains(stdout, "1 task linked") {
+		t.Errorf("Expected the link count, got %q", stdout)
+	}
+}
+
+func TestSyncAfterListRename(t *testing.T) {
+	api, srv := newFakeAPI(t)
+	app, _ := newListsApp(t)
+	loginFakeAPI(t, app, srv.URL)
+	runCmd(t, app, "add", "Write docs")
+	runCmd(t, app, "push")
+
+	// The links move with the list, so nothing is pushed or pulled twice
+	runCmd(t, app, "list-rename", "default", "home")
+	if _, stdout, stderr := runCmd(t, app, "sync"); stdout != "Already up to date\n" {
+		t.Errorf("Expected nothing to sync, got %q %s", stdout, stderr)
+	}
+	if todos := loadTodos(t, app); len(todos) != 1 || len(api.todos) != 1 {
+		t.Errorf("Expected one task on both sides, got %+v and %d remote", todos, len(api.todos))
+	}
+}
+
+func TestSyncStateFollowsTheList(t *testing.T) {
+	api, srv := newFakeAPI(t)
+	app := newTestApp(t)
+	loginFakeAPI(t, app, srv.URL)
+	api.create(remoteTodo{Title: "From the web"})
+
+	// A list that cannot be saved after the pull keeps its links unsaved too
+	api.hook = func() {
+		os.Remove(app.File)
+		os.MkdirAll(filepath.Join(app.File, "blocked"), 0o755)
+	}
+	if code, _, _ := runCmd(t, app, "pull"); code != ExitError {
+		t.Errorf("Expected the pull to fail saving the list, got %d", code)
+	}
+	if _, err := os.Stat(syncStatePath(app.File)); !os.IsNotExist(err) {
+		t.Errorf("Expected no links saved for tasks that were not, got %v", err)
+	}
+}
+
+func TestSyncKeepsPartialProgress(t *testing.T) {
+	api, srv := newFakeAPI(t)
+	app := newTestApp(t)
+	loginFakeAPI(t, app, srv.URL)
+	runCmd(t, app, "add", "First")
+	runCmd(t, app, "add", "Second")
+
+	api.failAfter = 1
+	if code, stdout, _ := runCmd(t, app, "push"); code != ExitError || !strings.Contains(stdout, "from task 1") {
+		t.Errorf("Expected the push to fail after the first todo, got %d: %q", code, stdout)
+	}
+	api.failAfter = -1
+	runCmd(t, app, "push")
+	if len(api.todos) != 2 {
+		t.Errorf("Expected each task pushed once, got %d remote todos", len(api.todos))
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Interval is a stretch of work on a task. End is nil while the timer runs.
+type Interval struct {
+	Start time.Time  `json:"start"`
+	End   *time.Time `json:"end,omitempty"`
+}
+
+// Running reports whether the task has a timer running.
+func (t Todo) Running() bool {
+	return len(t.Intervals) > 0 && t.Intervals[len(t.Intervals)-1].End == nil
+}
+
+// stopTimer closes the running interval of t at now, if there is one.
+func (t *Todo) stopTimer(now time.Time) bool {
+	if !t.Running() {
+		return false
+	}
+	t.Intervals[len(t.Intervals)-1].End = &now
+	return true
+}
+
+// Tracked returns the time worked on t between from and to, counting a
+// running timer up to now. Zero bounds are open.
+func (t Todo) Tracked(from, to, now time.Time) time.Duration {
+	var total time.Duration
+	for _, iv := range t.Intervals {
+		start, end := iv.Start, now
+		if iv.End != nil {
+			end = *iv.End
+		}
+		if !from.IsZero() && start.Before(from) {
+			start = from
+		}
+		if !to.IsZero() && end.After(to) {
+			end = to
+		}
+		if end.After(start) {
+			total += end.Sub(start)
+		}
+	}
+	return total
+}
+
+// activeTimer returns the position of the task whose timer runs. There is
+// at most one.
+func (todos Todos) activeTimer() (int, bool) {
+	for i, t := range todos {
+		if t.Running() {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// startTimer starts timing the task id, stopping any other running timer
+// first. A task that was not started yet moves to in-progress. It returns
+// the ID of the task whose timer was stopped, or 0.
+func (todos Todos) startTimer(id int, now time.Time) (int, error) {
+	index, err := todos.IndexOf(id)
+	if err != nil {
+		return 0, err
+	}
+	t := &todos[index]
+	if t.Running() {
+		return 0, fmt.Errorf("task %d is already being timed", id)
+	}
+	if t.Status.Finished() {
+		return 0, fmt.Errorf("task %d is %s, reopen it before tracking time", id, t.Status)
+	}
+	if t.Status != StatusInProgress {
+		if err := t.setStatus(StatusInProgress, now); err != nil {
+			return 0, err
+		}
+	}
+
+	stopped := 0
+	if active, ok := todos.activeTimer(); ok {
+		todos[active].stopTimer(now)
+		stopped = todos[active].ID
+	}
+	t.Intervals = append(t.Intervals, Interval{Start: now})
+	return stopped, nil
+}
+
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// startOfWeek returns Monday 00:00 of the week of t.
+func startOfWeek(t time.Time) time.Time {
+	day := startOfDay(t)
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// timeReport sums the time tracked between from and to per task, tag and
+// day.
+type timeReport struct {
+	tasks []taskTime
+	tags  map[string]time.Duration
+	days  []time.Time
+	byDay map[time.Time]time.Duration
+	total time.Duration
+}
+
+type taskTime struct {
+	Todo
+	spent time.Duration
+}
+
+func newTimeReport(todos Todos, from, to, now time.Time) timeReport {
+	r := timeReport{tags: map[string]time.Duration{}, byDay: map[time.Time]time.Duration{}}
+	for day := startOfDay(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+		r.days = append(r.days, day)
+	}
+
+	for _, t := range todos {
+		spent := t.Tracked(from, to, now)
+		if spent == 0 {
+			continue
+		}
+		r.tasks = append(r.tasks, taskTime{t, spent})
+		r.total += spent
+
+		tags := t.Tags
+		if len(tags) == 0 {
+			tags = []string{noGroup}
+		}
+		for _, tag := range tags {
+			r.tags[tag] += spent
+		}
+		for _, day := range r.days {
+			r.byDay[day] += t.Tracked(day, day.AddDate(0, 0, 1), now)
+		}
+	}
+
+	slices.SortStableFunc(r.tasks, func(a, b taskTime) int { return cmp.Compare(b.spent, a.spent) })
+	return r
+}
+
+func (r timeReport) render(w io.Writer) {
+	tasks := newTable(w)
+	tasks.SetHeaders("id", "Task", "Time")
+	for _, t := range r.tasks {
+		tasks.AddRow(strconv.Itoa(t.ID), t.Description, formatDuration(t.spent))
+	}
+	tasks.SetFooters("", "Total", formatDuration(r.total))
+	tasks.Render()
+
+	tagNames := make([]string, 0, len(r.tags))
+	for tag := range r.tags {
+		tagNames = append(tagNames, tag)
+	}
+	slices.SortFunc(tagNames, func(a, b string) int {
+		return cmp.Or(cmp.Compare(r.tags[b], r.tags[a]), cmp.Compare(a, b))
+	})
+	fmt.Fprintln(w)
+	tags := newTable(w)
+	tags.SetHeaders("Tag", "Time")
+	for _, tag := range tagNames {
+		tags.AddRow(tag, formatDuration(r.tags[tag]))
+	}
+	tags.Render()
+
+	fmt.Fprintln(w)
+	days := newTable(w)
+	days.SetHeaders("Day", "Time")
+	for _, day := range r.days {
+		days.AddRow(day.Format("Mon 2006-01-02"), formatDuration(r.byDay[day]))
+	}
+	days.SetFooters("Total", formatDuration(r.total))
+	days.Render()
+}
+
+func newStartCmd() *Command {
+	return &Command{
+		Name:    "start",
+		Args:    "<id>",
+		Summary: "Start timing work on a task, stopping any running timer.",
+		Flags:   newFlagSet("start"),
+		Run: func(ctx *Context, args []string) error {
+			if len(args) != 1 {
+				return usageErrorf("expected exactly one task id")
+			}
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			stopped, err := ctx.List.startTimer(id, time.Now())
+			if err != nil {
+				return err
+			}
+			if stopped != 0 {
+				fmt.Fprintf(ctx.Stdout, "Stopped timer of task %d\n", stopped)
+			}
+			fmt.Fprintf(ctx.Stdout, "Started timer of task %d\n", id)
+			return nil
+		},
+	}
+}
+
+func newStopCmd() *Command {
+	return &Command{
+		Name:    "stop",
+		Args:    "[id]",
+		Summary: "Stop the running timer.",
+		Flags:   newFlagSet("stop"),
+		Run: func(ctx *Context, args []string) error {
+			if len(args) > 1 {
+				return usageErrorf("expected at most one task id")
+			}
+
+			index, ok := ctx.List.activeTimer()
+			if !ok {
+				return fmt.Errorf("no timer is running")
+			}
+			if len(args) == 1 {
+				id, err := parseID(args[0])
+				if err != nil {
+					return err
+				}
+				if ctx.List.Todos[index].ID != id {
+					return fmt.Errorf("task %d is not being timed, task %d is", id, ctx.List.Todos[index].ID)
+				}
+			}
+
+			t := &ctx.List.Todos[index]
+			t.stopTimer(time.Now())
+			last := t.Intervals[len(t.Intervals)-1]
+			fmt.Fprintf(ctx.Stdout, "Stopped timer of task %d after %s\n", t.ID, formatDuration(last.End.Sub(last.Start)))
+			return nil
+		},
+	}
+}
+
+func newReportCmd() *Command {
+	flags := newFlagSet("report")
+	week := flags.Bool("week", false, "report on the current week (the default)")
+	since := &timeFlag{}
+	until := &timeFlag{endOfDay: true}
+	flags.Var(since, "since", "report from this date")
+	flags.Var(until, "until", "report up to and including this date")
+
+	return &Command{
+		Name:     "report",
+		Summary:  "Summarize tracked time per task, tag and day.",
+		Flags:    flags,
+		ReadOnly: true,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) > 0 {
+				return usageErrorf("unexpected argument %q", args[0])
+			}
+
+			now := time.Now()
+			from, to := since.Time, until.Time
+			if *week || (from.IsZero() && to.IsZero()) {
+				if !from.IsZero() || !to.IsZero() {
+					return usageErrorf("-week cannot be combined with -since or -until")
+				}
+				from = startOfWeek(now)
+				to = from.AddDate(0, 0, 7)
+			}
+			if from.IsZero() || to.IsZero() {
+				return usageErrorf("give both -since and -until, or -week")
+			}
+			if !to.After(from) {
+				return usageErrorf("-until must be after -since")
+			}
+
+			fmt.Fprintf(ctx.Stdout, "Time tracked %s to %s\n\n", from.Format("Mon 2006-01-02"), to.Add(-time.Nanosecond).Format("Mon 2006-01-02"))
+			newTimeReport(ctx.List.Todos, from, to, now).render(ctx.Stdout)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStartTimer(t *testing.T) {
+	now := time.Date(2026, 10, 12, 9, 0, 0, 0, time.Local)
+	todos := Todos{
+		{ID: 1, Description: "Write", Status: StatusTodo},
+		{ID: 2, Description: "Review", Status: StatusTodo},
+		{ID: 3, Description: "Shipped", Status: StatusDone},
+	}
+
+	if stopped, err := todos.startTimer(1, now); err != nil || stopped != 0 {
+		t.Fatalf("Expected timer started, got %d, %v", stopped, err)
+	}
+	if todos[0].Status != StatusInProgress || !todos[0].Running() {
+		t.Errorf("Expected task 1 in progress and timed, got %+v", todos[0])
+	}
+	if _, err := todos.startTimer(1, now); err == nil {
+		t.Error("Expected error for a running timer, got nil")
+	}
+	if _, err := todos.startTimer(3, now); err == nil {
+		t.Error("Expected error for a finished task, got nil")
+	}
+
+	// Only one timer runs at a time
+	stopped, err := todos.startTimer(2, now.Add(time.Hour))
+	if err != nil || stopped != 1 {
+		t.Fatalf("Expected timer of task 1 stopped, got %d, %v", stopped, err)
+	}
+	if todos[0].Running() || !todos[1].Running() {
+		t.Error("Expected only task 2 to be timed")
+	}
+	if got := todos[0].Tracked(time.Time{}, time.Time{}, now); got != time.Hour {
+		t.Errorf("Expected 1h on task 1, got %v", got)
+	}
+
+	// Finishing a task stops its timer
+	todos[1].setStatus(StatusDone, now.Add(90*time.Minute))
+	if _, ok := todos.activeTimer(); ok {
+		t.Error("Expected no running timer after marking done")
+	}
+}
+
+func TestTimeReport(t *testing.T) {
+	monday := time.Date(2026, 10, 12, 0, 0, 0, 0, time.Local)
+	at := func(day int, hour float64) time.Time {
+		return monday.AddDate(0, 0, day).Add(time.Duration(hour * float64(time.Hour)))
+	}
+	end := func(day int, hour float64) *time.Time {
+		t := at(day, hour)
+		return &t
+	}
+
+	todos := Todos{
+		{ID: 1, Description: "Write", Tags: []string{"work"}, Intervals: []Interval{
+			{Start: at(0, 9), End: end(0, 11)},
+			{Start: at(-1, 23), End: end(0, 1)}, // Sunday night, half in the week
+		}},
+		{ID: 2, Description: "Gym", Intervals: []Interval{
+			{Start: at(2, 18), End: end(2, 19.5)},
+		}},
+		{ID: 3, Description: "Night shift", Tags: []string{"work"}, Intervals: []Interval{
+			{Start: at(3, 22)}, // still running
+		}},
+		{ID: 4, Description: "Idle"},
+	}
+
+	r := newTimeReport(todos, startOfWeek(at(3, 12)), monday.AddDate(0, 0, 7), at(4, 2))
+
+	if len(r.tasks) != 3 || r.tasks[0].ID != 3 || r.tasks[0].spent != 4*time.Hour || r.tasks[1].spent != 3*time.Hour {
+		t.Fatalf("Expected tasks by time spent, got %+v", r.tasks)
+	}
+	if r.total != 8*time.Hour+30*time.Minute {
+		t.Errorf("Expected 8h30m in total, got %v", r.total)
+	}
+	if r.tags["work"] != 7*time.Hour || r.tags[noGroup] != 90*time.Minute {
+		t.Errorf("Unexpected tag totals: %v", r.tags)
+	}
+	if r.byDay[monday] != 3*time.Hour || r.byDay[monday.AddDate(0, 0, 3)] != 2*time.Hour || r.byDay[monday.AddDate(0, 0, 4)] != 2*time.Hour {
+		t.Errorf("Unexpected day totals: %v", r.byDay)
+	}
+
+	var buf bytes.Buffer
+	r.render(&buf)
+	for _, want := range []string{"Write", "3h00m", "work", "7h00m", "Mon 2026-10-12", "8h30m"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %q in report, got '%s'", want, buf.String())
+		}
+	}
+}
+
+func TestAppStartStop(t *testing.T) {
+	app := newTestApp(t)
+	runCmd(t, app, "add", "Task 1")
+	runCmd(t, app, "add", "Task 2")
+
+	code, stdout, stderr := runCmd(t, app, "start", "1")
+	if code != ExitOK || stdout != "Started timer of task 1\n" {
+		t.Fatalf("Unexpected start: %d %q %s", code, stdout, stderr)
+	}
+	_, stdout, _ = runCmd(t, app, "start", "2")
+	if stdout != "Stopped timer of task 1\nStarted timer of task 2\n" {
+		t.Errorf("Expected switch of timers, got %q", stdout)
+	}
+
+	if code, _, _ = runCmd(t, app, "stop", "1"); code != ExitError {
+		t.Errorf("Expected exit code %d stopping an idle task, got %d", ExitError, code)
+	}
+	_, stdout, _ = runCmd(t, app, "stop")
+	if !strings.HasPrefix(stdout, "Stopped timer of task 2 after 0h00m") {
+		t.Errorf("Unexpected stop output: %q", stdout)
+	}
+	if code, _, _ = runCmd(t, app, "stop"); code != ExitError {
+		t.Errorf("Expected exit code %d with no timer, got %d", ExitError, code)
+	}
+
+	todos := loadTodos(t, app)
+	if len(todos[0].Intervals) != 1 || len(todos[1].Intervals) != 1 || todos[1].Running() {
+		t.Errorf("Expected one closed interval per task, got %+v", todos)
+	}
+
+	code, stdout, _ = runCmd(t, app, "report", "--week")
+	if code != ExitOK || !strings.Contains(stdout, "Task 1") || !strings.Contains(stdout, "Day") {
+		t.Errorf("Unexpected report: %d '%s'", code, stdout)
+	}
+	if code, _, _ = runCmd(t, app, "report", "--week", "--since", "2026-10-01"); code != ExitUsage {
+		t.Errorf("Expected exit code %d, got %d", ExitUsage, code)
+	}
+}
@@ -1,98 +1,202 @@
 package main
 
 import (
-	"errors"
+	"bytes"
+	"encoding/json"
 	"fmt"
-	"os"
-	"strconv"
+	"io"
 	"time"
-
-	"github.com/aquasecurity/table"
 )
 
 type Todo struct {
-	ID          int        `json:"ID"`
-	Description string     `json:"description"`
-	Status      string     `json:"status"`
-	CreatedAt   time.Time  `json:"createdAt"`
-	UpdatedAt   *time.Time `json:"updatedAt,omitempty"`
+	ID          int          `json:"id"`
+	Description string       `json:"description"`
+	Details     string       `json:"details,omitempty"`
+	Status      Status       `json:"status"`
+	CreatedAt   time.Time    `json:"createdAt"`
+	UpdatedAt   *time.Time   `json:"updatedAt,omitempty"`
+	StartedAt   *time.Time   `json:"startedAt,omitempty"`
+	CompletedAt *time.Time   `json:"completedAt,omitempty"`
+	ArchivedAt  *time.Time   `json:"archivedAt,omitempty"`
+	DueAt       *time.Time   `json:"dueAt,omitempty"`
+	Priority    Priority     `json:"priority,omitempty"`
+	Project     string       `json:"project,omitempty"`
+	Tags        []string     `json:"tags,omitempty"`
+	ParentID    int          `json:"parentId,omitempty"`
+	BlockedBy   []int        `json:"blockedBy,omitempty"`
+	Recur       *Recurrence  `json:"recur,omitempty"`
+	Notes       []Note       `json:"notes,omitempty"`
+	Intervals   []Interval   `json:"intervals,omitempty"`
+	Transitions []Transition `json:"transitions,omitempty"`
+
+	// Progress is the rolled up completion of the subtasks in percent, see
+	// Todos.rollup. It is computed when the list is loaded and never stored.
+	Progress *int `json:"-"`
 }
 
 type Todos []Todo
 
-func (todos *Todos) add(description string) {
-	id := len(*todos) + 1
+// TodoList is the stored task list. NextID is persisted so IDs keep
+// increasing and are never reused after a delete.
+type TodoList struct {
+	NextID int `json:"nextId"`
+	Todos  `json:"todos"`
+}
+
+// UnmarshalJSON also accepts the legacy layout, a bare array of todos.
+func (l *TodoList) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var todos Todos
+		if err := json.Unmarshal(data, &todos); err != nil {
+			return err
+		}
+		*l = TodoList{Todos: todos}
+	} else {
+		type plain TodoList
+		var p plain
+		if err := json.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		*l = TodoList(p)
+	}
+
+	l.normalizeIDs()
+	for i := range l.Todos {
+		l.Todos[i].Status = normalizeStatus(l.Todos[i].Status)
+	}
+	return nil
+}
+
+// normalizeIDs gives every task a unique positive ID and moves NextID past
+// the highest one. Legacy files computed IDs from the list length, so they
+// can contain duplicates.
+func (l *TodoList) normalizeIDs() {
+	for _, t := range l.Todos {
+		if t.ID >= l.NextID {
+			l.NextID = t.ID + 1
+		}
+	}
+	if l.NextID < 1 {
+		l.NextID = 1
+	}
+
+	seen := make(map[int]bool)
+	for i := range l.Todos {
+		if l.Todos[i].ID < 1 || seen[l.Todos[i].ID] {
+			l.Todos[i].ID = l.NextID
+			l.NextID++
+		}
+		seen[l.Todos[i].ID] = true
+	}
+}
+
+func (l *TodoList) add(description string) *Todo {
+	if l.NextID < 1 {
+		l.NextID = 1
+	}
 
 	todo := Todo{
-		ID:          id,
+		ID:          l.NextID,
 		Description: description,
-		Status:      "todo",
+		Status:      StatusTodo,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   nil,
 	}
+	l.NextID++
 
-	*todos = append(*todos, todo)
+	l.Todos = append(l.Todos, todo)
+	return &l.Todos[len(l.Todos)-1]
 }
 
-func (todos *Todos) ValidateIndex(ID int) error {
-	if ID < 0 || ID >= len(*todos) {
-		err := errors.New("Invalid Index")
-		fmt.Println(err)
-		return err
+// IndexOf returns the slice position of the task with the given ID.
+func (todos *Todos) IndexOf(ID int) (int, error) {
+	for i, t := range *todos {
+		if t.ID == ID {
+			return i, nil
+		}
 	}
-	return nil
+	return -1, fmt.Errorf("task %d not found", ID)
 }
 
 func (todos *Todos) delete(ID int) error {
 	t := *todos
-	if err := t.ValidateIndex(ID); err != nil {
+	index, err := t.IndexOf(ID)
+	if err != nil {
 		return err
 	}
 
-	*todos = append(t[:ID], t[ID+1:]...)
+	t.unlink(ID)
+	*todos = append(t[:index], t[index+1:]...)
 
 	return nil
 }
 
-func (todos *Todos) StatusChange(status string, ID int) error {
+func (todos *Todos) StatusChange(status Status, ID int) error {
 	t := *todos
-	text := status[5:]
-	if err := t.ValidateIndex(ID); err != nil {
+	index, err := t.IndexOf(ID)
+	if err != nil {
 		return err
 	}
 
-	t[ID].Status = text
-	updateTime := time.Now()
-	t[ID].UpdatedAt = &updateTime
+	if status == StatusDone {
+		if open := idList(t.openBlockers(t[index])); len(open) > 0 {
+			return fmt.Errorf("task %d is blocked by open tasks %s, finish or cancel them first", ID, open.String())
+		}
+	}
+
+	return t[index].setStatus(status, time.Now())
+}
+
+// setStatus moves the task to status if the transition is allowed and
+// stamps the matching timestamps. Leaving in-progress stops the timer.
+// Setting the current status is a no-op.
+func (t *Todo) setStatus(status Status, now time.Time) error {
+	if !status.Valid() {
+		_, err := ParseStatus(string(status))
+		return err
+	}
+	if t.Status == status {
+		return nil
+	}
+	if !t.Status.CanTransitionTo(status) {
+		return fmt.Errorf("cannot change task %d from %s to %s (allowed: %s)", t.ID, t.Status, status, allowedNames(t.Status))
+	}
+
+	switch status {
+	case StatusInProgress:
+		if t.StartedAt == nil {
+			t.StartedAt = &now
+		}
+	case StatusDone:
+		t.CompletedAt = &now
+	case StatusTodo:
+		t.CompletedAt = nil
+	}
+
+	if status != StatusInProgress {
+		t.stopTimer(now)
+	}
+
+	t.Transitions = append(t.Transitions, Transition{From: t.Status, To: status, At: now})
+	t.Status = status
+	t.UpdatedAt = &now
 	return nil
 }
 
 func (todos *Todos) update(description string, ID int) error {
 	t := *todos
-
-	if err := t.ValidateIndex(ID); err != nil {
+	index, err := t.IndexOf(ID)
+	if err != nil {
 		return err
 	}
 
-	t[ID].Description = description
+	t[index].Description = description
 	return nil
 }
 
-func (todos *Todos) Print() {
-	table := table.New(os.Stdout)
-	table.SetRowLines(false)
-	table.SetHeaders("id", "Description", "Status", "Created At", "Updated At")
-
-	for id, t := range *todos {
-		createdAt := t.CreatedAt.Format(time.RFC1123)
-		updatedAt := ""
-		if t.UpdatedAt == nil {
-			updatedAt = ""
-		} else {
-			updatedAt = t.UpdatedAt.Format(time.RFC1123)
-		}
-		table.AddRow(strconv.Itoa(id), t.Description, t.Status, createdAt, updatedAt)
-	}
-
-	table.Render()
+// Print renders the tasks as a table with the default fields. Overdue rows
+// are highlighted when w is a terminal.
+func (todos *Todos) Print(w io.Writer) {
+	fields, _ := lookupFields(defaultFields)
+	renderTable(w, *todos, fields)
 }
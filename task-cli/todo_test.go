@@ -1,61 +1,133 @@
 package main
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 )
 
-func TestTodosAdd(t *testing.T) {
-	todos := Todos{}
+func TestTodoListAdd(t *testing.T) {
+	list := TodoList{}
 
 	// Test adding first todo
-	todos.add("First task")
-	if len(todos) != 1 {
-		t.Errorf("Expected 1 todo, got %d", len(todos))
+	list.add("First task")
+	if len(list.Todos) != 1 {
+		t.Errorf("Expected 1 todo, got %d", len(list.Todos))
 	}
 
 	// Verify first todo properties
-	if todos[0].ID != 1 {
-		t.Errorf("Expected ID 1, got %d", todos[0].ID)
+	if list.Todos[0].ID != 1 {
+		t.Errorf("Expected ID 1, got %d", list.Todos[0].ID)
 	}
-	if todos[0].Description != "First task" {
-		t.Errorf("Expected description 'First task', got '%s'", todos[0].Description)
+	if list.Todos[0].Description != "First task" {
+		t.Errorf("Expected description 'First task', got '%s'", list.Todos[0].Description)
 	}
-	if todos[0].Status != "todo" {
-		t.Errorf("Expected status 'todo', got '%s'", todos[0].Status)
+	if list.Todos[0].Status != "todo" {
+		t.Errorf("Expected status 'todo', got '%s'", list.Todos[0].Status)
 	}
-	if todos[0].UpdatedAt != nil {
+	if list.Todos[0].UpdatedAt != nil {
 		t.Error("Expected UpdatedAt to be nil for new todo")
 	}
 
 	// Test adding second todo
-	todos.add("Second task")
-	if len(todos) != 2 {
-		t.Errorf("Expected 2 todos, got %d", len(todos))
+	todo := list.add("Second task")
+	if len(list.Todos) != 2 {
+		t.Errorf("Expected 2 todos, got %d", len(list.Todos))
+	}
+	if todo.ID != 2 {
+		t.Errorf("Expected ID 2, got %d", todo.ID)
 	}
-	if todos[1].ID != 2 {
-		t.Errorf("Expected ID 2, got %d", todos[1].ID)
+	if list.NextID != 3 {
+		t.Errorf("Expected NextID 3, got %d", list.NextID)
 	}
 
 	// Test adding empty description
-	todos.add("")
-	if len(todos) != 3 {
-		t.Errorf("Expected 3 todos, got %d", len(todos))
+	list.add("")
+	if len(list.Todos) != 3 {
+		t.Errorf("Expected 3 todos, got %d", len(list.Todos))
+	}
+	if list.Todos[2].Description != "" {
+		t.Errorf("Expected empty description, got '%s'", list.Todos[2].Description)
+	}
+}
+
+func TestTodoListIDsNotReused(t *testing.T) {
+	list := TodoList{}
+	list.add("Task 1")
+	list.add("Task 2")
+	list.add("Task 3")
+
+	// Deleting the newest task must not free its ID
+	if err := list.delete(3); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	todo := list.add("Task 4")
+	if todo.ID != 4 {
+		t.Errorf("Expected ID 4, got %d", todo.ID)
+	}
+}
+
+func TestTodoListUnmarshalLegacyArray(t *testing.T) {
+	// Legacy files are a bare array and may contain duplicate IDs
+	data := []byte(`[
+		{"ID": 2, "description": "Task A", "status": "todo"},
+		{"ID": 3, "description": "Task B", "status": "todo"},
+		{"ID": 3, "description": "Task C", "status": "done"}
+	]`)
+
+	var list TodoList
+	if err := json.Unmarshal(data, &list); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(list.Todos) != 3 {
+		t.Fatalf("Expected 3 todos, got %d", len(list.Todos))
 	}
-	if todos[2].Description != "" {
-		t.Errorf("Expected empty description, got '%s'", todos[2].Description)
+	if list.Todos[0].ID != 2 || list.Todos[1].ID != 3 {
+		t.Errorf("Expected existing IDs to be kept, got %d and %d", list.Todos[0].ID, list.Todos[1].ID)
+	}
+	if list.Todos[2].ID != 4 {
+		t.Errorf("Expected duplicate ID to be renumbered to 4, got %d", list.Todos[2].ID)
+	}
+	if list.NextID != 5 {
+		t.Errorf("Expected NextID 5, got %d", list.NextID)
+	}
+}
+
+func TestTodoListJSONRoundTrip(t *testing.T) {
+	list := TodoList{}
+	list.add("Task 1")
+	list.add("Task 2")
+	list.delete(2)
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var loaded TodoList
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The counter survives even though the highest ID was deleted
+	if loaded.NextID != 3 {
+		t.Errorf("Expected NextID 3, got %d", loaded.NextID)
+	}
+	if len(loaded.Todos) != 1 || loaded.Todos[0].ID != 1 {
+		t.Errorf("Expected only task 1, got %v", loaded.Todos)
 	}
 }
 
 func TestTodosDelete(t *testing.T) {
-	// Test deleting from valid index
+	// Test deleting by ID
 	todos := Todos{
 		{ID: 1, Description: "Task 1", Status: "todo"},
 		{ID: 2, Description: "Task 2", Status: "todo"},
 		{ID: 3, Description: "Task 3", Status: "todo"},
 	}
 
-	err := todos.delete(1)
+	err := todos.delete(2)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -74,7 +146,7 @@ func TestTodosDelete(t *testing.T) {
 		{ID: 1, Description: "Task 1", Status: "todo"},
 		{ID: 2, Description: "Task 2", Status: "todo"},
 	}
-	err = todos2.delete(0)
+	err = todos2.delete(1)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -87,7 +159,7 @@ func TestTodosDelete(t *testing.T) {
 		{ID: 1, Description: "Task 1", Status: "todo"},
 		{ID: 2, Description: "Task 2", Status: "todo"},
 	}
-	err = todos3.delete(1)
+	err = todos3.delete(2)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -95,57 +167,60 @@ func TestTodosDelete(t *testing.T) {
 		t.Errorf("Expected 1 todo after deletion, got %d", len(todos3))
 	}
 
-	// Test deleting from invalid index (negative)
+	// Test deleting an unknown ID (negative)
 	todos4 := Todos{
 		{ID: 1, Description: "Task 1", Status: "todo"},
 	}
 	err = todos4.delete(-1)
 	if err == nil {
-		t.Error("Expected error for negative index, got nil")
+		t.Error("Expected error for negative ID, got nil")
 	}
 
-	// Test deleting from invalid index (out of bounds)
-	err = todos4.delete(5)
+	// Test deleting an unknown ID (slice position, not an ID)
+	err = todos4.delete(0)
 	if err == nil {
-		t.Error("Expected error for out of bounds index, got nil")
+		t.Error("Expected error for unknown ID, got nil")
 	}
 
 	// Test deleting from empty list
 	todos5 := Todos{}
-	err = todos5.delete(0)
+	err = todos5.delete(1)
 	if err == nil {
 		t.Error("Expected error for empty list, got nil")
 	}
 }
 
-func TestTodosValidateIndex(t *testing.T) {
+func TestTodosIndexOf(t *testing.T) {
 	todos := Todos{
-		{ID: 1, Description: "Task 1", Status: "todo"},
-		{ID: 2, Description: "Task 2", Status: "todo"},
-		{ID: 3, Description: "Task 3", Status: "todo"},
+		{ID: 4, Description: "Task 4", Status: "todo"},
+		{ID: 7, Description: "Task 7", Status: "todo"},
+		{ID: 9, Description: "Task 9", Status: "todo"},
 	}
 
-	// Test valid indices
-	validIndices := []int{0, 1, 2}
-	for _, idx := range validIndices {
-		err := todos.ValidateIndex(idx)
+	// Test known IDs
+	expected := map[int]int{4: 0, 7: 1, 9: 2}
+	for id, want := range expected {
+		index, err := todos.IndexOf(id)
 		if err != nil {
-			t.Errorf("Expected no error for valid index %d, got %v", idx, err)
+			t.Errorf("Expected no error for ID %d, got %v", id, err)
+		}
+		if index != want {
+			t.Errorf("Expected index %d for ID %d, got %d", want, id, index)
 		}
 	}
 
-	// Test invalid indices
-	invalidIndices := []int{-1, -10, 3, 4, 100}
-	for _, idx := range invalidIndices {
-		err := todos.ValidateIndex(idx)
+	// Test unknown IDs
+	unknownIDs := []int{-1, 0, 1, 2, 5, 100}
+	for _, id := range unknownIDs {
+		_, err := todos.IndexOf(id)
 		if err == nil {
-			t.Errorf("Expected error for invalid index %d, got nil", idx)
+			t.Errorf("Expected error for unknown ID %d, got nil", id)
 		}
 	}
 
 	// Test with empty list
 	emptyTodos := Todos{}
-	err := emptyTodos.ValidateIndex(0)
+	_, err := emptyTodos.IndexOf(1)
 	if err == nil {
 		t.Error("Expected error for empty list, got nil")
 	}
@@ -159,7 +234,7 @@ func TestTodosUpdate(t *testing.T) {
 		{ID: 3, Description: "Task 3", Status: "todo"},
 	}
 
-	err := todos.update("Updated Task 1", 0)
+	err := todos.update("Updated Task 1", 1)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -168,7 +243,7 @@ func TestTodosUpdate(t *testing.T) {
 	}
 
 	// Test updating middle element
-	err = todos.update("Updated Task 2", 1)
+	err = todos.update("Updated Task 2", 2)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -177,7 +252,7 @@ func TestTodosUpdate(t *testing.T) {
 	}
 
 	// Test updating last element
-	err = todos.update("Updated Task 3", 2)
+	err = todos.update("Updated Task 3", 3)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -186,7 +261,7 @@ func TestTodosUpdate(t *testing.T) {
 	}
 
 	// Test updating with empty string
-	err = todos.update("", 0)
+	err = todos.update("", 1)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -194,21 +269,21 @@ func TestTodosUpdate(t *testing.T) {
 		t.Errorf("Expected empty description, got '%s'", todos[0].Description)
 	}
 
-	// Test updating with invalid index (negative)
+	// Test updating with unknown ID (negative)
 	err = todos.update("Invalid", -1)
 	if err == nil {
-		t.Error("Expected error for negative index, got nil")
+		t.Error("Expected error for negative ID, got nil")
 	}
 
-	// Test updating with invalid index (out of bounds)
+	// Test updating with unknown ID
 	err = todos.update("Invalid", 10)
 	if err == nil {
-		t.Error("Expected error for out of bounds index, got nil")
+		t.Error("Expected error for unknown ID, got nil")
 	}
 
 	// Test updating empty list
 	emptyTodos := Todos{}
-	err = emptyTodos.update("Should fail", 0)
+	err = emptyTodos.update("Should fail", 1)
 	if err == nil {
 		t.Error("Expected error for empty list, got nil")
 	}
@@ -223,7 +298,7 @@ func TestTodosStatusChange(t *testing.T) {
 	}
 
 	beforeUpdate := time.Now()
-	err := todos.StatusChange("mark:in-progress", 0)
+	err := todos.StatusChange("in-progress", 1)
 	afterUpdate := time.Now()
 
 	if err != nil {
@@ -241,7 +316,7 @@ func TestTodosStatusChange(t *testing.T) {
 	}
 
 	// Test changing status to done
-	err = todos.StatusChange("mark:done", 1)
+	err = todos.StatusChange("done", 2)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -253,7 +328,7 @@ func TestTodosStatusChange(t *testing.T) {
 	}
 
 	// Test changing status to todo
-	err = todos.StatusChange("mark:todo", 2)
+	err = todos.StatusChange("todo", 3)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -261,41 +336,139 @@ func TestTodosStatusChange(t *testing.T) {
 		t.Errorf("Expected status 'todo', got '%s'", todos[2].Status)
 	}
 
-	// Test with custom status
-	err = todos.StatusChange("mark:custom-status", 0)
-	if err != nil {
-		t.Errorf("Expected no error, got %v", err)
+	// Test with unknown status
+	err = todos.StatusChange("custom-status", 1)
+	if err == nil {
+		t.Error("Expected error for unknown status, got nil")
 	}
-	if todos[0].Status != "custom-status" {
-		t.Errorf("Expected status 'custom-status', got '%s'", todos[0].Status)
+	if todos[0].Status != "in-progress" {
+		t.Errorf("Expected status to stay 'in-progress', got '%s'", todos[0].Status)
 	}
 
-	// Test with invalid index (negative)
-	err = todos.StatusChange("mark:done", -1)
+	// Test with unknown ID (negative)
+	err = todos.StatusChange("done", -1)
 	if err == nil {
-		t.Error("Expected error for negative index, got nil")
+		t.Error("Expected error for negative ID, got nil")
 	}
 
-	// Test with invalid index (out of bounds)
-	err = todos.StatusChange("mark:done", 10)
+	// Test with unknown ID
+	err = todos.StatusChange("done", 10)
 	if err == nil {
-		t.Error("Expected error for out of bounds index, got nil")
+		t.Error("Expected error for unknown ID, got nil")
 	}
 
 	// Test with empty list
 	emptyTodos := Todos{}
-	err = emptyTodos.StatusChange("mark:done", 0)
+	err = emptyTodos.StatusChange("done", 1)
 	if err == nil {
 		t.Error("Expected error for empty list, got nil")
 	}
 
 	// Test with empty status
-	err = todos.StatusChange("mark:", 0)
-	if err != nil {
+	err = todos.StatusChange("", 1)
+	if err == nil {
+		t.Error("Expected error for empty status, got nil")
+	}
+}
+
+func TestTodosStatusChangeTimestamps(t *testing.T) {
+	todos := Todos{
+		{ID: 1, Description: "Task 1", Status: "todo", CreatedAt: time.Now()},
+	}
+
+	todos.StatusChange("in-progress", 1)
+	if todos[0].StartedAt == nil {
+		t.Fatal("Expected StartedAt to be set when work starts")
+	}
+	started := *todos[0].StartedAt
+
+	// Blocking and resuming keeps the original start time
+	todos.StatusChange("blocked", 1)
+	todos.StatusChange("in-progress", 1)
+	if !todos[0].StartedAt.Equal(started) {
+		t.Error("Expected StartedAt to keep the first start time")
+	}
+	if todos[0].CompletedAt != nil {
+		t.Error("Expected CompletedAt to be nil before completion")
+	}
+
+	todos.StatusChange("done", 1)
+	if todos[0].CompletedAt == nil {
+		t.Fatal("Expected CompletedAt to be set when done")
+	}
+
+	// Reopening clears the completion time
+	todos.StatusChange("todo", 1)
+	if todos[0].CompletedAt != nil {
+		t.Error("Expected CompletedAt to be cleared when reopened")
+	}
+
+	// Every change is recorded as a transition
+	expected := []Status{"in-progress", "blocked", "in-progress", "done", "todo"}
+	if len(todos[0].Transitions) != len(expected) {
+		t.Fatalf("Expected %d transitions, got %d", len(expected), len(todos[0].Transitions))
+	}
+	for i, want := range expected {
+		if todos[0].Transitions[i].To != want {
+			t.Errorf("Transition %d: expected to %s, got %s", i, want, todos[0].Transitions[i].To)
+		}
+	}
+	if todos[0].Transitions[0].From != "todo" {
+		t.Errorf("Expected first transition from todo, got %s", todos[0].Transitions[0].From)
+	}
+}
+
+func TestTodosStatusChangeRejectsTransition(t *testing.T) {
+	todos := Todos{
+		{ID: 1, Description: "Task 1", Status: "done", CreatedAt: time.Now()},
+		{ID: 2, Description: "Task 2", Status: "blocked", CreatedAt: time.Now()},
+	}
+
+	// Finished tasks must be reopened first
+	if err := todos.StatusChange("in-progress", 1); err == nil {
+		t.Error("Expected error moving done task to in-progress, got nil")
+	}
+	if todos[0].UpdatedAt != nil {
+		t.Error("Rejected transition should not touch UpdatedAt")
+	}
+
+	// Blocked tasks have to be unblocked before they are done
+	if err := todos.StatusChange("done", 2); err == nil {
+		t.Error("Expected error moving blocked task to done, got nil")
+	}
+
+	// Setting the current status again is a no-op
+	if err := todos.StatusChange("done", 1); err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
-	if todos[0].Status != "" {
-		t.Errorf("Expected empty status, got '%s'", todos[0].Status)
+	if len(todos[0].Transitions) != 0 {
+		t.Error("Expected no transition to be recorded")
+	}
+}
+
+func TestTodoListUnmarshalNormalizesStatus(t *testing.T) {
+	data := []byte(`[
+		{"ID": 1, "description": "learn golang", "status": "in-progres"},
+		{"ID": 2, "description": "go for a run", "status": "DONE"},
+		{"ID": 3, "description": "read a book", "status": ""},
+		{"ID": 4, "description": "play dota", "status": "someday"}
+	]`)
+
+	var list TodoList
+	if err := json.Unmarshal(data, &list); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []Status{"in-progress", "done", "todo", "someday"}
+	for i, want := range expected {
+		if list.Todos[i].Status != want {
+			t.Errorf("Todo %d: expected status '%s', got '%s'", i, want, list.Todos[i].Status)
+		}
+	}
+
+	// Tasks with an unrecognised legacy status can still be moved
+	if err := list.StatusChange("done", 4); err != nil {
+		t.Errorf("Expected no error, got %v", err)
 	}
 }
 
@@ -0,0 +1,418 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type tuiMode int
+
+const (
+	modeBrowse tuiMode = iota
+	modeAdd
+	modeEdit
+	modeFilter
+	modeDelete
+)
+
+// tuiStatusCycle is the order the space key moves a task through. Blocked
+// and cancelled tasks go back to todo.
+var tuiStatusCycle = map[Status]Status{
+	StatusTodo:       StatusInProgress,
+	StatusInProgress: StatusDone,
+	StatusDone:       StatusTodo,
+	StatusBlocked:    StatusTodo,
+	StatusCancelled:  StatusTodo,
+}
+
+const tuiHelp = "↑↓ move  a add  e edit  space status  d delete  t timer  / filter  f finished  u undo  U redo  enter details  q quit"
+
+var (
+	tuiSelected = lipgloss.NewStyle().Reverse(true)
+	tuiOverdue  = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	tuiFinished = lipgloss.NewStyle().Faint(true)
+	tuiTitle    = lipgloss.NewStyle().Bold(true)
+	tuiError    = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+)
+
+// tuiModel is the state of the tui command. Every change runs the same
+// command the command line would, so validation, locking and the journal
+// behave exactly alike; the list is reloaded from the store afterwards.
+type tuiModel struct {
+	app    *App
+	todos  Todos
+	shown  Todos
+	cursor int
+	offset int
+
+	filter   string
+	finished bool
+	detail   bool
+
+	mode    tuiMode
+	input   textinput.Model
+	message string
+	failed  bool
+
+	width, height int
+}
+
+func newTUIModel(app *App) *tuiModel {
+	input := textinput.New()
+	input.Prompt = "> "
+	return &tuiModel{app: app, input: input}
+}
+
+// readList loads the selected store under a shared lock.
+func (app *App) readList() (TodoList, error) {
+	list := TodoList{}
+	path, err := app.StorePath()
+	if err != nil {
+		return list, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return list, err
+	}
+	lock, err := LockStore(path, false)
+	if err != nil {
+		return list, err
+	}
+	defer lock.Unlock()
+
+	store, _ := OpenStore(app.StoreBackend(), path)
+	if err := store.Load(&list); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return list, err
+	}
+	list.Todos.rollup()
+	return list, nil
+}
+
+// reload reads the tasks again, keeping the cursor on the selected task.
+func (m *tuiModel) reload() {
+	list, err := m.app.readList()
+	if err != nil {
+		m.message, m.failed = err.Error(), true
+		return
+	}
+	m.todos = list.Todos
+	m.refilter()
+}
+
+func (m *tuiModel) refilter() {
+	selected := 0
+	if t, ok := m.selected(); ok {
+		selected = t.ID
+	}
+
+	filter, _ := parseTUIFilter(m.filter)
+	if !m.finished {
+		filter.Statuses = []Status{StatusTodo, StatusInProgress, StatusBlocked}
+	}
+	m.shown = m.todos.Filter(filter).tree(true)
+
+	m.cursor = min(m.cursor, max(len(m.shown)-1, 0))
+	for i, t := range m.shown {
+		if t.ID == selected {
+			m.cursor = i
+		}
+	}
+}
+
+// parseTUIFilter turns the filter text into a TodoFilter: #tags and a
+// +project as in descriptions, the rest matched against the description.
+func parseTUIFilter(text string) (TodoFilter, error) {
+	rest, tags, project, err := parseLabels(text)
+	if err != nil {
+		return TodoFilter{}, err
+	}
+	f := TodoFilter{Tags: tags, Project: project}
+	if rest != "" {
+		f.Grep = regexp.MustCompile("(?i)" + regexp.QuoteMeta(rest))
+	}
+	return f, nil
+}
+
+// selected returns the task under the cursor as stored, without the
+// decorations of the tree view.
+func (m *tuiModel) selected() (Todo, bool) {
+	if m.cursor >= len(m.shown) {
+		return Todo{}, false
+	}
+	index, err := m.todos.IndexOf(m.shown[m.cursor].ID)
+	if err != nil {
+		return Todo{}, false
+	}
+	return m.todos[index], true
+}
+
+// exec runs a task command and shows the first line it printed.
+func (m *tuiModel) exec(args ...string) {
+	var stdout, stderr bytes.Buffer
+	origOut, origErr := m.app.Stdout, m.app.Stderr
+	m.app.Stdout, m.app.Stderr = &stdout, &stderr
+	code := m.app.Run(args)
+	m.app.Stdout, m.app.Stderr = origOut, origErr
+
+	out := stdout.String()
+	if code != ExitOK {
+		out = stderr.String()
+	}
+	m.message, _, _ = strings.Cut(strings.TrimSpace(out), "\n")
+	m.failed = code != ExitOK
+	m.reload()
+}
+
+func (m *tuiModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.input.Width = max(msg.Width-4, 10)
+		return m, nil
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		switch m.mode {
+		case modeBrowse:
+			return m, m.browseKey(msg)
+		case modeDelete:
+			if t, ok := m.selected(); ok && msg.String() == "y" {
+				m.exec("delete", strconv.Itoa(t.ID))
+			} else {
+				m.message, m.failed = "", false
+			}
+			m.mode = modeBrowse
+			return m, nil
+		default:
+			return m, m.inputKey(msg)
+		}
+	}
+	return m, nil
+}
+
+func (m *tuiModel) browseKey(msg tea.KeyMsg) tea.Cmd {
+	t, ok := m.selected()
+	id := strconv.Itoa(t.ID)
+
+	switch msg.String() {
+	case "q":
+		return tea.Quit
+	case "up", "k":
+		m.cursor = max(m.cursor-1, 0)
+	case "down", "j":
+		m.cursor = min(m.cursor+1, max(len(m.shown)-1, 0))
+	case "home", "g":
+		m.cursor = 0
+	case "end", "G":
+		m.cursor = max(len(m.shown)-1, 0)
+	case "enter":
+		m.detail = !m.detail
+	case "a":
+		return m.startInput(modeAdd, "", "description, #tags and +project")
+	case "e":
+		if ok {
+			return m.startInput(modeEdit, t.Description, "")
+		}
+	case "/":
+		return m.startInput(modeFilter, m.filter, "text, #tag or +project")
+	case "f":
+		m.finished = !m.finished
+		m.refilter()
+	case " ":
+		if ok {
+			m.exec("mark", id, string(tuiStatusCycle[t.Status]))
+		}
+	case "t":
+		if ok && t.Running() {
+			m.exec("stop", id)
+		} else if ok {
+			m.exec("start", id)
+		}
+	case "d":
+		if ok {
+			m.mode = modeDelete
+			m.message, m.failed = fmt.Sprintf("Delete task %d? (y/n)", t.ID), false
+		}
+	case "u":
+		m.exec("undo")
+	case "U":
+		m.exec("redo")
+	case "r":
+		m.message, m.failed = "", false
+		m.reload()
+	}
+	return nil
+}
+
+func (m *tuiModel) startInput(mode tuiMode, value, placeholder string) tea.Cmd {
+	m.mode = mode
+	m.input.SetValue(value)
+	m.input.Placeholder = placeholder
+	m.input.CursorEnd()
+	return m.input.Focus()
+}
+
+func (m *tuiModel) inputKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		if m.mode == modeFilter {
+			m.filter = ""
+			m.refilter()
+		}
+		m.mode = modeBrowse
+		m.input.Blur()
+		return nil
+	case "enter":
+		value := strings.TrimSpace(m.input.Value())
+		switch m.mode {
+		case modeAdd:
+			if value != "" {
+				m.exec("add", "--", value)
+			}
+		case modeEdit:
+			if t, ok := m.selected(); ok && value != "" {
+				m.exec("update", strconv.Itoa(t.ID), "--", value)
+			}
+		case modeFilter:
+			if _, err := parseTUIFilter(value); err != nil {
+				m.message, m.failed = err.Error(), true
+				return nil
+			}
+			m.filter = value
+			m.refilter()
+		}
+		m.mode = modeBrowse
+		m.input.Blur()
+		return nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return cmd
+}
+
+func (m *tuiModel) View() string {
+	var b strings.Builder
+
+	title := fmt.Sprintf("task · list %s · %d of %d tasks", m.app.ListName(), len(m.shown), len(m.todos))
+	if m.filter != "" {
+		title += " · filter: " + m.filter
+	}
+	if m.finished {
+		title += " · with finished"
+	}
+	b.WriteString(tuiTitle.Render(title) + "\n\n")
+
+	details := m.details()
+	rows := len(m.shown)
+	if m.height > 0 {
+		// Title, blank line, message and help or input
+		rows = max(m.height-4-len(details), 1)
+	}
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+rows {
+		m.offset = m.cursor - rows + 1
+	}
+
+	now := time.Now()
+	if len(m.shown) == 0 {
+		b.WriteString(tuiFinished.Render("No tasks. Press a to add one.") + "\n")
+	}
+	for i := m.offset; i < len(m.shown) && i < m.offset+rows; i++ {
+		t := m.shown[i]
+		line := fmt.Sprintf(" %4d  %-11s %-7s %s", t.ID, t.Status, t.Priority, t.Description)
+		if t.DueAt != nil {
+			line += "  due " + formatValue(t.DueAt, humanLayout)
+		}
+		if t.Running() {
+			line += "  ⏱"
+		}
+		if m.width > 0 {
+			line = lipgloss.NewStyle().MaxWidth(m.width).Render(line)
+		}
+
+		switch {
+		case i == m.cursor:
+			line = tuiSelected.Render(line)
+		case t.Overdue(now):
+			line = tuiOverdue.Render(line)
+		case t.Status.Finished():
+			line = tuiFinished.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+
+	for _, line := range details {
+		b.WriteString(line + "\n")
+	}
+
+	if m.failed {
+		b.WriteString(tuiError.Render(m.message) + "\n")
+	} else {
+		b.WriteString(m.message + "\n")
+	}
+	if m.mode == modeBrowse || m.mode == modeDelete {
+		b.WriteString(tuiFinished.Render(tuiHelp))
+	} else {
+		b.WriteString(m.input.View())
+	}
+	return b.String()
+}
+
+// details returns the lines of the detail pane of the selected task.
+func (m *tuiModel) details() []string {
+	t, ok := m.selected()
+	if !m.detail || !ok {
+		return nil
+	}
+	lines := []string{""}
+	for _, f := range Fields {
+		value := formatValue(f.Value(t), humanLayout)
+		if value != "" {
+			lines = append(lines, fmt.Sprintf("  %-12s %s", f.Header, value))
+		}
+	}
+	if spent := t.Tracked(time.Time{}, time.Time{}, time.Now()); spent > 0 {
+		lines = append(lines, fmt.Sprintf("  %-12s %s", "Tracked", formatDuration(spent)))
+	}
+	return append(lines, "")
+}
+
+func newTUICmd() *Command {
+	return &Command{
+		Name:    "tui",
+		Summary: "Browse and edit tasks in a full-screen terminal UI.",
+		Flags:   newFlagSet("tui"),
+		NoStore: true,
+		Run: func(ctx *Context, args []string) error {
+			if len(args) > 0 {
+				return usageErrorf("unexpected argument %q", args[0])
+			}
+			m := newTUIModel(ctx.App)
+			m.reload()
+			if m.failed {
+				return errors.New(m.message)
+			}
+			_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+			return err
+		},
+	}
+}
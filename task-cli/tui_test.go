@@ -0,0 +1,119 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Helper to send key presses to the TUI; multi-character strings are typed
+func press(m *tuiModel, keys ...string) {
+	for _, key := range keys {
+		var msg tea.KeyMsg
+		switch key {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		case " ":
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		}
+		m.Update(msg)
+	}
+}
+
+func TestTUIEditing(t *testing.T) {
+	app := newTestApp(t)
+	runCmd(t, app, "add", "Existing task")
+
+	m := newTUIModel(app)
+	m.reload()
+	if len(m.shown) != 1 || !strings.Contains(m.View(), "Existing task") {
+		t.Fatalf("Expected the stored task to be shown, got '%s'", m.View())
+	}
+
+	press(m, "a", "Write docs #docs", "enter")
+	todos := loadTodos(t, app)
+	if len(todos) != 2 || todos[1].Description != "Write docs" || todos[1].Tags[0] != "docs" {
+		t.Fatalf("Expected a task added with a tag, got %+v", todos)
+	}
+	if m.message != "Added task 2" {
+		t.Errorf("Expected the add message, got %q", m.message)
+	}
+
+	press(m, "down", " ")
+	if todos = loadTodos(t, app); todos[1].Status != StatusInProgress {
+		t.Errorf("Expected task 2 in progress, got %s", todos[1].Status)
+	}
+
+	press(m, "e", "!", "enter")
+	if todos = loadTodos(t, app); todos[1].Description != "Write docs!" {
+		t.Errorf("Expected the description edited, got %q", todos[1].Description)
+	}
+
+	// Finished tasks are hidden until f is pressed
+	press(m, " ")
+	if len(m.shown) != 1 || m.shown[0].ID != 1 {
+		t.Errorf("Expected the done task hidden, got %+v", m.shown)
+	}
+	press(m, "f")
+	if len(m.shown) != 2 {
+		t.Errorf("Expected finished tasks shown, got %+v", m.shown)
+	}
+
+	press(m, "d", "n")
+	if len(loadTodos(t, app)) != 2 {
+		t.Error("Expected delete to need a confirmation")
+	}
+	press(m, "d", "y")
+	if todos = loadTodos(t, app); len(todos) != 1 {
+		t.Errorf("Expected a task deleted, got %+v", todos)
+	}
+	press(m, "u")
+	if todos = loadTodos(t, app); len(todos) != 2 || !strings.HasPrefix(m.message, "Undid") {
+		t.Errorf("Expected the delete undone, got %+v (%q)", todos, m.message)
+	}
+}
+
+func TestTUIFilterAndDetails(t *testing.T) {
+	app := newTestApp(t)
+	runCmd(t, app, "add", "Buy milk +home")
+	runCmd(t, app, "add", "Fix bug #work")
+	runCmd(t, app, "add", "--parent", "2", "Write test")
+
+	m := newTUIModel(app)
+	m.reload()
+	if len(m.shown) != 3 || !strings.Contains(m.shown[2].Description, "└─ Write test") {
+		t.Errorf("Expected subtasks in a tree, got %+v", m.shown)
+	}
+
+	press(m, "/", "#work", "enter")
+	if len(m.shown) != 1 || m.shown[0].ID != 2 {
+		t.Errorf("Expected only the work task, got %+v", m.shown)
+	}
+	press(m, "/", "esc")
+	if len(m.shown) != 3 {
+		t.Errorf("Expected esc to clear the filter, got %+v", m.shown)
+	}
+
+	press(m, "/", "milk", "enter", "enter")
+	view := m.View()
+	if !strings.Contains(view, "Project      home") || !strings.Contains(view, "filter: milk") {
+		t.Errorf("Expected the detail pane of task 1, got '%s'", view)
+	}
+
+	// Errors of the command are shown and nothing changes
+	runCmd(t, app, "add", "--blocked-by", "1", "Cook")
+	press(m, "r", "/", "esc", "/", "cook", "enter", " ", " ")
+	if !m.failed || !strings.Contains(m.message, "blocked by open tasks 1") {
+		t.Errorf("Expected the blocked error, got %q", m.message)
+	}
+	if todos := loadTodos(t, app); todos[3].Status != StatusInProgress {
+		t.Errorf("Expected task 4 to stay in progress, got %s", todos[3].Status)
+	}
+}